@@ -11,20 +11,51 @@ import (
 
 // Todo 待办事项
 type Todo struct {
-	Id          int       `json:"id"`
-	Content     string    `json:"content"`
-	CreatedAt   time.Time `json:"created_at"`
-	CompletedAt time.Time `json:"completed_at"`
-	Completed   bool      `json:"completed"`
+	Id             int       `json:"id"`
+	Content        string    `json:"content"`
+	CreatedAt      time.Time `json:"created_at"`
+	CompletedAt    time.Time `json:"completed_at"`
+	Completed      bool      `json:"completed"`
+	CompletionNote string    `json:"completion_note,omitempty"`
+}
+
+// Event 追加到事件日志中的一条记录
+type Event struct {
+	Action    string    `json:"action"`
+	Id        int       `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Content   string    `json:"content,omitempty"`
 }
 
 var (
-	todos        []Todo
-	filePath     = "todo.json"
-	addFlag      string
-	listFlag     bool
-	delFlag      int
-	completeFlag int
+	todos           []Todo
+	filePath        = "todo.json"
+	archivePath     = "todo_archive.json"
+	eventsPath      = "todo_events.jsonl"
+	addFlag         string
+	listFlag        bool
+	delFlag         int
+	completeFlag    int
+	noColorFlag     bool
+	archiveFlag     bool
+	listArchiveFlag bool
+	moveUpFlag      int
+	moveDownFlag    int
+	noteFlag        string
+	showFlag        int
+	logEventsFlag   bool
+	statusFlag      string
+	afterFlag       string
+	beforeFlag      string
+)
+
+// 日期参数的格式
+const dateLayout = "2006-01-02"
+
+// 终端颜色控制码
+const (
+	colorReset    = "\033[0m"
+	colorDimGreen = "\033[2;32m"
 )
 
 func init() {
@@ -33,6 +64,17 @@ func init() {
 	flag.BoolVar(&listFlag, "list", false, "列出所有待办事项")
 	flag.IntVar(&delFlag, "del", 0, "删除指定编号的待办事项")
 	flag.IntVar(&completeFlag, "complete", 0, "完成指定编号的待办事项")
+	flag.StringVar(&noteFlag, "note", "", "与 -complete 一起使用，记录完成备注")
+	flag.IntVar(&showFlag, "show", 0, "显示指定编号的待办事项详情")
+	flag.BoolVar(&noColorFlag, "no-color", false, "禁用彩色输出")
+	flag.BoolVar(&archiveFlag, "archive", false, "将已完成的待办事项移动到归档文件")
+	flag.BoolVar(&listArchiveFlag, "list-archive", false, "列出已归档的待办事项")
+	flag.IntVar(&moveUpFlag, "move-up", 0, "将指定编号的待办事项上移一位")
+	flag.IntVar(&moveDownFlag, "move-down", 0, "将指定编号的待办事项下移一位")
+	flag.BoolVar(&logEventsFlag, "log-events", false, "添加、完成、删除时额外追加事件到 todo_events.jsonl")
+	flag.StringVar(&statusFlag, "status", "all", "与 -list/-list-archive 一起使用，按状态过滤: all, pending, done")
+	flag.StringVar(&afterFlag, "created-after", "", "与 -list/-list-archive 一起使用，只显示该日期(含)之后创建的事项，格式 2006-01-02")
+	flag.StringVar(&beforeFlag, "created-before", "", "与 -list/-list-archive 一起使用，只显示该日期(含)之前创建的事项，格式 2006-01-02")
 	flag.Parse()
 
 	// 加载待办事项
@@ -48,13 +90,30 @@ func main() {
 	case delFlag != 0:
 		delTodo(delFlag)
 	case completeFlag != 0:
-		completeTodo(completeFlag)
+		completeTodo(completeFlag, noteFlag)
+	case showFlag != 0:
+		showTodo(showFlag)
+	case archiveFlag:
+		archiveTodos()
+	case listArchiveFlag:
+		listArchive()
+	case moveUpFlag != 0:
+		moveTodo(moveUpFlag, -1)
+	case moveDownFlag != 0:
+		moveTodo(moveDownFlag, 1)
 	default:
 		fmt.Println("使用方法:")
 		fmt.Println(" - 添加待办: todo -add '要做的事情'")
 		fmt.Println(" - 列出所有待办: todo -list")
+		fmt.Println(" - 过滤列表: todo -list -status done -created-after 2024-01-08 -created-before 2024-01-14")
 		fmt.Println(" - 删除待办: todo -del [Id]")
-		fmt.Println(" - 完成待办: todo -complete [Id]")
+		fmt.Println(" - 完成待办: todo -complete [Id] [-note '完成备注']")
+		fmt.Println(" - 查看详情: todo -show [Id]")
+		fmt.Println(" - 归档已完成: todo -archive")
+		fmt.Println(" - 列出归档: todo -list-archive")
+		fmt.Println(" - 调整顺序: todo -move-up [Id] / todo -move-down [Id]")
+		fmt.Println(" - 禁用颜色: todo -list -no-color (或设置环境变量 NO_COLOR)")
+		fmt.Println(" - 记录事件: todo -log-events -add '要做的事情' (或设置环境变量 TODO_LOG_EVENTS)")
 	}
 }
 
@@ -96,11 +155,57 @@ func saveTodos() {
 	}
 }
 
+// logEvents 判断是否记录事件日志
+func logEvents() bool {
+	return logEventsFlag || os.Getenv("TODO_LOG_EVENTS") != ""
+}
+
+// appendEvent 以追加方式向事件日志写入一行JSON，每条事件单独打开文件并同步到磁盘，
+// 写入失败只给出提示，不影响待办事项本身的保存
+func appendEvent(action string, id int, content string) {
+	if !logEvents() {
+		return
+	}
+
+	line, err := json.Marshal(Event{Action: action, Id: id, Timestamp: time.Now(), Content: content})
+	if err != nil {
+		fmt.Printf("记录事件失败: %v\n\n", err)
+		return
+	}
+
+	file, err := os.OpenFile(eventsPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Printf("记录事件失败: %v\n\n", err)
+		return
+	}
+	defer file.Close()
+
+	// 整行一次写入，避免中途崩溃留下半行
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		fmt.Printf("记录事件失败: %v\n\n", err)
+		return
+	}
+	if err := file.Sync(); err != nil {
+		fmt.Printf("记录事件失败: %v\n\n", err)
+	}
+}
+
 // addTodo 添加待办事项
 func addTodo(content string) {
+	// 列表顺序可以手动调整，最后一项不一定是最大编号
 	id := 1
-	if len(todos) > 0 {
-		id = todos[len(todos)-1].Id + 1
+	for _, todo := range todos {
+		if todo.Id >= id {
+			id = todo.Id + 1
+		}
+	}
+	// 归档事项保留原编号，新编号不能与之重复
+	if archived, err := loadArchive(); err == nil {
+		for _, todo := range archived {
+			if todo.Id >= id {
+				id = todo.Id + 1
+			}
+		}
 	}
 
 	todo := Todo{
@@ -111,37 +216,147 @@ func addTodo(content string) {
 	}
 	todos = append(todos, todo)
 	saveTodos()
+	appendEvent("add", todo.Id, todo.Content)
 	fmt.Printf("添加待办事项成功(Id: %d)\n", todo.Id)
 }
 
 // listTodos 列出所有待办事项
 func listTodos() {
-	if len(todos) == 0 {
-		fmt.Println("没有待办事项")
+	filtered, active, err := filterTodos(todos)
+	if err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return
+	}
+	if active && len(todos) > 0 {
+		printTodos("待办列表:", "没有符合条件的待办事项", filtered)
+		return
+	}
+	printTodos("待办列表:", "没有待办事项", filtered)
+}
+
+// listArchive 列出已归档的待办事项
+func listArchive() {
+	archived, err := loadArchive()
+	if err != nil {
+		fmt.Printf("加载归档失败: %v\n\n", err)
 		return
 	}
-	fmt.Println("待办列表:")
+	filtered, active, err := filterTodos(archived)
+	if err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		return
+	}
+	if active && len(archived) > 0 {
+		printTodos("归档列表:", "没有符合条件的已归档事项", filtered)
+		return
+	}
+	printTodos("归档列表:", "没有已归档的待办事项", filtered)
+}
+
+// filterTodos 按 -status、-created-after、-created-before 过滤列表，多个条件同时满足才保留，
+// 第二个返回值表示是否指定了过滤条件
+func filterTodos(list []Todo) ([]Todo, bool, error) {
+	if statusFlag != "all" && statusFlag != "pending" && statusFlag != "done" {
+		return nil, false, fmt.Errorf("无效的状态 %q (可选: all, pending, done)", statusFlag)
+	}
+	var after, before time.Time
+	if afterFlag != "" {
+		t, err := time.ParseInLocation(dateLayout, afterFlag, time.Local)
+		if err != nil {
+			return nil, false, fmt.Errorf("无效的 -created-after 日期 %q，格式应为 2006-01-02", afterFlag)
+		}
+		after = t
+	}
+	if beforeFlag != "" {
+		t, err := time.ParseInLocation(dateLayout, beforeFlag, time.Local)
+		if err != nil {
+			return nil, false, fmt.Errorf("无效的 -created-before 日期 %q，格式应为 2006-01-02", beforeFlag)
+		}
+		// 包含当天，取第二天零点作为上限
+		before = t.AddDate(0, 0, 1)
+	}
+	if !after.IsZero() && !before.IsZero() && !after.Before(before) {
+		return nil, false, fmt.Errorf("-created-after 不能晚于 -created-before")
+	}
+
+	active := statusFlag != "all" || !after.IsZero() || !before.IsZero()
+	if !active {
+		return list, false, nil
+	}
+
+	var filtered []Todo
+	for _, todo := range list {
+		if statusFlag == "pending" && todo.Completed || statusFlag == "done" && !todo.Completed {
+			continue
+		}
+		if !after.IsZero() && todo.CreatedAt.Before(after) {
+			continue
+		}
+		if !before.IsZero() && !todo.CreatedAt.Before(before) {
+			continue
+		}
+		filtered = append(filtered, todo)
+	}
+	return filtered, true, nil
+}
+
+// printTodos 按统一格式打印待办事项列表
+func printTodos(title, empty string, list []Todo) {
+	if len(list) == 0 {
+		fmt.Println(empty)
+		return
+	}
+	fmt.Println(title)
 	fmt.Println("--------------------------------------------------------------------------------")
 
-	for _, todo := range todos {
+	color := useColor()
+	for _, todo := range list {
 		status := " "
 		if todo.Completed {
 			status = "√"
 		}
-		fmt.Printf("[%s] %d. %s (创建于: %s)\n",
+		line := fmt.Sprintf("[%s] %d. %s (创建于: %s)",
 			status,
 			todo.Id,
 			todo.Content,
 			todo.CreatedAt.Format("2006-01-02 15:04:05"))
+		if todo.Completed && todo.CompletionNote != "" {
+			line += fmt.Sprintf(" 备注: %s", todo.CompletionNote)
+		}
+		if color {
+			line = colorize(todo, line)
+		}
+		fmt.Println(line)
 	}
 }
 
+// useColor 判断是否输出颜色：指定 -no-color、设置 NO_COLOR 或标准输出不是终端时禁用
+func useColor() bool {
+	if noColorFlag || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorize 按待办状态给整行加上颜色，只在行首尾添加控制码，不改变文本列
+func colorize(todo Todo, line string) string {
+	if todo.Completed {
+		return colorDimGreen + line + colorReset
+	}
+	return line
+}
+
 // delTodo 删除指定编号的待办事项
 func delTodo(id int) {
 	for i, todo := range todos {
 		if todo.Id == id {
 			todos = append(todos[:i], todos[i+1:]...)
 			saveTodos()
+			appendEvent("delete", todo.Id, "")
 			fmt.Printf("删除待办事项成功(Id: %d)\n\n", todo.Id)
 			return
 		}
@@ -149,17 +364,152 @@ func delTodo(id int) {
 	fmt.Println("待办事项不存在")
 }
 
-// completeTodo 完成指定编号的待办事项
-func completeTodo(id int) {
+// completeTodo 完成指定编号的待办事项，note 不为空时记录完成备注
+func completeTodo(id int, note string) {
 	for i, todo := range todos {
 		if todo.Id == id {
 			todo.Completed = true
 			todo.CompletedAt = time.Now()
+			if note != "" {
+				todo.CompletionNote = note
+			}
 			todos[i] = todo
 			saveTodos()
+			appendEvent("complete", todo.Id, "")
 			fmt.Printf("完成待办事项(Id: %d)\n\n", todo.Id)
 			return
 		}
 	}
 	fmt.Println("待办事项不存在")
 }
+
+// moveTodo 将指定编号的待办事项与相邻项交换位置，offset 为 -1 上移、1 下移；
+// 列表在文件中的顺序即显示顺序，编号保持不变
+func moveTodo(id, offset int) {
+	for i, todo := range todos {
+		if todo.Id != id {
+			continue
+		}
+		j := i + offset
+		if j < 0 {
+			fmt.Printf("待办事项(Id: %d)已经在最前面，无法上移\n\n", id)
+			return
+		}
+		if j >= len(todos) {
+			fmt.Printf("待办事项(Id: %d)已经在最后面，无法下移\n\n", id)
+			return
+		}
+		todos[i], todos[j] = todos[j], todos[i]
+		saveTodos()
+		direction := "上移"
+		if offset > 0 {
+			direction = "下移"
+		}
+		fmt.Printf("待办事项(Id: %d)已%s到第 %d 位\n\n", id, direction, j+1)
+		return
+	}
+	fmt.Println("待办事项不存在")
+}
+
+// showTodo 显示指定编号的待办事项详情，待办列表中找不到时再查找归档
+func showTodo(id int) {
+	todo, archived, found := findTodo(id)
+	if !found {
+		fmt.Println("待办事项不存在")
+		return
+	}
+
+	fmt.Printf("Id: %d\n", todo.Id)
+	fmt.Printf("内容: %s\n", todo.Content)
+	fmt.Printf("创建于: %s\n", todo.CreatedAt.Format("2006-01-02 15:04:05"))
+	if todo.Completed {
+		fmt.Printf("状态: 已完成 (完成于: %s)\n", todo.CompletedAt.Format("2006-01-02 15:04:05"))
+	} else {
+		fmt.Println("状态: 未完成")
+	}
+	if todo.CompletionNote != "" {
+		fmt.Printf("完成备注: %s\n", todo.CompletionNote)
+	}
+	if archived {
+		fmt.Printf("位置: 归档 (%s)\n", archivePath)
+	}
+}
+
+// findTodo 按编号查找待办事项，archived 表示在归档文件中找到
+func findTodo(id int) (todo Todo, archived bool, found bool) {
+	for _, todo := range todos {
+		if todo.Id == id {
+			return todo, false, true
+		}
+	}
+	list, err := loadArchive()
+	if err != nil {
+		return Todo{}, false, false
+	}
+	for _, todo := range list {
+		if todo.Id == id {
+			return todo, true, true
+		}
+	}
+	return Todo{}, false, false
+}
+
+// loadArchive 加载归档文件，文件不存在时返回空列表
+func loadArchive() ([]Todo, error) {
+	data, err := os.ReadFile(archivePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Todo{}, nil
+		}
+		return nil, err
+	}
+
+	var archived []Todo
+	if err := json.Unmarshal(data, &archived); err != nil {
+		return nil, fmt.Errorf("解析归档文件失败: %v", err)
+	}
+	return archived, nil
+}
+
+// saveArchive 保存归档文件
+func saveArchive(archived []Todo) error {
+	data, err := json.MarshalIndent(archived, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(archivePath, append(data, '\n'), 0644)
+}
+
+// archiveTodos 将已完成的待办事项追加到归档文件，并从待办列表中移除
+func archiveTodos() {
+	var done, active []Todo
+	for _, todo := range todos {
+		if todo.Completed {
+			done = append(done, todo)
+		} else {
+			active = append(active, todo)
+		}
+	}
+	if len(done) == 0 {
+		fmt.Println("没有已完成的待办事项需要归档")
+		return
+	}
+
+	archived, err := loadArchive()
+	if err != nil {
+		fmt.Printf("归档失败: %v\n\n", err)
+		return
+	}
+	// 先写归档再更新待办列表，中途失败时最多出现重复，不会丢失事项
+	if err := saveArchive(append(archived, done...)); err != nil {
+		fmt.Printf("归档失败: %v\n\n", err)
+		return
+	}
+
+	if active == nil {
+		active = []Todo{}
+	}
+	todos = active
+	saveTodos()
+	fmt.Printf("已归档 %d 个已完成的待办事项到 %s\n\n", len(done), archivePath)
+}
@@ -1,70 +1,369 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math/rand"
 	"net"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"sort"
+	"strconv"
 	"sync"
+	"syscall"
 	"time"
 )
 
+// ScanState 扫描进度状态，用于中断后继续扫描
+type ScanState struct {
+	Host          string    `json:"host"`
+	StartPort     int       `json:"start_port"`
+	EndPort       int       `json:"end_port"`
+	Completed     []int     `json:"completed"`
+	OpenPorts     []int     `json:"open_ports"`
+	ClosedPorts   []int     `json:"closed_ports"`
+	FilteredPorts []int     `json:"filtered_ports"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// ScanResult -output json 时输出的扫描结果
+type ScanResult struct {
+	Host          string  `json:"host"`
+	StartPort     int     `json:"start_port"`
+	EndPort       int     `json:"end_port"`
+	Scanned       int     `json:"scanned"`
+	OpenPorts     []int   `json:"open_ports"`
+	ClosedPorts   []int   `json:"closed_ports"`
+	FilteredPorts []int   `json:"filtered_ports"`
+	ErrorPorts    []int   `json:"error_ports,omitempty"`
+	FirstError    string  `json:"first_error,omitempty"`
+	Seconds       float64 `json:"duration_seconds"`
+}
+
+// 端口状态
+const (
+	portOpen     = "open"
+	portClosed   = "closed"   // 连接被拒绝，目标返回了 RST
+	portFiltered = "filtered" // 超时或网络不可达，通常被防火墙丢弃
+	portError    = "error"    // 其他错误，如域名无法解析
+)
+
+// connRefusedErrnos 表示连接被拒绝的错误码，Windows 上为 WSAECONNREFUSED
+var connRefusedErrnos = []syscall.Errno{syscall.ECONNREFUSED, syscall.Errno(10061)}
+
+// classifyDialError 根据 net.DialTimeout 返回的错误判断端口状态：
+// 连接被拒绝为 closed；超时，以及主机/网络不可达等其他系统调用错误为 filtered；
+// 不是来自连接本身的错误（如 DNS 解析失败）为 error
+func classifyDialError(err error) string {
+	if err == nil {
+		return portOpen
+	}
+	if os.IsTimeout(err) {
+		return portFiltered
+	}
+	for _, errno := range connRefusedErrnos {
+		if errors.Is(err, errno) {
+			return portClosed
+		}
+	}
+	var opErr *net.OpError
+	var sysErr *os.SyscallError
+	if errors.As(err, &opErr) && errors.As(err, &sysErr) {
+		return portFiltered
+	}
+	return portError
+}
+
+// stateSaveInterval 扫描过程中保存状态文件的间隔
+const stateSaveInterval = 2 * time.Second
+
+// scanProgress 扫描进度，并发访问时由调用方加锁
+type scanProgress struct {
+	completed     map[int]bool
+	openPorts     []int
+	closedPorts   []int
+	filteredPorts []int
+	errorPorts    []int
+	firstError    string
+}
+
+// newScanProgress 从状态文件恢复进度，state 为 nil 时从头开始
+func newScanProgress(state *ScanState) *scanProgress {
+	progress := &scanProgress{completed: make(map[int]bool)}
+	if state == nil {
+		return progress
+	}
+	for _, port := range state.Completed {
+		progress.completed[port] = true
+	}
+	progress.openPorts = append(progress.openPorts, state.OpenPorts...)
+	progress.closedPorts = append(progress.closedPorts, state.ClosedPorts...)
+	progress.filteredPorts = append(progress.filteredPorts, state.FilteredPorts...)
+	return progress
+}
+
+// pending 返回端口范围内尚未完成的端口
+func (p *scanProgress) pending(startPort, endPort int) []int {
+	ports := make([]int, 0, endPort-startPort+1)
+	for port := startPort; port <= endPort; port++ {
+		if !p.completed[port] {
+			ports = append(ports, port)
+		}
+	}
+	return ports
+}
+
+// record 记录一个端口的连接结果并返回端口状态。
+// error 状态（如域名暂时无法解析）与端口本身无关，不标记为已完成，断点续扫时会重新扫描
+func (p *scanProgress) record(port int, err error) string {
+	status := classifyDialError(err)
+	switch status {
+	case portOpen:
+		p.openPorts = append(p.openPorts, port)
+	case portClosed:
+		p.closedPorts = append(p.closedPorts, port)
+	case portFiltered:
+		p.filteredPorts = append(p.filteredPorts, port)
+	default:
+		p.errorPorts = append(p.errorPorts, port)
+		if p.firstError == "" {
+			p.firstError = err.Error()
+		}
+		return status
+	}
+	p.completed[port] = true
+	return status
+}
+
+// snapshot 生成可写入状态文件的当前进度
+func (p *scanProgress) snapshot(host string, startPort, endPort int) ScanState {
+	state := ScanState{
+		Host:          host,
+		StartPort:     startPort,
+		EndPort:       endPort,
+		Completed:     make([]int, 0, len(p.completed)),
+		OpenPorts:     append([]int{}, p.openPorts...),
+		ClosedPorts:   append([]int{}, p.closedPorts...),
+		FilteredPorts: append([]int{}, p.filteredPorts...),
+		UpdatedAt:     time.Now(),
+	}
+	for port := range p.completed {
+		state.Completed = append(state.Completed, port)
+	}
+	sort.Ints(state.Completed)
+	sort.Ints(state.OpenPorts)
+	sort.Ints(state.ClosedPorts)
+	sort.Ints(state.FilteredPorts)
+	return state
+}
+
 func main() {
 	host := flag.String("host", "localhost", "要扫描的主机地址")
 	startPort := flag.Int("start", 1, "要扫描的起始端口")
 	endPort := flag.Int("end", 1024, "要扫描的结束端口")
 	timeout := flag.Int("timeout", 500, "扫描超时时间（毫秒）")
 	concurrency := flag.Int("con", 100, "扫描的并发数")
+	randomize := flag.Bool("randomize", false, "随机打乱端口扫描顺序")
+	rate := flag.Int("rate", 0, "每秒最多发起的连接数（0表示不限制）")
+	statePath := flag.String("state", "", "扫描状态文件，中断后使用同一文件可继续扫描")
+	output := flag.String("output", "text", "输出格式: text, json")
 	flag.Parse()
 
+	if *output != "text" && *output != "json" {
+		fmt.Println("无效的输出格式，可选值: text, json")
+		os.Exit(0)
+	}
+	// JSON 模式下 stdout 只输出结果，过程信息写到 stderr
+	var log io.Writer = os.Stdout
+	if *output == "json" {
+		log = os.Stderr
+	}
+
 	// 验证端口范围
 	if *startPort < 1 || *startPort > *endPort || *endPort > 65535 {
 		fmt.Println("无效的端口范围，请确保 1 <= 起始端口 <= 结束端口 <= 65535")
 		os.Exit(0)
 	}
+	if *concurrency < 1 || *rate < 0 {
+		fmt.Println("并发数必须大于0，速率不能为负数")
+		os.Exit(0)
+	}
 
-	fmt.Printf("开始扫描 %s 的端口范围 %d-%d...\n", *host, *startPort, *endPort)
-	fmt.Printf("扫描超时为 %d 毫秒，并发数为 %d\n", *timeout, *concurrency)
+	fmt.Fprintf(log, "开始扫描 %s 的端口范围 %d-%d...\n", *host, *startPort, *endPort)
+	fmt.Fprintf(log, "扫描超时为 %d 毫秒，并发数为 %d\n", *timeout, *concurrency)
+
+	// 加载已有的扫描状态，跳过已完成的端口
+	progress := newScanProgress(nil)
+	if *statePath != "" {
+		state, err := loadState(*statePath)
+		if err != nil {
+			fmt.Printf("加载状态文件失败: %v\n", err)
+			os.Exit(1)
+		}
+		if state != nil {
+			if state.Host != *host || state.StartPort != *startPort || state.EndPort != *endPort {
+				fmt.Printf("状态文件记录的是 %s 的端口范围 %d-%d，与本次扫描参数不一致\n",
+					state.Host, state.StartPort, state.EndPort)
+				os.Exit(1)
+			}
+			progress = newScanProgress(state)
+			fmt.Fprintf(log, "从状态文件继续扫描，已完成 %d 个端口，已发现 %d 个开放端口\n",
+				len(progress.completed), len(progress.openPorts))
+		}
+	}
+
+	ports := progress.pending(*startPort, *endPort)
+	if *randomize {
+		rand.Shuffle(len(ports), func(i, j int) { ports[i], ports[j] = ports[j], ports[i] })
+		fmt.Fprintln(log, "已随机打乱扫描顺序")
+	}
+
+	// 限速时每个ticker周期发放一个令牌，未被取走的令牌不会累积
+	var ticker *time.Ticker
+	if *rate > 0 {
+		ticker = time.NewTicker(time.Second / time.Duration(*rate))
+		defer ticker.Stop()
+		fmt.Fprintf(log, "限速为每秒 %d 个连接\n", *rate)
+	}
 
 	startTime := time.Now()
 
 	// 创建带缓冲的通道控制并发数量
 	semaphore := make(chan struct{}, *concurrency)
 	var wg sync.WaitGroup
-	var openPorts []int
 	var mu sync.Mutex
+	snapshot := func() ScanState { return progress.snapshot(*host, *startPort, *endPort) }
 
-	// 遍历端口范围
-	for port := *startPort; port <= *endPort; port++ {
+	// 定期保存进度，收到中断信号时保存后退出
+	done := make(chan struct{})
+	if *statePath != "" {
+		go func() {
+			saveTicker := time.NewTicker(stateSaveInterval)
+			defer saveTicker.Stop()
+			sigCh := make(chan os.Signal, 1)
+			signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+			for {
+				select {
+				case <-saveTicker.C:
+					mu.Lock()
+					state := snapshot()
+					mu.Unlock()
+					if err := saveState(*statePath, state); err != nil {
+						fmt.Fprintf(log, "保存状态文件失败: %v\n", err)
+					}
+				case <-sigCh:
+					mu.Lock()
+					state := snapshot()
+					mu.Unlock()
+					if err := saveState(*statePath, state); err != nil {
+						fmt.Fprintf(log, "保存状态文件失败: %v\n", err)
+						os.Exit(1)
+					}
+					fmt.Fprintf(log, "\n扫描已中断，进度已保存到 %s（已完成 %d 个端口），使用相同参数重新运行即可继续\n",
+						*statePath, len(state.Completed))
+					os.Exit(130)
+				case <-done:
+					return
+				}
+			}
+		}()
+	}
+
+	// 遍历端口列表
+	for _, port := range ports {
 		semaphore <- struct{}{} // 获取信号量
+		if ticker != nil {
+			<-ticker.C // 先占用并发名额再等待令牌，两个限制同时生效
+		}
 		wg.Add(1)
 
 		go func(port int) {
 			defer wg.Done()
 			defer func() { <-semaphore }() // 释放信号量
 
-			address := fmt.Sprintf("%s:%d", *host, port)
+			address := net.JoinHostPort(*host, strconv.Itoa(port))
 			conn, err := net.DialTimeout("tcp", address, time.Duration(*timeout)*time.Millisecond)
 
 			if err == nil {
 				conn.Close()
-				mu.Lock()
-				openPorts = append(openPorts, port)
-				mu.Unlock()
-				fmt.Printf("端口 %d 已开放\n", port)
 			}
+
+			mu.Lock()
+			if progress.record(port, err) == portOpen {
+				fmt.Fprintf(log, "端口 %d 已开放\n", port)
+			}
+			mu.Unlock()
 		}(port)
 	}
 
 	wg.Wait()
+	close(done)
 	duration := time.Since(startTime)
 
-	// 排序开放端口
+	// 扫描完成后删除状态文件，下次使用同一文件时重新开始；
+	// 有出错的端口时保留状态文件，重新运行只重试这些端口
+	if *statePath != "" {
+		if len(progress.errorPorts) > 0 {
+			if err := saveState(*statePath, snapshot()); err != nil {
+				fmt.Fprintf(log, "保存状态文件失败: %v\n", err)
+			} else {
+				fmt.Fprintf(log, "有 %d 个端口出错，状态文件 %s 已保留，使用相同参数重新运行将只重试这些端口\n",
+					len(progress.errorPorts), *statePath)
+			}
+		} else if err := os.Remove(*statePath); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(log, "删除状态文件失败: %v\n", err)
+		}
+	}
+
+	openPorts, closedPorts, filteredPorts := progress.openPorts, progress.closedPorts, progress.filteredPorts
+	errorPorts, firstError := progress.errorPorts, progress.firstError
+
+	// 排序各类端口
 	sort.Ints(openPorts)
+	sort.Ints(closedPorts)
+	sort.Ints(filteredPorts)
+	sort.Ints(errorPorts)
+
+	if *output == "json" {
+		result := ScanResult{
+			Host:          *host,
+			StartPort:     *startPort,
+			EndPort:       *endPort,
+			Scanned:       len(ports),
+			OpenPorts:     nonNil(openPorts),
+			ClosedPorts:   nonNil(closedPorts),
+			FilteredPorts: nonNil(filteredPorts),
+			ErrorPorts:    errorPorts,
+			FirstError:    firstError,
+			Seconds:       duration.Seconds(),
+		}
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Fprintf(log, "生成JSON失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+		return
+	}
 
 	fmt.Printf("扫描完成，耗时 %s\n", duration)
-	fmt.Printf("共扫描 %d 个端口\n", *endPort-*startPort+1)
+	fmt.Printf("共扫描 %d 个端口，平均每秒 %.1f 个\n", len(ports), float64(len(ports))/duration.Seconds())
+	if skipped := *endPort - *startPort + 1 - len(ports); skipped > 0 {
+		fmt.Printf("跳过状态文件中已完成的 %d 个端口\n", skipped)
+	}
+	fmt.Printf("开放: %d 个，关闭: %d 个（连接被拒绝），过滤: %d 个（超时或不可达）\n",
+		len(openPorts), len(closedPorts), len(filteredPorts))
+	if len(errorPorts) > 0 {
+		fmt.Printf("出错: %d 个，首个错误: %s\n", len(errorPorts), firstError)
+	}
+	// 没有响应的端口占绝大多数时，通常说明中间有防火墙在丢弃数据包
+	if notOpen := len(closedPorts) + len(filteredPorts); notOpen > 0 && len(filteredPorts)*10 >= notOpen*9 {
+		fmt.Println("提示: 绝大多数端口超时无响应，目标可能位于防火墙之后；可适当增大 -timeout 排除网络延迟的影响")
+	}
 
 	if len(openPorts) > 0 {
 		fmt.Println("开放端口列表：")
@@ -75,3 +374,56 @@ func main() {
 		fmt.Println("没有开放端口")
 	}
 }
+
+// nonNil 使空列表在JSON中输出为 [] 而不是 null
+func nonNil(ports []int) []int {
+	if ports == nil {
+		return []int{}
+	}
+	return ports
+}
+
+// loadState 读取扫描状态文件，文件不存在时返回nil
+func loadState(path string) (*ScanState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var state ScanState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析状态文件失败: %v", err)
+	}
+	return &state, nil
+}
+
+// saveState 先写入同目录的临时文件再重命名，保证状态文件不会被写坏
+func saveState(path string, state ScanState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+// 出错的端口不记为已完成，断点续扫时重新扫描
+func TestResumeRetriesErrorPorts(t *testing.T) {
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	dnsErr := &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "temporary failure in name resolution", Name: "example.test"}}
+
+	progress := newScanProgress(nil)
+	if got := progress.record(1, nil); got != portOpen {
+		t.Fatalf("端口 1 状态 = %s, 期望 %s", got, portOpen)
+	}
+	if got := progress.record(2, refused); got != portClosed {
+		t.Fatalf("端口 2 状态 = %s, 期望 %s", got, portClosed)
+	}
+	if got := progress.record(3, dnsErr); got != portError {
+		t.Fatalf("端口 3 状态 = %s, 期望 %s", got, portError)
+	}
+	if progress.firstError == "" {
+		t.Error("出错时应记录首个错误信息")
+	}
+
+	path := filepath.Join(t.TempDir(), "scan.state")
+	if err := saveState(path, progress.snapshot("example.test", 1, 4)); err != nil {
+		t.Fatalf("保存状态文件失败: %v", err)
+	}
+	state, err := loadState(path)
+	if err != nil || state == nil {
+		t.Fatalf("加载状态文件失败: %v", err)
+	}
+
+	resumed := newScanProgress(state)
+	if got, want := resumed.pending(1, 4), []int{3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("续扫待扫描端口 = %v, 期望 %v", got, want)
+	}
+	if !reflect.DeepEqual(resumed.openPorts, []int{1}) || !reflect.DeepEqual(resumed.closedPorts, []int{2}) {
+		t.Errorf("恢复的开放/关闭端口 = %v/%v, 期望 [1]/[2]", resumed.openPorts, resumed.closedPorts)
+	}
+	if len(resumed.errorPorts) != 0 {
+		t.Errorf("恢复后出错端口 = %v, 期望为空（等待重试）", resumed.errorPorts)
+	}
+
+	// 重试成功后计入对应状态
+	resumed.record(3, refused)
+	resumed.record(4, refused)
+	if got := resumed.pending(1, 4); len(got) != 0 {
+		t.Errorf("重试后仍有待扫描端口: %v", got)
+	}
+	if !reflect.DeepEqual(resumed.closedPorts, []int{2, 3, 4}) {
+		t.Errorf("重试后关闭端口 = %v, 期望 [2 3 4]", resumed.closedPorts)
+	}
+}
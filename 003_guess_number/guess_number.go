@@ -1,10 +1,13 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
 	"math/rand"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -25,6 +28,136 @@ func generateTarget() int {
 	return rand.Intn(maxNum-minNum+1) + minNum
 }
 
+// 游戏模式
+const (
+	modeNumber = "number" // 猜数字
+	modeWord   = "word"   // 猜单词（hangman）
+)
+
+// 猜单词模式的词库
+var wordList = []string{
+	"golang", "channel", "goroutine", "interface", "pointer", "compiler", "variable",
+	"function", "package", "module", "runtime", "closure", "struct", "slice", "mutex",
+	"keyboard", "monitor", "network", "program", "library", "terminal", "database",
+}
+
+// game 一局游戏的规则，主循环负责计次、计时和退出处理
+type game interface {
+	// status 返回每轮输入前显示的当前状态，没有时返回空字符串
+	status() string
+	// play 处理一次输入；err 不为空表示输入无效，不计入猜测次数；
+	// wasted 为 true 时消耗一次机会
+	play(input string) (wasted, won bool, err error)
+	// answer 返回本局的答案
+	answer() string
+}
+
+// numberGame 猜数字，每次猜测都消耗一次机会
+type numberGame struct {
+	target int
+}
+
+func (g *numberGame) status() string { return "" }
+
+func (g *numberGame) play(input string) (bool, bool, error) {
+	guess, err := parseInput(input)
+	if err != nil {
+		return false, false, err
+	}
+	if guess == g.target {
+		fmt.Println("恭喜你，猜对了！")
+		return true, true, nil
+	} else if guess < g.target {
+		fmt.Println("猜小了")
+	} else {
+		fmt.Println("猜大了")
+	}
+	return true, false, nil
+}
+
+func (g *numberGame) answer() string { return strconv.Itoa(g.target) }
+
+// wordGame 猜单词，只有猜错才消耗机会
+type wordGame struct {
+	secret  string
+	guessed map[rune]bool // 已猜过的字母
+	wrong   []rune        // 猜错的字母，按猜测顺序
+}
+
+func newWordGame() *wordGame {
+	return &wordGame{
+		secret:  wordList[rand.Intn(len(wordList))],
+		guessed: make(map[rune]bool),
+	}
+}
+
+// revealed 返回部分揭晓的单词，未猜中的字母显示为 _
+func (g *wordGame) revealed() string {
+	letters := make([]string, 0, len(g.secret))
+	for _, letter := range g.secret {
+		if g.guessed[letter] {
+			letters = append(letters, string(letter))
+		} else {
+			letters = append(letters, "_")
+		}
+	}
+	return strings.Join(letters, " ")
+}
+
+func (g *wordGame) status() string {
+	line := fmt.Sprintf("单词: %s (%d个字母)", g.revealed(), len(g.secret))
+	if len(g.wrong) > 0 {
+		wrong := make([]string, len(g.wrong))
+		for i, letter := range g.wrong {
+			wrong[i] = string(letter)
+		}
+		line += "  猜错: " + strings.Join(wrong, ", ")
+	}
+	return line
+}
+
+// play 输入一个字母时揭晓所有匹配的位置；输入多个字母时视为猜整个单词
+func (g *wordGame) play(input string) (bool, bool, error) {
+	input = strings.ToLower(input)
+	for _, letter := range input {
+		if letter < 'a' || letter > 'z' {
+			return false, false, fmt.Errorf("请输入英文字母")
+		}
+	}
+
+	if len(input) > 1 {
+		if input == g.secret {
+			for _, letter := range g.secret {
+				g.guessed[letter] = true
+			}
+			fmt.Println("恭喜你，猜对了！")
+			return false, true, nil
+		}
+		fmt.Println("单词不对")
+		return true, false, nil
+	}
+
+	letter := rune(input[0])
+	if g.guessed[letter] {
+		return false, false, fmt.Errorf("字母 %c 已经猜过了", letter)
+	}
+	g.guessed[letter] = true
+
+	if count := strings.Count(g.secret, input); count > 0 {
+		fmt.Printf("猜中了，单词中有%d个 %c\n", count, letter)
+		if !strings.Contains(g.revealed(), "_") {
+			fmt.Printf("恭喜你，猜对了！单词是 %s\n", g.secret)
+			return false, true, nil
+		}
+		return false, false, nil
+	}
+	g.wrong = append(g.wrong, letter)
+	fmt.Printf("单词中没有 %c\n", letter)
+	return true, false, nil
+}
+
+func (g *wordGame) answer() string { return g.secret }
+
 // 解析用户输入
 func parseInput(input string) (int, error) {
 	number, err := strconv.Atoi(input)
@@ -37,6 +170,31 @@ func parseInput(input string) (int, error) {
 	return number, nil
 }
 
+// 计时方式
+const (
+	timerPerGuess = "guess" // 每次猜测单独计时
+	timerTotal    = "total" // 整局游戏共用一个时限
+)
+
+// readLines 在单独的goroutine中逐行读取标准输入，整局游戏只启动一次。
+// 游戏结束时关闭 done，goroutine 不会阻塞在发送上而是直接退出；
+// 阻塞中的读取无法取消，随程序退出结束
+func readLines(done <-chan struct{}) <-chan string {
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			select {
+			case lines <- strings.TrimSpace(scanner.Text()):
+			case <-done:
+				return
+			}
+		}
+	}()
+	return lines
+}
+
 // 显示游戏帮助信息
 func showHelp() {
 	fmt.Println("猜数字游戏规则:")
@@ -47,26 +205,107 @@ func showHelp() {
 	fmt.Println("--------------------------------------------------------")
 }
 
+// showWordHelp 显示猜单词模式的规则
+func showWordHelp() {
+	fmt.Println("猜单词游戏规则:")
+	fmt.Println("1.系统会从词库中随机选出一个英文单词，显示为一排 _")
+	fmt.Println("2.每次输入一个字母，猜中时揭晓它在单词中的所有位置")
+	fmt.Printf("3.猜错%d次游戏结束，猜中字母不消耗机会\n", maxTries)
+	fmt.Println("4.知道答案时可以直接输入整个单词，猜错同样消耗一次机会")
+	fmt.Println("5.输入 'quit' 可以退出游戏")
+	fmt.Println("--------------------------------------------------------")
+}
+
+// showTimerHelp 显示限时模式说明
+func showTimerHelp(limit time.Duration, mode string) {
+	if mode == timerTotal {
+		fmt.Printf("限时模式: 必须在%v内猜出答案，超时游戏结束\n", limit)
+	} else {
+		fmt.Printf("限时模式: 每次猜测必须在%v内输入，超时游戏结束\n", limit)
+	}
+	fmt.Println("--------------------------------------------------------")
+}
+
 // 猜数字游戏
 func main() {
+	mode := flag.String("mode", modeNumber, "游戏模式: number 猜数字, word 猜单词")
+	timer := flag.Duration("timer", 0, "限时模式的时间限制 (如: 30s)，0 表示不限时")
+	timerMode := flag.String("timer-mode", timerPerGuess, "计时方式: guess 每次猜测计时, total 整局计时")
+	flag.Parse()
+
+	if *timer < 0 {
+		fmt.Println("参数错误: -timer 不能为负数")
+		os.Exit(2)
+	}
+	if *timerMode != timerPerGuess && *timerMode != timerTotal {
+		fmt.Printf("参数错误: 未知的计时方式 %s (可选: guess, total)\n", *timerMode)
+		os.Exit(2)
+	}
+
 	// 游戏初始化
-	tartget := generateTarget()
-	tries := 0
+	var g game
+	switch *mode {
+	case modeNumber:
+		g = &numberGame{target: generateTarget()}
+		fmt.Println("欢迎来到猜数字游戏! ")
+		showHelp()
+	case modeWord:
+		g = newWordGame()
+		fmt.Println("欢迎来到猜单词游戏! ")
+		showWordHelp()
+	default:
+		fmt.Printf("参数错误: 未知的游戏模式 %s (可选: number, word)\n", *mode)
+		os.Exit(2)
+	}
+	if *timer > 0 {
+		showTimerHelp(*timer, *timerMode)
+	}
+
+	tries := 0   // 已消耗的机会
+	guesses := 0 // 有效猜测次数
 	won := false
+	timedOut := false
 
-	// 欢迎信息
-	fmt.Println("欢迎来到猜数字游戏! ")
-	showHelp()
+	done := make(chan struct{})
+	defer close(done)
+	lines := readLines(done)
+
+	start := time.Now()
+	deadline := start.Add(*timer)
 
 	// 循环进行游戏
 	for tries < maxTries {
 		remaining := maxTries - tries
-		fmt.Printf("\n请输入你的猜测 (还剩%d次机会): ", remaining)
+		if status := g.status(); status != "" {
+			fmt.Printf("\n%s", status)
+		}
+		// 未开启限时模式时 timeout 为 nil，select 永远不会选中它
+		var timeout <-chan time.Time
+		if *timer > 0 {
+			left := time.Until(deadline)
+			timeout = time.After(left)
+			fmt.Printf("\n请输入你的猜测 (还剩%d次机会, 剩余时间%v): ", remaining, left.Round(100*time.Millisecond))
+		} else {
+			fmt.Printf("\n请输入你的猜测 (还剩%d次机会): ", remaining)
+		}
 
-		// 读取用户输入
+		// 读取用户输入，与计时器竞争
 		var input string
-		_, err := fmt.Scanln(&input)
-		if err != nil {
+		var ok bool
+		select {
+		case input, ok = <-lines:
+		case <-timeout:
+			timedOut = true
+		}
+		if timedOut {
+			fmt.Println("\n时间到！")
+			break
+		}
+		if !ok {
+			fmt.Println("\n输入已结束")
+			break
+		}
+		if input == "" || strings.ContainsAny(input, " \t") {
 			fmt.Println("输入错误，请重新输入")
 			continue
 		}
@@ -76,31 +315,34 @@ func main() {
 			os.Exit(0)
 		}
 
-		// 解析并验证输入
-		guess, err := parseInput(input)
+		// 按当前模式的规则判断猜测结果
+		wasted, correct, err := g.play(input)
 		if err != nil {
 			fmt.Println(err)
 			continue
 		}
 
-		// 增加尝试次数
-		tries++
-
-		// 判断猜测结果
-		if guess == tartget {
-			fmt.Println("恭喜你，猜对了！")
+		// 增加尝试次数，每次计时从下一次猜测开始重新计算
+		guesses++
+		if wasted {
+			tries++
+		}
+		if *timerMode == timerPerGuess {
+			deadline = time.Now().Add(*timer)
+		}
+		if correct {
 			won = true
 			break
-		} else if guess < tartget {
-			fmt.Println("猜小了")
-		} else {
-			fmt.Println("猜大了")
 		}
 	}
 
 	if won {
-		fmt.Printf("游戏结束，你猜了%d次，恭喜你猜对了！", tries)
+		elapsed := time.Since(start)
+		fmt.Printf("游戏结束，你猜了%d次，恭喜你猜对了！\n", guesses)
+		fmt.Printf("用时%v，平均每次猜测%v", elapsed.Round(time.Millisecond), (elapsed / time.Duration(guesses)).Round(time.Millisecond))
+	} else if timedOut {
+		fmt.Printf("游戏结束，你猜了%d次，超时未猜出，答案是%s！", guesses, g.answer())
 	} else {
-		fmt.Printf("游戏结束，你猜了%d次，没有猜对，游戏结束！", tries)
+		fmt.Printf("游戏结束，你猜了%d次，没有猜对，答案是%s！", guesses, g.answer())
 	}
 }
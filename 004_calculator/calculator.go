@@ -1,7 +1,13 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
 )
 
 /**
@@ -31,43 +37,247 @@ func div(a, b float64) (float64, error) {
 	return a / b, nil
 }
 
-// 计算器
-func calculator() {
+// 解析数字，支持十进制以及 0x(十六进制)、0b(二进制)、0o(八进制) 前缀的整数
+func parseNumber(s string) (float64, error) {
+	digits := strings.ToLower(strings.TrimLeft(s, "+-"))
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0b") || strings.HasPrefix(digits, "0o") {
+		n, err := strconv.ParseInt(s, 0, 64)
+		if err != nil {
+			return 0, fmt.Errorf("无效的数字: %s", s)
+		}
+		return float64(n), nil
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无效的数字: %s", s)
+	}
+	return n, nil
+}
+
+// 按指定进制格式化结果，非整数结果退回十进制显示
+func formatResult(v float64, base string) string {
+	if base == "dec" {
+		return fmt.Sprintf("%.2f", v)
+	}
+	if v != math.Trunc(v) {
+		return fmt.Sprintf("%.2f (非整数结果，以十进制显示)", v)
+	}
+	if math.Abs(v) >= 1<<63 {
+		return fmt.Sprintf("%.2f (超出64位整数范围，以十进制显示)", v)
+	}
+
+	n := int64(v)
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	switch base {
+	case "hex":
+		return fmt.Sprintf("%s0x%X", sign, n)
+	case "bin":
+		return fmt.Sprintf("%s0b%b", sign, n)
+	}
+	return fmt.Sprintf("%.2f", v)
+}
+
+// 可在表达式中直接使用的常量
+var constants = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+// Calculator 计算器状态：显示进制、角度单位、记忆寄存器和上一次的计算结果
+type Calculator struct {
+	base    string
+	angle   string // 三角函数参数的单位: deg 或 rad
+	memory  float64
+	last    float64
+	hasLast bool
+}
+
+// NewCalculator 创建计算器，记忆寄存器初始为0
+func NewCalculator(base, angle string) *Calculator {
+	return &Calculator{base: base, angle: angle}
+}
+
+// operand 解析操作数：MR 表示取出记忆寄存器中的值，pi、e 为常量，
+// sin(x)、cos(x)、tan(x) 为三角函数，参数同样可以是数字、常量或 MR
+func (c *Calculator) operand(s string) (float64, error) {
+	if strings.EqualFold(s, "MR") {
+		return c.memory, nil
+	}
+	if v, ok := constants[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	if open := strings.Index(s, "("); open > 0 && strings.HasSuffix(s, ")") {
+		arg, err := c.operand(s[open+1 : len(s)-1])
+		if err != nil {
+			return 0, err
+		}
+		return c.trig(strings.ToLower(s[:open]), arg)
+	}
+	return parseNumber(s)
+}
+
+// trig 按当前角度单位计算三角函数
+func (c *Calculator) trig(name string, x float64) (float64, error) {
+	rad := x
+	if c.angle == "deg" {
+		rad = x * math.Pi / 180
+	}
+	switch name {
+	case "sin":
+		return math.Sin(rad), nil
+	case "cos":
+		return math.Cos(rad), nil
+	case "tan":
+		// 角度制下 90°+k*180° 可以精确判断，弧度制的 pi/2 无法精确表示，直接计算
+		if c.angle == "deg" && math.Mod(math.Abs(x), 180) == 90 {
+			return 0, fmt.Errorf("tan(%g) 无定义", x)
+		}
+		return math.Tan(rad), nil
+	}
+	return 0, fmt.Errorf("未知的函数: %s (支持 sin、cos、tan)", name)
+}
+
+// angleCommand 执行 deg、rad 命令切换角度单位，不是角度命令时返回 false
+func (c *Calculator) angleCommand(cmd string) bool {
+	switch strings.ToLower(cmd) {
+	case "deg", "rad":
+		c.angle = strings.ToLower(cmd)
+	default:
+		return false
+	}
+	fmt.Printf("角度单位: %s\n", c.angle)
+	return true
+}
+
+// memoryCommand 执行记忆寄存器命令 M+、M-、MC，不是记忆命令时返回 false
+func (c *Calculator) memoryCommand(cmd string) bool {
+	switch strings.ToUpper(cmd) {
+	case "M+", "M-":
+		if !c.hasLast {
+			fmt.Println("错误: 还没有计算结果")
+			return true
+		}
+		if cmd[1] == '+' {
+			c.memory += c.last
+		} else {
+			c.memory -= c.last
+		}
+	case "MC":
+		c.memory = 0
+	default:
+		return false
+	}
+	fmt.Printf("记忆: %s\n", formatResult(c.memory, c.base))
+	return true
+}
+
+// calculate 计算一个二元表达式
+func calculate(a float64, op string, b float64) (float64, error) {
+	switch op {
+	case "+":
+		return add(a, b), nil
+	case "-":
+		return sub(a, b), nil
+	case "*":
+		return mul(a, b), nil
+	case "/":
+		return div(a, b)
+	}
+	return 0, fmt.Errorf("无效的操作符")
+}
+
+// Evaluate 计算一行 "数字 操作符 数字" 或单个操作数（如 sin(30)）形式的表达式，成功时记为上一次的结果
+func (c *Calculator) Evaluate(line string) (float64, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 1 && len(fields) != 3 {
+		return 0, fmt.Errorf("表达式格式应为: 数字 操作符 数字 (用空格分隔)，或单个数字")
+	}
+
+	a, err := c.operand(fields[0])
+	if err != nil {
+		return 0, err
+	}
+	if len(fields) == 1 {
+		c.last = a
+		c.hasLast = true
+		return a, nil
+	}
+	b, err := c.operand(fields[2])
+	if err != nil {
+		return 0, err
+	}
+	result, err := calculate(a, fields[1], b)
+	if err != nil {
+		return 0, err
+	}
+	c.last = result
+	c.hasLast = true
+	return result, nil
+}
+
+// Run 计算器主循环
+func (c *Calculator) Run() {
 	fmt.Println("=== 简单计算器 ===")
 	fmt.Println("支持的操作: +, -, *, /")
+	fmt.Println("支持的数字: 十进制, 0x1F(十六进制), 0b1010(二进制), 0o17(八进制)")
+	fmt.Println("记忆功能: M+ 结果加入记忆, M- 从记忆中减去结果, MR 在表达式中取出记忆 (如: MR * 2), MC 清除记忆")
+	fmt.Println("常量: pi, e (如: 2 * pi)")
+	fmt.Println("三角函数: sin(x), cos(x), tan(x)，括号内不含空格 (如: sin(90), cos(60)；rad 模式下如 cos(pi))")
+	fmt.Println("角度单位: deg 切换为角度制, rad 切换为弧度制")
+	fmt.Printf("结果显示进制: %s\n", c.base)
+	fmt.Printf("角度单位: %s\n", c.angle)
 	fmt.Println("输入 'exit' 退出")
 
-	var a, b float64
-	var op string
+	// 按行读取输入，出错的行整行丢弃，不会影响下一行的解析
+	scanner := bufio.NewScanner(os.Stdin)
 	for {
 		fmt.Print("请输入表达式 (例如: 3 + 4)")
-		fmt.Scan(&a, &op, &b)
+		if !scanner.Scan() {
+			fmt.Println()
+			fmt.Println("输入结束，退出计算器")
+			break
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
 
-		if op == "exit" {
+		if line == "exit" {
 			fmt.Println("退出计算器")
 			break
 		}
+		if c.memoryCommand(line) || c.angleCommand(line) {
+			continue
+		}
 
-		switch op {
-		case "+":
-			fmt.Printf("结果: %.2f\n", add(a, b))
-		case "-":
-			fmt.Printf("结果: %.2f\n", sub(a, b))
-		case "*":
-			fmt.Printf("结果: %.2f\n", mul(a, b))
-		case "/":
-			result, err := div(a, b)
-			if err != nil {
-				fmt.Println("错误:", err)
-			} else {
-				fmt.Printf("结果: %.2f\n", result)
-			}
-		default:
-			fmt.Println("无效的操作符")
+		result, err := c.Evaluate(line)
+		if err != nil {
+			fmt.Println("错误:", err)
+			continue
 		}
+		fmt.Printf("结果: %s\n", formatResult(result, c.base))
 	}
 }
 
 func main() {
-	calculator()
+	base := flag.String("base", "dec", "结果显示进制 (dec/hex/bin)，仅对整数结果生效")
+	angle := flag.String("angle", "deg", "三角函数的角度单位 (deg/rad)")
+	flag.Parse()
+
+	switch *base {
+	case "dec", "hex", "bin":
+	default:
+		fmt.Println("无效的进制，可选值: dec, hex, bin")
+		os.Exit(1)
+	}
+	if *angle != "deg" && *angle != "rad" {
+		fmt.Println("无效的角度单位，可选值: deg, rad")
+		os.Exit(1)
+	}
+
+	NewCalculator(*base, *angle).Run()
 }
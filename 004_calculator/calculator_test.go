@@ -0,0 +1,152 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+// 某一行出错后，下一行的计算不受影响
+func TestEvaluateRecoversAfterError(t *testing.T) {
+	c := NewCalculator("dec", "deg")
+
+	if _, err := c.Evaluate("5 / 0"); err == nil {
+		t.Fatal("5 / 0 应返回错误")
+	}
+	got, err := c.Evaluate("2 + 2")
+	if err != nil {
+		t.Fatalf("2 + 2 返回错误: %v", err)
+	}
+	if got != 4 {
+		t.Errorf("2 + 2 = %v, 期望 4", got)
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    float64
+		wantErr bool
+	}{
+		{"42", 42, false},
+		{"-1.5", -1.5, false},
+		{"0x1F", 31, false},
+		{"0X1f", 31, false},
+		{"-0x10", -16, false},
+		{"0b1010", 10, false},
+		{"+0b11", 3, false},
+		{"0o17", 15, false},
+		{"0x", 0, true},
+		{"0b102", 0, true},
+		{"0o8", 0, true},
+		{"abc", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseNumber(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseNumber(%q) 错误 = %v, 期望出错: %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseNumber(%q) = %v, 期望 %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateMixedBases(t *testing.T) {
+	tests := []struct {
+		line string
+		want float64
+	}{
+		{"0x10 + 0b1", 17},
+		{"0o10 * 0x2", 16},
+		{"0b11 - 0x4", -1},
+		{"0xFF / 0b101", 51},
+		{"1.5 + 0x1", 2.5},
+	}
+	for _, tt := range tests {
+		c := NewCalculator("dec", "deg")
+		got, err := c.Evaluate(tt.line)
+		if err != nil {
+			t.Errorf("Evaluate(%q) 返回错误: %v", tt.line, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Evaluate(%q) = %v, 期望 %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestFormatResult(t *testing.T) {
+	tests := []struct {
+		value float64
+		base  string
+		want  string
+	}{
+		{3, "dec", "3.00"},
+		{-2.5, "dec", "-2.50"},
+		{255, "hex", "0xFF"},
+		{-255, "hex", "-0xFF"},
+		{0, "hex", "0x0"},
+		{5, "bin", "0b101"},
+		{-5, "bin", "-0b101"},
+		{2.5, "hex", "2.50 (非整数结果，以十进制显示)"},
+		{-0.5, "bin", "-0.50 (非整数结果，以十进制显示)"},
+		{1e19, "hex", "10000000000000000000.00 (超出64位整数范围，以十进制显示)"},
+		{math.Pow(2, 64), "bin", "18446744073709551616.00 (超出64位整数范围，以十进制显示)"},
+		{-math.Pow(2, 63), "hex", "-9223372036854775808.00 (超出64位整数范围，以十进制显示)"},
+		{math.Pow(2, 62), "hex", "0x4000000000000000"},
+	}
+	for _, tt := range tests {
+		if got := formatResult(tt.value, tt.base); got != tt.want {
+			t.Errorf("formatResult(%v, %q) = %q, 期望 %q", tt.value, tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestTrigDegrees(t *testing.T) {
+	c := NewCalculator("dec", "deg")
+	got, err := c.Evaluate("sin(90)")
+	if err != nil {
+		t.Fatalf("sin(90) 返回错误: %v", err)
+	}
+	if math.Abs(got-1) > 1e-9 {
+		t.Errorf("sin(90) = %v, 期望 1", got)
+	}
+	for _, line := range []string{"tan(90)", "tan(-90)", "tan(270)"} {
+		if _, err := c.Evaluate(line); err == nil {
+			t.Errorf("角度制下 %s 应返回错误", line)
+		}
+	}
+}
+
+func TestAngleToggle(t *testing.T) {
+	c := NewCalculator("dec", "deg")
+	if !c.angleCommand("rad") {
+		t.Fatal("rad 应被识别为角度单位命令")
+	}
+	got, err := c.Evaluate("cos(pi)")
+	if err != nil {
+		t.Fatalf("cos(pi) 返回错误: %v", err)
+	}
+	if math.Abs(got+1) > 1e-9 {
+		t.Errorf("弧度制下 cos(pi) = %v, 期望 -1", got)
+	}
+
+	if !c.angleCommand("DEG") {
+		t.Fatal("DEG 应被识别为角度单位命令")
+	}
+	got, err = c.Evaluate("cos(60)")
+	if err != nil {
+		t.Fatalf("cos(60) 返回错误: %v", err)
+	}
+	if math.Abs(got-0.5) > 1e-9 {
+		t.Errorf("角度制下 cos(60) = %v, 期望 0.5", got)
+	}
+
+	if c.angleCommand("grad") {
+		t.Error("grad 不应被识别为角度单位命令")
+	}
+	if c.angle != "deg" {
+		t.Errorf("无效命令后角度单位 = %s, 期望 deg", c.angle)
+	}
+}
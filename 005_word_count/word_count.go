@@ -2,47 +2,99 @@ package main
 
 import (
 	"bufio"
+	"flag"
 	"fmt"
 	"os"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 /**
  * 单词计数程序
  */
 
-// 统计字符串中每个单词出现的次数
-func countWords(s string) map[string]int {
+// 默认的单词模式：连续的字母、数字和撇号，使 don't、it's 等缩写保持完整
+const defaultWordRegex = `[\p{L}\p{N}']+`
+
+// 统计字符串中每个单词出现的次数，单词为 wordRe 的每个非空匹配
+func countWords(s string, wordRe *regexp.Regexp) map[string]int {
 	words := make(map[string]int)
 
-	// 将字符串转为小写
-	lowercase := strings.ToLower(s)
+	// 将字符串转为小写后按模式提取单词
+	for _, word := range wordRe.FindAllString(strings.ToLower(s), -1) {
+		if word != "" {
+			words[word]++
+		}
+	}
+	return words
+}
 
-	// 构建单词
-	var currentWord strings.Builder
+// 统计字符串中每个字符出现的次数，按 rune 统计，多字节字符计为一个字符
+func countChars(s string, skipWhitespace bool) map[rune]int {
+	chars := make(map[rune]int)
+	for _, r := range s {
+		if skipWhitespace && unicode.IsSpace(r) {
+			continue
+		}
+		chars[r]++
+	}
+	return chars
+}
 
-	for _, r := range lowercase {
-		// 检查是否为字母或数字
-		if unicode.IsLetter(r) || unicode.IsDigit(r) {
-			currentWord.WriteRune(r)
-		} else if currentWord.Len() > 0 {
-			// 如果遇到非字母数字且当前有单词，则添加到映射
-			word := currentWord.String()
-			words[word]++
-			currentWord.Reset()
+// 打印字符频率，按出现次数从多到少排序
+func printCharFrequency(chars map[rune]int) {
+	runes := make([]rune, 0, len(chars))
+	total := 0
+	for r, count := range chars {
+		runes = append(runes, r)
+		total += count
+	}
+	sort.Slice(runes, func(i, j int) bool {
+		if chars[runes[i]] != chars[runes[j]] {
+			return chars[runes[i]] > chars[runes[j]]
+		}
+		return runes[i] < runes[j]
+	})
+
+	fmt.Printf("=== 字符频率 (共 %d 个字符, %d 种) ===\n", total, len(runes))
+	for _, r := range runes {
+		label := string(r)
+		if unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			label = strconv.QuoteRune(r)
 		}
+		fmt.Printf("%s: %d (%.2f%%)\n", label, chars[r], float64(chars[r])*100/float64(total))
 	}
-	// 处理最后一个单词
-	if currentWord.Len() > 0 {
-		word := currentWord.String()
-		words[word]++
+}
+
+// 打印平均单词长度和最长单词，长度按字符数计算
+func printWordSummary(words map[string]int) {
+	totalWords, totalLen := 0, 0
+	longest := ""
+	for word, count := range words {
+		length := utf8.RuneCountInString(word)
+		totalWords += count
+		totalLen += length * count
+		longestLen := utf8.RuneCountInString(longest)
+		if length > longestLen || (length == longestLen && word < longest) {
+			longest = word
+		}
 	}
-	return words
+	if totalWords == 0 {
+		return
+	}
+
+	fmt.Println("=== 单词概况 ===")
+	fmt.Printf("单词总数: %d\n", totalWords)
+	fmt.Printf("平均单词长度: %.2f\n", float64(totalLen)/float64(totalWords))
+	fmt.Printf("最长单词: %s (%d 个字符)\n", longest, utf8.RuneCountInString(longest))
 }
 
 // 单词计数程序
-func wordCount() {
+func wordCount(wordRe *regexp.Regexp, charMode, skipWhitespace bool) {
 	fmt.Println("=== 单词计数程序 ===")
 	fmt.Println("请输入一段文本（输入空行结束）:")
 
@@ -70,14 +122,31 @@ func wordCount() {
 		return
 	}
 
-	wordCounts := countWords(text)
+	wordCounts := countWords(text, wordRe)
 
 	for word, count := range wordCounts {
 		fmt.Printf("%s: %d\n", word, count)
 	}
+	printWordSummary(wordCounts)
 
+	if charMode {
+		// 多行输入之间用空格拼接，去掉末尾多出的空格
+		printCharFrequency(countChars(strings.TrimSuffix(text, " "), skipWhitespace))
+	}
 }
 
 func main() {
-	wordCount()
+	charMode := flag.Bool("chars", false, "统计每个字符出现的频率")
+	skipWhitespace := flag.Bool("no-whitespace", false, "字符频率统计时排除空白字符")
+	wordRegex := flag.String("wordregex", defaultWordRegex, "定义单词的正则表达式，每个匹配计为一个单词")
+	flag.Parse()
+
+	// 只编译一次，整个输入共用
+	wordRe, err := regexp.Compile(*wordRegex)
+	if err != nil {
+		fmt.Println("无效的 -wordregex:", err)
+		os.Exit(1)
+	}
+
+	wordCount(wordRe, *charMode, *skipWhitespace)
 }
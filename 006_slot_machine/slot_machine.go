@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"math/rand"
 	"os"
@@ -29,79 +30,169 @@ const (
 	Diamond = "💎"
 )
 
+// Payline 中奖线，依次给出每个转轮上参与判定的行号
+type Payline struct {
+	Name string
+	Rows [3]int
+}
+
+// 预定义的中奖线，第1条为中间一行
+var paylines = []Payline{
+	{"中间一行", [3]int{1, 1, 1}},
+	{"上面一行", [3]int{0, 0, 0}},
+	{"下面一行", [3]int{2, 2, 2}},
+	{"左上到右下", [3]int{0, 1, 2}},
+	{"左下到右上", [3]int{2, 1, 0}},
+}
+
+// LineWin 单条中奖线的中奖结果
+type LineWin struct {
+	Line   int // 中奖线编号，从1开始
+	Amount int // 该线赢得的积分
+}
+
+// SessionStats 本局游戏的统计数据
+type SessionStats struct {
+	Spins             int // 旋转次数
+	Wagered           int // 累计投注
+	Won               int // 累计赢得的积分
+	BiggestWin        int // 单次旋转赢得的最高积分
+	LosingStreak      int // 当前连续未中奖次数
+	LongestLoseStreak int // 最长连续未中奖次数
+}
+
 // SlotMachine  老虎机结构体
 type SlotMachine struct {
-	Balance int       // 玩家余额
-	Reels   [3]string // 三个转轮
-	Symbols []string  // 符号列表
+	Balance int          // 玩家余额
+	Grid    [3][3]string // 转轮格子，Grid[行][转轮]
+	Symbols []string     // 符号列表
+	Lines   int          // 启用的中奖线数量
+	Stats   SessionStats // 本局统计
 }
 
 // NewSlotMachine 创建新的老虎机
-func NewSlotMachine(initialBalance int) *SlotMachine {
+func NewSlotMachine(initialBalance, lines int) *SlotMachine {
 	return &SlotMachine{
 		Balance: initialBalance,
 		Symbols: []string{Cherry, Lemon, Orange, Bell, Bar, Seven, Diamond},
+		Lines:   lines,
 	}
 }
 
-// Spin 旋转老虎机
+// Spin 旋转老虎机，bet 为每条中奖线的赌注
 func (sm *SlotMachine) Spin(bet int) int {
+	totalBet := bet * sm.Lines
 	// 检查余额是否足够
-	if sm.Balance < bet {
-		fmt.Println("余额不足! ")
+	if sm.Balance < totalBet {
+		fmt.Printf("余额不足! 本次需要 %d 币 (%d 币 x %d 条线)\n", totalBet, bet, sm.Lines)
 		return 0
 	}
 	// 扣除积分
-	sm.Balance -= bet
-	// 随机生成三个图标
+	sm.Balance -= totalBet
+	// 随机生成每个格子的图标
 	rand.NewSource(time.Now().UnixNano())
-	for i := 0; i < 3; i++ {
-		index := rand.Intn(len(sm.Symbols))
-		sm.Reels[i] = sm.Symbols[index]
+	for row := 0; row < 3; row++ {
+		for reel := 0; reel < 3; reel++ {
+			index := rand.Intn(len(sm.Symbols))
+			sm.Grid[row][reel] = sm.Symbols[index]
+		}
 	}
 
 	// 显示旋转结果
 	sm.DisplayReels()
 
 	// 判断中奖情况并计算积分
-	winAmount := sm.calculateWin(bet)
+	winAmount, wins := sm.calculateWin(bet)
 
+	for _, win := range wins {
+		fmt.Printf("第%d条线(%s) 中奖 %d 积分\n", win.Line, paylines[win.Line-1].Name, win.Amount)
+	}
 	if winAmount > 0 {
 		fmt.Printf("恭喜! 你赢了 %d 积分\n", winAmount)
 		sm.Balance += winAmount
 	} else {
 		fmt.Println("很遗憾，未中奖！")
 	}
+	sm.Stats.record(totalBet, winAmount)
 	return winAmount
 }
 
+// record 记录一次旋转的投注和中奖积分
+func (st *SessionStats) record(totalBet, winAmount int) {
+	st.Spins++
+	st.Wagered += totalBet
+	st.Won += winAmount
+	if winAmount > st.BiggestWin {
+		st.BiggestWin = winAmount
+	}
+	if winAmount > 0 {
+		st.LosingStreak = 0
+		return
+	}
+	st.LosingStreak++
+	if st.LosingStreak > st.LongestLoseStreak {
+		st.LongestLoseStreak = st.LosingStreak
+	}
+}
+
+// PrintStats 显示本局统计
+func (sm *SlotMachine) PrintStats() {
+	st := sm.Stats
+	fmt.Println("\n===== 本局统计 =====")
+	fmt.Printf("旋转次数: %d\n", st.Spins)
+	fmt.Printf("累计投注: %d 币\n", st.Wagered)
+	fmt.Printf("累计赢得: %d 币\n", st.Won)
+	fmt.Printf("净收益: %+d 币\n", st.Won-st.Wagered)
+	fmt.Printf("单次最高奖: %d 币\n", st.BiggestWin)
+	fmt.Printf("最长连续未中奖: %d 次\n", st.LongestLoseStreak)
+}
+
 // DisplayReels 显示旋转结果
 func (sm *SlotMachine) DisplayReels() {
 	fmt.Println("\n==========")
-	fmt.Printf("| %s %s %s |\n", sm.Reels[0], sm.Reels[1], sm.Reels[2])
+	for _, row := range sm.Grid {
+		fmt.Printf("| %s %s %s |\n", row[0], row[1], row[2])
+	}
 	fmt.Println("==========")
 }
 
-// calculateWin 计算中奖
-func (sm *SlotMachine) calculateWin(bet int) int {
+// calculateWin 计算所有启用中奖线的中奖积分之和，并返回每条中奖线的结果
+func (sm *SlotMachine) calculateWin(bet int) (int, []LineWin) {
+	total := 0
+	var wins []LineWin
+	for i := 0; i < sm.Lines; i++ {
+		var symbols [3]string
+		for reel, row := range paylines[i].Rows {
+			symbols[reel] = sm.Grid[row][reel]
+		}
+		if amount := lineWin(symbols, bet); amount > 0 {
+			total += amount
+			wins = append(wins, LineWin{Line: i + 1, Amount: amount})
+		}
+	}
+	return total, wins
+}
+
+// lineWin 计算单条中奖线上三个图标的中奖积分
+func lineWin(symbols [3]string, bet int) int {
 	// 三个相同的7是最高奖
-	if sm.Reels[0] == Seven && sm.Reels[1] == Seven && sm.Reels[2] == Seven {
+	if symbols[0] == Seven && symbols[1] == Seven && symbols[2] == Seven {
 		return bet * 100
 	}
 	// 三个相同的钻石
-	if sm.Reels[0] == Diamond && sm.Reels[1] == Diamond && sm.Reels[2] == Diamond {
+	if symbols[0] == Diamond && symbols[1] == Diamond && symbols[2] == Diamond {
 		return bet * 50
 	}
 	// 三个相同的其他图标
-	if sm.Reels[0] == sm.Reels[1] && sm.Reels[1] == sm.Reels[2] {
+	if symbols[0] == symbols[1] && symbols[1] == symbols[2] {
 		return bet * 10
 	}
 	// 两个相同
-	if sm.Reels[0] == sm.Reels[1] || sm.Reels[1] == sm.Reels[2] || sm.Reels[0] == sm.Reels[2] {
+	if symbols[0] == symbols[1] || symbols[1] == symbols[2] || symbols[0] == symbols[2] {
 		return bet * 5
 	}
 	// 至少包含一个7
-	if sm.Reels[0] == Seven || sm.Reels[1] == Seven || sm.Reels[2] == Seven {
+	if symbols[0] == Seven || symbols[1] == Seven || symbols[2] == Seven {
 		return bet * 1
 	}
 	// 未中奖
@@ -109,9 +200,9 @@ func (sm *SlotMachine) calculateWin(bet int) int {
 }
 
 // 显示游戏帮助
-func showHelp() {
+func showHelp(lines int) {
 	fmt.Println("\n===== 游戏帮助 =====")
-	fmt.Println("1. 输入赌注金额进行游戏")
+	fmt.Printf("1. 输入每条线的赌注金额进行游戏，当前启用 %d 条中奖线，总赌注 = 赌注 x %d\n", lines, lines)
 	fmt.Println("2. 输入0退出游戏")
 	fmt.Println("3. 输入h查看帮助")
 	fmt.Println("中奖规则:")
@@ -120,18 +211,30 @@ func showHelp() {
 	fmt.Println("- 三个相同其他图标: 10倍奖励")
 	fmt.Println("- 两个相邻相同图标: 5倍奖励")
 	fmt.Println("- 至少一个7️⃣: 1倍奖励")
+	fmt.Println("中奖线 (每条线单独判定，奖励累加):")
+	for i := 0; i < lines; i++ {
+		fmt.Printf("- 第%d条: %s\n", i+1, paylines[i].Name)
+	}
 }
 
 // 主流程
 func main() {
+	lines := flag.Int("lines", 1, fmt.Sprintf("启用的中奖线数量 (1-%d)", len(paylines)))
+	flag.Parse()
+
+	if *lines < 1 || *lines > len(paylines) {
+		fmt.Printf("中奖线数量必须在 1-%d 之间\n", len(paylines))
+		os.Exit(1)
+	}
+
 	fmt.Println("===== 欢迎来到老虎机游戏! =====")
 	fmt.Println("祝你好运!")
 
 	// 初始积分
-	slotMachine := NewSlotMachine(100)
+	slotMachine := NewSlotMachine(100, *lines)
 
 	// 显示帮助
-	showHelp()
+	showHelp(*lines)
 
 	for {
 		fmt.Printf("\n当前余额: %d 币\n", slotMachine.Balance)
@@ -142,7 +245,7 @@ func main() {
 
 		// 处理帮助请求
 		if input == "h" || input == "H" {
-			showHelp()
+			showHelp(*lines)
 			continue
 		}
 
@@ -155,6 +258,7 @@ func main() {
 		if bet == 0 {
 			fmt.Println("感谢游玩! ")
 			fmt.Printf("最终余额: %d 币\n", slotMachine.Balance)
+			slotMachine.PrintStats()
 			os.Exit(0)
 		}
 		// 检查输入是否有效
@@ -168,6 +272,7 @@ func main() {
 		// 检查是否破产
 		if slotMachine.Balance <= 0 {
 			fmt.Println("游戏结束，你破产了!")
+			slotMachine.PrintStats()
 			os.Exit(0)
 		}
 	}
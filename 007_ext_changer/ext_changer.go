@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
 )
 
@@ -26,11 +28,46 @@ func printHelp() {
 	fmt.Println("  -new			修改后的文件后缀(例如：.md)")
 	fmt.Println("  -recurse		是否递归处理子目录(true/false, 默认：false)")
 	fmt.Println("  -dry			试运行，不实际修改文件(true/false, 默认：false)")
+	fmt.Println("  -regex			匹配完整文件名的正则表达式，设置后忽略 -old/-new")
+	fmt.Println("  -replace		配合 -regex 使用的新文件名模板，可用 $1、${name} 引用分组")
+	fmt.Println("  -report		只统计各后缀的文件数量，不修改文件，可配合 -recurse")
 	fmt.Println("\n示例:")
 	fmt.Println("  将当前目录下所有.txt文件改为.md")
 	fmt.Println("  ext_changer -old .txt -new .md")
 	fmt.Println("  将当前目录下所有.jpg文件改为.png，并递归处理子目录")
 	fmt.Println("  ext_changer -old .jpg -new .png -recurse true")
+	fmt.Println("  将 IMG_0001.jpg 这类文件重命名为 photo_0001.jpg")
+	fmt.Println("  ext_changer -regex 'IMG_(\\d+)\\.jpg' -replace 'photo_$1.jpg'")
+	fmt.Println("  修改前先查看目录及子目录中各后缀的文件数量")
+	fmt.Println("  ext_changer -report -recurse")
+}
+
+// renameFunc 根据原文件名生成新文件名，第二个返回值表示该文件是否需要处理
+type renameFunc func(fileName string) (string, bool)
+
+// extRenamer 按后缀修改文件名
+func extRenamer(oldExt, newExt string) renameFunc {
+	return func(fileName string) (string, bool) {
+		// 检查文件是否有指定的旧后缀
+		if !strings.HasSuffix(fileName, "."+oldExt) {
+			return "", false
+		}
+		return strings.TrimSuffix(fileName, "."+oldExt) + "." + newExt, true
+	}
+}
+
+// regexRenamer 按正则表达式替换完整文件名
+func regexRenamer(re *regexp.Regexp, template string) renameFunc {
+	return func(fileName string) (string, bool) {
+		if !re.MatchString(fileName) {
+			return "", false
+		}
+		newFileName := re.ReplaceAllString(fileName, template)
+		if newFileName == fileName {
+			return "", false
+		}
+		return newFileName, true
+	}
 }
 
 // 检查后缀是否符合格式（不含点）
@@ -40,17 +77,19 @@ func normalizeExtension(ext string) string {
 }
 
 // 处理文件重命名
-func processFile(filePath string, oldExt string, newExt string, dryRun bool) (bool, error) {
-	// 获取文件名和当前后缀
+func processFile(filePath string, rename renameFunc, dryRun bool) (bool, error) {
+	// 获取目录和文件名
 	dir := filepath.Dir(filePath)
 	fileName := filepath.Base(filePath)
-	// 检查文件是否有指定的旧后缀
-	if !strings.HasSuffix(fileName, "."+oldExt) {
-		return false, nil
-	}
 
 	// 生成新文件名
-	newFileName := strings.TrimSuffix(fileName, "."+oldExt) + "." + newExt
+	newFileName, ok := rename(fileName)
+	if !ok {
+		return false, nil
+	}
+	if newFileName == "" || strings.ContainsAny(newFileName, `/\`) {
+		return false, fmt.Errorf("无效的新文件名: '%s'", newFileName)
+	}
 	newFilePath := filepath.Join(dir, newFileName)
 
 	// 检查新文件是否已存在
@@ -71,11 +110,9 @@ func processFile(filePath string, oldExt string, newExt string, dryRun bool) (bo
 	return true, nil
 }
 
-// 处理目录中的文件
-func processDirectory(rootDir string, oldExt, newExt string, recurse, dryRun bool) (int, int, error) {
-	var total, changed int
-	// 遍历目录
-	err := filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+// 遍历目录中的文件，对每个文件调用 fn
+func walkFiles(rootDir string, recurse bool, fn func(path string)) error {
+	return filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return fmt.Errorf("访问路径失败: %s, 错误: %v", path, err)
 		}
@@ -83,23 +120,65 @@ func processDirectory(rootDir string, oldExt, newExt string, recurse, dryRun boo
 		if info.IsDir() && path != rootDir && !recurse {
 			return filepath.SkipDir
 		}
-		// 处理文件
 		if !info.IsDir() {
-			total++
-			ok, err := processFile(path, oldExt, newExt, dryRun)
-			if err != nil {
-				fmt.Printf("处理文件失败: %s, 错误: %v\n", path, err)
-				return nil // 继续处理下一个文件
-			}
-			if ok {
-				changed++
-			}
+			fn(path)
 		}
 		return nil
 	})
+}
+
+// 处理目录中的文件
+func processDirectory(rootDir string, rename renameFunc, recurse, dryRun bool) (int, int, error) {
+	var total, changed int
+	err := walkFiles(rootDir, recurse, func(path string) {
+		total++
+		ok, err := processFile(path, rename, dryRun)
+		if err != nil {
+			fmt.Printf("处理文件失败: %s, 错误: %v\n", path, err)
+			return // 继续处理下一个文件
+		}
+		if ok {
+			changed++
+		}
+	})
 	return total, changed, err
 }
 
+// 没有后缀的文件在报告中的名称
+const noExtension = "(无后缀)"
+
+// extCount 某个后缀及其文件数量
+type extCount struct {
+	Ext   string
+	Count int
+}
+
+// 统计目录中各后缀的文件数量，按数量从多到少排序，数量相同时按后缀排序
+func reportExtensions(rootDir string, recurse bool) ([]extCount, int, error) {
+	counts := make(map[string]int)
+	total := 0
+	err := walkFiles(rootDir, recurse, func(path string) {
+		total++
+		ext := filepath.Ext(path)
+		if ext == "" {
+			ext = noExtension
+		}
+		counts[ext]++
+	})
+
+	report := make([]extCount, 0, len(counts))
+	for ext, count := range counts {
+		report = append(report, extCount{Ext: ext, Count: count})
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Count != report[j].Count {
+			return report[i].Count > report[j].Count
+		}
+		return report[i].Ext < report[j].Ext
+	})
+	return report, total, err
+}
+
 // 主函数
 func main() {
 	// 解析命令行参数
@@ -108,24 +187,64 @@ func main() {
 	newExt := flag.String("new", "", "新的文件后缀")
 	recurse := flag.Bool("recurse", false, "是否递归处理子目录")
 	dryRun := flag.Bool("dry", false, "试运行，不实际修改文件")
+	pattern := flag.String("regex", "", "匹配完整文件名的正则表达式")
+	replace := flag.String("replace", "", "正则模式下的新文件名模板")
+	report := flag.Bool("report", false, "只统计各后缀的文件数量，不修改文件")
 	help := flag.Bool("help", false, "显示帮助信息")
 	flag.Parse()
 
+	// 统计模式不需要其他参数，也不会修改任何文件
+	if *report && !*help {
+		fmt.Printf("正在统计目录: %s\n", *dir)
+		fmt.Println("--------------------------------")
+		counts, total, err := reportExtensions(*dir, *recurse)
+		for _, c := range counts {
+			fmt.Printf("%6d  %s\n", c.Count, c.Ext)
+		}
+		fmt.Println("------------------------")
+		fmt.Printf("共 %d 个文件，%d 种后缀\n", total, len(counts))
+		if err != nil {
+			fmt.Printf("处理过程中出现错误: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 正则模式只需要 -regex 和 -replace，后缀模式需要 -old 和 -new
+	missing := *oldExt == "" || *newExt == ""
+	if *pattern != "" {
+		missing = *replace == ""
+	}
+
 	// 显示帮助信息
-	if *help || *oldExt == "" || *newExt == "" {
+	if *help || missing {
 		printHelp()
-		if *oldExt == "" || *newExt == "" {
+		if missing {
 			os.Exit(1) // 参数缺失，异常退出
 		}
 		os.Exit(0) // 显示帮助后正常退出
 	}
-	// 标准化后缀格式（去掉开头的点）
-	normalizedOldExt := normalizeExtension(*oldExt)
-	normalizedNewExt := normalizeExtension(*newExt)
+
+	var rename renameFunc
+	if *pattern != "" {
+		re, err := regexp.Compile(*pattern)
+		if err != nil {
+			fmt.Printf("无效的正则表达式: %v\n", err)
+			os.Exit(1)
+		}
+		rename = regexRenamer(re, *replace)
+	} else {
+		// 标准化后缀格式（去掉开头的点）
+		rename = extRenamer(normalizeExtension(*oldExt), normalizeExtension(*newExt))
+	}
 
 	// 显示操作信息
 	fmt.Printf("正在处理目录: %s\n", *dir)
-	fmt.Printf("将所有的 .%s 文件改为 .%s\n", normalizedOldExt, normalizedNewExt)
+	if *pattern != "" {
+		fmt.Printf("将匹配 %s 的文件名替换为 %s\n", *pattern, *replace)
+	} else {
+		fmt.Printf("将所有的 .%s 文件改为 .%s\n", normalizeExtension(*oldExt), normalizeExtension(*newExt))
+	}
 	if *recurse {
 		fmt.Println("递归处理子目录")
 	}
@@ -135,7 +254,7 @@ func main() {
 	fmt.Println("--------------------------------")
 
 	// 处理目录
-	total, changed, err := processDirectory(*dir, normalizedOldExt, normalizedNewExt, *recurse, *dryRun)
+	total, changed, err := processDirectory(*dir, rename, *recurse, *dryRun)
 	// 显示结果摘要
 	fmt.Println("------------------------")
 	fmt.Printf("处理完成。共检查 %d 个文件，", total)
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"unicode/utf8"
 )
 
 /**
@@ -14,42 +17,71 @@ import (
 	可选择是否区分大小写
 	支持递归搜索子目录
 	显示匹配内容所在的文件名和行号
+	支持替换匹配文本，默认只预览，加 -write 才写回文件
 */
 
-// 搜索文件内容
-func searchInFile(filePath, query string, caseSensitive bool) ([]int, error) {
+// Match 一处匹配结果
+type Match struct {
+	File   string `json:"file"`
+	Line   int    `json:"line"`   // 行号，从1开始
+	Column int    `json:"column"` // 列号，按字符计算，从1开始
+	Text   string `json:"text"`   // 匹配所在行的内容
+}
+
+// 搜索文件内容，返回每一处匹配
+func searchInFile(filePath, query string, caseSensitive bool) ([]Match, error) {
 	content, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
 
-	var lines []int
+	var matches []Match
 	text := string(content)
 	linesText := strings.Split(text, "\n")
 
+	queryToCheck := query
+	if !caseSensitive {
+		queryToCheck = strings.ToLower(query)
+	}
+
 	for i, line := range linesText {
+		line = strings.TrimSuffix(line, "\r")
 		lineToCheck := line
-		queryToCheck := query
 
 		// 如果不区分大小写，统一转换为小写
 		if !caseSensitive {
 			lineToCheck = strings.ToLower(line)
-			queryToCheck = strings.ToLower(query)
 		}
 
-		if strings.Contains(lineToCheck, queryToCheck) {
-			lines = append(lines, i+1) // 行号从1开始
+		// 查找该行中的每一处匹配
+		offset := 0
+		for {
+			idx := strings.Index(lineToCheck[offset:], queryToCheck)
+			if idx < 0 {
+				break
+			}
+			pos := offset + idx
+			matches = append(matches, Match{
+				File:   filePath,
+				Line:   i + 1, // 行号从1开始
+				Column: utf8.RuneCountInString(lineToCheck[:pos]) + 1,
+				Text:   line,
+			})
+			offset = pos + len(queryToCheck)
+			if offset >= len(lineToCheck) {
+				break
+			}
 		}
 	}
-	return lines, nil
+	return matches, nil
 }
 
-// 处理目录搜索
-func searchInDirectory(rootDir, query string, caseSensitive, recurse bool) {
+// 处理目录搜索，jsonOutput 为 true 时不打印匹配，只收集结果
+func searchInDirectory(rootDir, query string, caseSensitive, recurse, jsonOutput bool) []Match {
+	var all []Match
 	filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return fmt.Errorf("访问路径失败: %s, 错误: %v", path, err)
-			return nil
 		}
 
 		// 如果是目录且不递归处理，则跳过
@@ -59,21 +91,146 @@ func searchInDirectory(rootDir, query string, caseSensitive, recurse bool) {
 
 		// 只处理文件
 		if !info.IsDir() {
-			lines, err := searchInFile(path, query, caseSensitive)
+			matches, err := searchInFile(path, query, caseSensitive)
 			if err != nil {
-				fmt.Printf("读取 %s 失败：%v\n", path, err)
+				if jsonOutput {
+					fmt.Fprintf(os.Stderr, "读取 %s 失败：%v\n", path, err)
+				} else {
+					fmt.Printf("读取 %s 失败：%v\n", path, err)
+				}
 				return nil
 			}
+			all = append(all, matches...)
 
-			if len(lines) > 0 {
+			if len(matches) > 0 && !jsonOutput {
 				fmt.Printf("在 %s 中找到匹配项：\n", path)
-				for _, line := range lines {
-					fmt.Printf("第 %d 行\n", line)
+				lastLine := 0
+				for _, m := range matches {
+					// 同一行有多处匹配时只显示一次
+					if m.Line != lastLine {
+						fmt.Printf("第 %d 行\n", m.Line)
+						lastLine = m.Line
+					}
 				}
 			}
 		}
 		return nil
 	})
+	return all
+}
+
+// 备份文件后缀，替换时跳过这类文件，避免重复运行时改写备份
+const backupSuffix = ".bak"
+
+// LineChange 替换前后的一行内容，用于预览
+type LineChange struct {
+	Line int
+	Old  string
+	New  string
+}
+
+// 构造匹配 query 的正则，按字面文本匹配，不区分大小写时加 (?i)
+func buildMatcher(query string, caseSensitive bool) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(query)
+	if !caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+	return regexp.MustCompile(pattern)
+}
+
+// 逐行替换文本，返回替换后的内容、发生变化的行和替换次数，换行符保持不变
+func replaceInText(text string, re *regexp.Regexp, replacement string) (string, []LineChange, int) {
+	lines := strings.Split(text, "\n")
+	var changes []LineChange
+	count := 0
+	for i, line := range lines {
+		n := len(re.FindAllStringIndex(line, -1))
+		if n == 0 {
+			continue
+		}
+		newLine := re.ReplaceAllLiteralString(line, replacement)
+		count += n
+		changes = append(changes, LineChange{
+			Line: i + 1,
+			Old:  strings.TrimSuffix(line, "\r"),
+			New:  strings.TrimSuffix(newLine, "\r"),
+		})
+		lines[i] = newLine
+	}
+	return strings.Join(lines, "\n"), changes, count
+}
+
+// 替换单个文件中的匹配，write 为 false 时只返回预览不写文件，backup 为 true 时先保存原文件到 .bak
+func replaceInFile(filePath string, re *regexp.Regexp, replacement string, write, backup bool) ([]LineChange, int, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	// 非UTF-8文件多半是二进制文件，改写可能损坏内容
+	if !utf8.Valid(content) {
+		return nil, 0, nil
+	}
+
+	newText, changes, count := replaceInText(string(content), re, replacement)
+	if count == 0 || !write {
+		return changes, count, nil
+	}
+
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	if backup {
+		if err := os.WriteFile(filePath+backupSuffix, content, info.Mode().Perm()); err != nil {
+			return nil, 0, fmt.Errorf("备份失败: %v", err)
+		}
+	}
+	if err := os.WriteFile(filePath, []byte(newText), info.Mode().Perm()); err != nil {
+		return nil, 0, fmt.Errorf("写入失败: %v", err)
+	}
+	return changes, count, nil
+}
+
+// 处理目录替换，打印每个文件的预览或替换次数，返回涉及的文件数和替换总数
+func replaceInDirectory(rootDir, query, replacement string, caseSensitive, recurse, write, backup bool) (int, int) {
+	re := buildMatcher(query, caseSensitive)
+	files, total := 0, 0
+	filepath.Walk(rootDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return fmt.Errorf("访问路径失败: %s, 错误: %v", path, err)
+		}
+
+		if info.IsDir() && path != rootDir && !recurse {
+			return filepath.SkipDir
+		}
+		if info.IsDir() || strings.HasSuffix(path, backupSuffix) {
+			return nil
+		}
+
+		changes, count, err := replaceInFile(path, re, replacement, write, backup)
+		if err != nil {
+			fmt.Printf("处理 %s 失败：%v\n", path, err)
+			return nil
+		}
+		if count == 0 {
+			return nil
+		}
+		files++
+		total += count
+
+		if write {
+			fmt.Printf("%s: 替换 %d 处\n", path, count)
+			return nil
+		}
+		fmt.Printf("--- %s (%d 处)\n", path, count)
+		for _, c := range changes {
+			fmt.Printf("第 %d 行\n", c.Line)
+			fmt.Printf("- %s\n", c.Old)
+			fmt.Printf("+ %s\n", c.New)
+		}
+		return nil
+	})
+	return files, total
 }
 
 func main() {
@@ -82,6 +239,11 @@ func main() {
 	query := flag.String("query", "", "要搜索的文本")
 	caseSensitive := flag.Bool("case", false, "是否区分大小写")
 	recurse := flag.Bool("recurse", false, "是否递归搜索子目录")
+	output := flag.String("output", "text", "输出格式 (text/json)")
+	replace := flag.String("replace", "", "将匹配文本替换为该内容，默认只预览")
+	write := flag.Bool("write", false, "配合 -replace 将替换结果写回文件")
+	flag.BoolVar(write, "i", false, "同 -write")
+	backup := flag.Bool("backup", false, "写回前将原文件备份为 .bak")
 	help := flag.Bool("help", false, "显示帮助信息")
 
 	flag.Parse()
@@ -94,17 +256,70 @@ func main() {
 		fmt.Println("  -dir      搜索目录 (默认: 当前目录)")
 		fmt.Println("  -case     是否区分大小写 (true/false, 默认: false)")
 		fmt.Println("  -recurse  是否递归搜索子目录 (true/false, 默认: false)")
+		fmt.Println("  -output   输出格式 text/json，json 输出包含 file、line、column、text 的数组 (默认: text)")
+		fmt.Println("  -replace  将匹配文本替换为该内容，不加 -write 时只显示预览")
+		fmt.Println("  -write    配合 -replace 将替换结果写回文件 (可简写为 -i)")
+		fmt.Println("  -backup   写回前将原文件备份为 .bak")
 		fmt.Println("  -help     显示帮助信息")
 		os.Exit(0)
 	}
 
+	if *output != "text" && *output != "json" {
+		fmt.Println("无效的输出格式，可选值: text, json")
+		os.Exit(1)
+	}
+
+	// -replace "" 表示删除匹配文本，因此按是否设置了该参数判断
+	replaceSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "replace" {
+			replaceSet = true
+		}
+	})
+	if !replaceSet && (*write || *backup) {
+		fmt.Println("-write 和 -backup 需要与 -replace 一起使用")
+		os.Exit(1)
+	}
+	if replaceSet {
+		if *output == "json" {
+			fmt.Println("-replace 不支持 json 输出")
+			os.Exit(1)
+		}
+		fmt.Printf("替换文本: %q -> %q\n", *query, *replace)
+		fmt.Printf("搜索目录: %s\n", *dir)
+		fmt.Println("------------------------")
+		files, total := replaceInDirectory(*dir, *query, *replace, *caseSensitive, *recurse, *write, *backup)
+		fmt.Println("------------------------")
+		if *write {
+			fmt.Printf("替换完成：%d 个文件，共 %d 处\n", files, total)
+		} else {
+			fmt.Printf("预览：%d 个文件，共 %d 处将被替换，未修改任何文件，加 -write 写入\n", files, total)
+		}
+		return
+	}
+
+	// JSON 模式只输出一个完整的数组，便于脚本直接解析
+	if *output == "json" {
+		matches := searchInDirectory(*dir, *query, *caseSensitive, *recurse, true)
+		if matches == nil {
+			matches = []Match{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(matches); err != nil {
+			fmt.Fprintf(os.Stderr, "输出JSON失败：%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	fmt.Printf("搜索文本: %q\n", *query)
 	fmt.Printf("搜索目录: %s\n", *dir)
 	fmt.Printf("区分大小写: %v\n", *caseSensitive)
 	fmt.Printf("递归搜索: %v\n", *recurse)
 	fmt.Println("------------------------")
 
-	searchInDirectory(*dir, *query, *caseSensitive, *recurse)
+	searchInDirectory(*dir, *query, *caseSensitive, *recurse, false)
 	fmt.Println("------------------------")
 	fmt.Println("搜索完成")
 }
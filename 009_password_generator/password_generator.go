@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bufio"
 	"flag"
 	"fmt"
 	"math/rand"
@@ -35,8 +36,13 @@ type PasswordConfig struct {
 	IncludeSymbols   bool // 包含特殊字符
 	ExcludeAmbiguous bool // 排除易混淆字符
 	Count            int  // 生成密码数量
+
+	CommonPasswords map[string]struct{} // 常见/泄露密码集合，为nil时不检查
 }
 
+// 与常见密码重复时最多重新生成的次数，字符集和长度太小时避免无限循环
+const maxRegenerations = 1000
+
 // 易混淆字符
 var ambiguousChars = []string{"0", "O", "o", "1", "l", "I", "i"}
 
@@ -112,6 +118,45 @@ func generatePassword(config *PasswordConfig) (string, error) {
 	return string(passwordBytes), nil
 }
 
+// 从文件加载常见/泄露密码列表（每行一个），用集合保存以便O(1)查找
+func loadCommonPasswords(filename string) (map[string]struct{}, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取常见密码列表失败: %v", err)
+	}
+	defer file.Close()
+
+	passwords := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	// 泄露密码列表中偶尔有超长行，放宽单行长度限制
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		passwords[line] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取常见密码列表失败: %v", err)
+	}
+	return passwords, nil
+}
+
+// 生成不在常见密码列表中的密码，返回密码和重新生成的次数
+func generateUncommonPassword(config *PasswordConfig) (string, int, error) {
+	for regenerations := 0; regenerations <= maxRegenerations; regenerations++ {
+		password, err := generatePassword(config)
+		if err != nil {
+			return "", regenerations, err
+		}
+		if _, common := config.CommonPasswords[password]; !common {
+			return password, regenerations, nil
+		}
+	}
+	return "", maxRegenerations, fmt.Errorf("连续%d次生成的密码都在常见密码列表中，请增加长度或字符类型", maxRegenerations+1)
+}
+
 // 评估密码强度
 func evaluatePasswordStrength(password string) string {
 	var score int
@@ -175,12 +220,15 @@ func showHelp() {
 	fmt.Println("  -numbers    包含数字 (默认: true)")
 	fmt.Println("  -symbols    包含特殊字符 (默认: false)")
 	fmt.Println("  -exclude    排除易混淆字符 (默认: false)")
+	fmt.Println("  -check-common 常见/泄露密码列表文件，生成的密码在列表中时重新生成")
 	fmt.Println("  -help       显示帮助信息")
 	fmt.Println("\n示例:")
 	fmt.Println("  生成一个12位包含所有字符类型的密码:")
 	fmt.Println("  password_generator -length 12 -symbols true")
 	fmt.Println("  生成5个8位不包含特殊字符的密码:")
 	fmt.Println("  password_generator -length 8 -count 5 -symbols false")
+	fmt.Println("  生成密码并排除常见密码列表中的密码:")
+	fmt.Println("  password_generator -length 8 -check-common rockyou.txt")
 }
 
 func main() {
@@ -192,6 +240,7 @@ func main() {
 	includeNumbers := flag.Bool("numbers", true, "包含数字")
 	includeSymbols := flag.Bool("symbols", false, "包含特殊字符")
 	excludeAmbiguous := flag.Bool("exclude", false, "排除易混淆字符")
+	checkCommon := flag.String("check-common", "", "常见/泄露密码列表文件，每行一个")
 	help := flag.Bool("help", false, "显示帮助信息")
 
 	flag.Parse()
@@ -224,6 +273,16 @@ func main() {
 		Count:            *count,
 	}
 
+	// 加载常见密码列表
+	if *checkCommon != "" {
+		passwords, err := loadCommonPasswords(*checkCommon)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		config.CommonPasswords = passwords
+	}
+
 	// 显示配置信息
 	fmt.Printf("密码配置:\n")
 	fmt.Printf("  长度: %d\n", config.Length)
@@ -233,11 +292,16 @@ func main() {
 	fmt.Printf("  数字: %v\n", config.IncludeNumbers)
 	fmt.Printf("  特殊字符: %v\n", config.IncludeSymbols)
 	fmt.Printf("  排除易混淆字符: %v\n", config.ExcludeAmbiguous)
+	if config.CommonPasswords != nil {
+		fmt.Printf("  常见密码列表: %s (%d 个)\n", *checkCommon, len(config.CommonPasswords))
+	}
 	fmt.Println("------------------------")
 
 	// 生成密码
+	totalRegenerations := 0
 	for i := 0; i < config.Count; i++ {
-		password, err := generatePassword(config)
+		password, regenerations, err := generateUncommonPassword(config)
+		totalRegenerations += regenerations
 		if err != nil {
 			fmt.Printf("生成密码失败: %v\n", err)
 			os.Exit(1)
@@ -248,5 +312,8 @@ func main() {
 	}
 
 	fmt.Println("------------------------")
+	if config.CommonPasswords != nil {
+		fmt.Printf("因与常见密码重复重新生成: %d 次\n", totalRegenerations)
+	}
 	fmt.Println("密码生成完成")
 }
@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
 	"os"
 	"regexp"
 	"sort"
@@ -37,15 +39,55 @@ type TextStats struct {
 	Punctuation    int             // 标点符号数
 	WordFreq       map[string]int  // 词频统计
 	TopWords       []WordFrequency // 高频词汇
+	PhraseFreq     map[string]int  // 短语（N-gram）频率统计
+	TopPhrases     []WordFrequency // 高频短语
+	Keywords       []KeywordScore  // TF-IDF 关键词（多文件时）
 	ReadingTime    float64         // 预估阅读时间（分钟）
+	Syllables      int             // 英文音节数
+	FleschEase     float64         // Flesch 阅读易读度
+	FleschGrade    float64         // Flesch-Kincaid 年级水平
+	WordSet        map[string]bool // 不重复单词集合
+	UniqueWords    int             // 不重复单词数
+	TypeTokenRatio float64         // 词汇丰富度（不重复单词数/总单词数）
+	Language       string          // 主要语言 (chinese/english/mixed/unknown)
+	LangConfidence float64         // 语言判断置信度（0-1）
+	PositiveHits   int             // 命中的积极词数
+	NegativeHits   int             // 命中的消极词数
+	SentimentScore float64         // 情感得分（-1 到 +1）
+	SentimentLabel string          // 情感倾向 (positive/neutral/negative)
 }
 
+// 语言检测结果
+const (
+	langChinese = "chinese"
+	langEnglish = "english"
+	langMixed   = "mixed"
+	langUnknown = "unknown"
+)
+
+// 语言名称（用于显示）
+var languageNames = map[string]string{
+	langChinese: "中文",
+	langEnglish: "英文",
+	langMixed:   "中英混合",
+	langUnknown: "未知",
+}
+
+// 中文占比达到该阈值判为中文，低于 1-该阈值 判为英文，其余为混合
+const languageThreshold = 0.7
+
 // WordFrequency 词频结构体
 type WordFrequency struct {
 	Word  string
 	Count int
 }
 
+// KeywordScore 关键词评分结构体
+type KeywordScore struct {
+	Word  string
+	Score float64
+}
+
 // AnalysisConfig 分析配置结构体
 type AnalysisConfig struct {
 	ShowWordFreq  bool   // 显示词频分析
@@ -54,6 +96,159 @@ type AnalysisConfig struct {
 	IgnoreCase    bool   // 忽略大小写
 	OutputFormat  string // 输出格式 (text/json)
 	ReadingSpeed  int    // 阅读速度（字/分钟）
+	NoStopWords   bool   // 不过滤停用词
+	NGram         int    // 短语统计的词数（0 表示不统计）
+	Sentiment     bool   // 情感分析
+}
+
+// 情感倾向
+const (
+	sentimentPositive = "positive"
+	sentimentNeutral  = "neutral"
+	sentimentNegative = "negative"
+)
+
+// 情感倾向名称（用于显示）
+var sentimentNames = map[string]string{
+	sentimentPositive: "积极",
+	sentimentNeutral:  "中性",
+	sentimentNegative: "消极",
+}
+
+// 情感得分绝对值达到该阈值才判为积极或消极
+const sentimentThreshold = 0.2
+
+// 情感词典：1 为积极词，-1 为消极词
+// 英文按单词匹配（不区分大小写），中文没有分词，按子串在原文中匹配
+var sentimentLexicon = map[string]int{
+	"good": 1, "great": 1, "excellent": 1, "happy": 1, "love": 1, "like": 1, "nice": 1, "best": 1,
+	"wonderful": 1, "amazing": 1, "awesome": 1, "fantastic": 1, "perfect": 1, "glad": 1, "enjoy": 1,
+	"beautiful": 1, "success": 1, "successful": 1, "easy": 1, "fast": 1, "helpful": 1, "thanks": 1,
+	"bad": -1, "terrible": -1, "awful": -1, "horrible": -1, "sad": -1, "hate": -1, "worst": -1,
+	"poor": -1, "ugly": -1, "angry": -1, "fail": -1, "failed": -1, "failure": -1, "wrong": -1,
+	"slow": -1, "difficult": -1, "broken": -1, "problem": -1, "disappointed": -1, "annoying": -1,
+	"好": 1, "优秀": 1, "喜欢": 1, "开心": 1, "高兴": 1, "满意": 1, "成功": 1, "精彩": 1,
+	"美好": 1, "快乐": 1, "感谢": 1, "推荐": 1, "方便": 1, "完美": 1, "棒": 1,
+	"不好": -1, "差": -1, "糟糕": -1, "讨厌": -1, "失望": -1, "难过": -1, "失败": -1, "问题": -1,
+	"生气": -1, "痛苦": -1, "麻烦": -1, "错误": -1, "不满": -1, "垃圾": -1, "不喜欢": -1,
+}
+
+// 从文件加载情感词典（每行一个词，+ 开头为积极词，- 开头为消极词），替换内置词典
+func loadLexicon(filename string) (int, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, fmt.Errorf("读取情感词典失败: %v", err)
+	}
+	defer file.Close()
+
+	lexicon := make(map[string]int)
+	lineNum := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		polarity := 0
+		switch line[0] {
+		case '+':
+			polarity = 1
+		case '-':
+			polarity = -1
+		default:
+			return 0, fmt.Errorf("情感词典第 %d 行格式错误: 应以 + 或 - 开头", lineNum)
+		}
+		word := strings.ToLower(strings.TrimSpace(line[1:]))
+		if word == "" {
+			return 0, fmt.Errorf("情感词典第 %d 行缺少词语", lineNum)
+		}
+		lexicon[word] = polarity
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("读取情感词典失败: %v", err)
+	}
+	if len(lexicon) == 0 {
+		return 0, fmt.Errorf("情感词典 '%s' 中没有词语", filename)
+	}
+
+	sentimentLexicon = lexicon
+	return len(lexicon), nil
+}
+
+// 判断词语是否包含汉字
+func containsHan(word string) bool {
+	for _, char := range word {
+		if unicode.Is(unicode.Han, char) {
+			return true
+		}
+	}
+	return false
+}
+
+// 统计文本中积极词和消极词的命中次数
+func countSentiment(text string, words []string, stats *TextStats) {
+	// 中文词按长度从长到短匹配，匹配过的部分替换掉，避免"不好"中的"好"被重复计为积极词
+	var hanWords []string
+	for word := range sentimentLexicon {
+		if containsHan(word) {
+			hanWords = append(hanWords, word)
+		}
+	}
+	sort.Slice(hanWords, func(i, j int) bool {
+		li, lj := utf8.RuneCountInString(hanWords[i]), utf8.RuneCountInString(hanWords[j])
+		if li != lj {
+			return li > lj
+		}
+		return hanWords[i] < hanWords[j]
+	})
+
+	rest := text
+	for _, word := range hanWords {
+		count := strings.Count(rest, word)
+		if count == 0 {
+			continue
+		}
+		if sentimentLexicon[word] > 0 {
+			stats.PositiveHits += count
+		} else {
+			stats.NegativeHits += count
+		}
+		rest = strings.ReplaceAll(rest, word, " ")
+	}
+
+	// 其余语言按单词匹配
+	for _, word := range words {
+		if containsHan(word) {
+			continue
+		}
+		switch sentimentLexicon[strings.ToLower(word)] {
+		case 1:
+			stats.PositiveHits++
+		case -1:
+			stats.NegativeHits++
+		}
+	}
+}
+
+// 根据命中次数计算归一化情感得分和倾向
+func computeSentiment(stats *TextStats) {
+	total := stats.PositiveHits + stats.NegativeHits
+	if total == 0 {
+		stats.SentimentScore = 0
+	} else {
+		stats.SentimentScore = float64(stats.PositiveHits-stats.NegativeHits) / float64(total)
+	}
+
+	switch {
+	case stats.SentimentScore >= sentimentThreshold:
+		stats.SentimentLabel = sentimentPositive
+	case stats.SentimentScore <= -sentimentThreshold:
+		stats.SentimentLabel = sentimentNegative
+	default:
+		stats.SentimentLabel = sentimentNeutral
+	}
 }
 
 // 常用停用词（中英文）
@@ -68,18 +263,65 @@ var stopWords = map[string]bool{
 	"from": true, "they": true, "we": true, "say": true, "her": true, "she": true, "or": true, "an": true,
 }
 
+// 从文件加载自定义停用词（每行一个），合并到内置停用词表
+func loadStopWords(filename string) (int, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return 0, fmt.Errorf("读取停用词文件失败: %v", err)
+	}
+	defer file.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		word := strings.ToLower(strings.TrimSpace(scanner.Text()))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		if !stopWords[word] {
+			stopWords[word] = true
+			count++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return count, fmt.Errorf("读取停用词文件失败: %v", err)
+	}
+	return count, nil
+}
+
+// 判断是否为停用词（不区分大小写）
+func isStopWord(word string, config *AnalysisConfig) bool {
+	if config.NoStopWords {
+		return false
+	}
+	return stopWords[strings.ToLower(word)]
+}
+
 // 分析文本文件
 func analyzeText(filename string, config *AnalysisConfig) (*TextStats, error) {
-	// 读取文件内容
-	content, err := ioutil.ReadFile(filename)
+	file, err := os.Open(filename)
 	if err != nil {
 		return nil, fmt.Errorf("读取文件失败: %v", err)
 	}
+	defer file.Close()
+
+	return analyzeReader(filename, file, config)
+}
+
+// 分析任意输入流（文件或标准输入）
+func analyzeReader(name string, reader io.Reader, config *AnalysisConfig) (*TextStats, error) {
+	// 读取全部内容
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("读取输入失败: %v", err)
+	}
 
 	text := string(content)
 	stats := &TextStats{
-		Filename: filename,
-		WordFreq: make(map[string]int),
+		Filename:   name,
+		WordFreq:   make(map[string]int),
+		PhraseFreq: make(map[string]int),
+		WordSet:    make(map[string]bool),
 	}
 
 	// 基础统计
@@ -109,6 +351,14 @@ func analyzeText(filename string, config *AnalysisConfig) (*TextStats, error) {
 	for _, word := range words {
 		if isEnglishWord(word) {
 			englishWordCount++
+			stats.Syllables += countSyllables(word)
+		}
+
+		// 词汇集合（不受词频开关和停用词影响）
+		if config.IgnoreCase {
+			stats.WordSet[strings.ToLower(word)] = true
+		} else {
+			stats.WordSet[word] = true
 		}
 
 		// 词频统计
@@ -117,7 +367,7 @@ func analyzeText(filename string, config *AnalysisConfig) (*TextStats, error) {
 			if config.IgnoreCase {
 				key = strings.ToLower(word)
 			}
-			if len(key) >= config.MinWordLength && !stopWords[key] {
+			if len(key) >= config.MinWordLength && !isStopWord(key, config) {
 				stats.WordFreq[key]++
 			}
 		}
@@ -129,13 +379,251 @@ func analyzeText(filename string, config *AnalysisConfig) (*TextStats, error) {
 		stats.TopWords = getTopWords(stats.WordFreq, config.TopWordsCount)
 	}
 
+	// 短语（N-gram）统计
+	if config.NGram >= 2 {
+		countNGrams(text, config, stats.PhraseFreq)
+		stats.TopPhrases = getTopWords(stats.PhraseFreq, config.TopWordsCount)
+	}
+
 	// 计算预估阅读时间
 	totalReadableChars := stats.ChineseChars + stats.EnglishWords
 	stats.ReadingTime = float64(totalReadableChars) / float64(config.ReadingSpeed)
 
+	// 情感分析
+	if config.Sentiment {
+		countSentiment(text, words, stats)
+		computeSentiment(stats)
+	}
+
+	// 计算可读性评分
+	detectLanguage(stats)
+	computeReadability(stats)
+	computeVocabulary(stats)
+
 	return stats, nil
 }
 
+// 根据中文字符数与英文单词数的比例判断文本的主要语言和置信度
+func detectLanguage(stats *TextStats) {
+	total := stats.ChineseChars + stats.EnglishWords
+	if total == 0 {
+		stats.Language = langUnknown
+		stats.LangConfidence = 0
+		return
+	}
+
+	share := float64(stats.ChineseChars) / float64(total)
+	switch {
+	case share >= languageThreshold:
+		stats.Language = langChinese
+		stats.LangConfidence = share
+	case share <= 1-languageThreshold:
+		stats.Language = langEnglish
+		stats.LangConfidence = 1 - share
+	default:
+		// 两种语言越接近各占一半，判为混合的把握越大
+		stats.Language = langMixed
+		stats.LangConfidence = 1 - math.Abs(share-0.5)*2
+	}
+}
+
+// 估算英文单词的音节数（元音组启发式）
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+	count := 0
+	prevVowel := false
+	for _, char := range word {
+		isVowel := strings.ContainsRune("aeiouy", char)
+		if isVowel && !prevVowel {
+			count++
+		}
+		prevVowel = isVowel
+	}
+
+	// 词尾不发音的 e（如 make），但保留 -le 结尾（如 table）
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && count > 1 {
+		count--
+	}
+
+	// 纯数字等不含元音的词不计音节
+	if count == 0 && strings.IndexFunc(word, unicode.IsLetter) >= 0 {
+		count = 1
+	}
+	return count
+}
+
+// 计算 Flesch 可读性评分，只对英文文本计算
+func computeReadability(stats *TextStats) {
+	if stats.EnglishWords == 0 || stats.Language != langEnglish {
+		return
+	}
+
+	sentences := stats.Sentences
+	if sentences == 0 {
+		sentences = 1
+	}
+	wordsPerSentence := float64(stats.EnglishWords) / float64(sentences)
+	syllablesPerWord := float64(stats.Syllables) / float64(stats.EnglishWords)
+
+	stats.FleschEase = 206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord
+	stats.FleschGrade = 0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59
+}
+
+// 计算不重复单词数和词汇丰富度
+func computeVocabulary(stats *TextStats) {
+	stats.UniqueWords = len(stats.WordSet)
+	if stats.Words > 0 {
+		stats.TypeTokenRatio = float64(stats.UniqueWords) / float64(stats.Words)
+	}
+}
+
+// Flesch 易读度等级描述
+func fleschLevel(score float64) string {
+	switch {
+	case score >= 90:
+		return "非常容易"
+	case score >= 70:
+		return "容易"
+	case score >= 60:
+		return "标准"
+	case score >= 50:
+		return "较难"
+	case score >= 30:
+		return "困难"
+	default:
+		return "非常困难"
+	}
+}
+
+// 统计 N 个连续单词组成的短语，忽略全部由停用词组成的短语
+// 按句子结尾标点分句后分别统计，短语不会跨越两个句子
+func countNGrams(text string, config *AnalysisConfig, phraseFreq map[string]int) {
+	for _, sentence := range sentenceTerminator.Split(text, -1) {
+		countSentenceNGrams(extractWords(sentence, config), config, phraseFreq)
+	}
+}
+
+// 统计单个句子内的 N-gram
+func countSentenceNGrams(words []string, config *AnalysisConfig, phraseFreq map[string]int) {
+	n := config.NGram
+	for i := 0; i+n <= len(words); i++ {
+		gram := make([]string, n)
+		allStop := true
+		for j := 0; j < n; j++ {
+			gram[j] = words[i+j]
+			if config.IgnoreCase {
+				gram[j] = strings.ToLower(gram[j])
+			}
+			if !isStopWord(gram[j], config) {
+				allStop = false
+			}
+		}
+		if !allStop {
+			phraseFreq[strings.Join(gram, " ")]++
+		}
+	}
+}
+
+// 计算多个文件的 TF-IDF 关键词，在所有文件中都常见的词得分更低
+func computeKeywords(statsList []*TextStats, topCount int) {
+	// 统计每个词出现在多少个文件中
+	docFreq := make(map[string]int)
+	for _, stats := range statsList {
+		for word := range stats.WordFreq {
+			docFreq[word]++
+		}
+	}
+
+	docs := float64(len(statsList))
+	for _, stats := range statsList {
+		total := 0
+		for _, count := range stats.WordFreq {
+			total += count
+		}
+		if total == 0 {
+			continue
+		}
+
+		var keywords []KeywordScore
+		for word, count := range stats.WordFreq {
+			tf := float64(count) / float64(total)
+			idf := math.Log((1+docs)/(1+float64(docFreq[word]))) + 1
+			keywords = append(keywords, KeywordScore{Word: word, Score: tf * idf})
+		}
+
+		sort.Slice(keywords, func(i, j int) bool {
+			if keywords[i].Score != keywords[j].Score {
+				return keywords[i].Score > keywords[j].Score
+			}
+			return keywords[i].Word < keywords[j].Word
+		})
+		if len(keywords) > topCount {
+			keywords = keywords[:topCount]
+		}
+		stats.Keywords = keywords
+	}
+}
+
+// 合并多个文件的统计结果
+func mergeStats(statsList []*TextStats, config *AnalysisConfig) *TextStats {
+	total := &TextStats{
+		Filename:   fmt.Sprintf("汇总 (%d 个文件)", len(statsList)),
+		WordFreq:   make(map[string]int),
+		PhraseFreq: make(map[string]int),
+		WordSet:    make(map[string]bool),
+	}
+
+	for _, stats := range statsList {
+		total.Characters += stats.Characters
+		total.CharactersNoWS += stats.CharactersNoWS
+		total.Words += stats.Words
+		total.Lines += stats.Lines
+		total.Paragraphs += stats.Paragraphs
+		total.Sentences += stats.Sentences
+		total.ChineseChars += stats.ChineseChars
+		total.EnglishWords += stats.EnglishWords
+		total.Numbers += stats.Numbers
+		total.Punctuation += stats.Punctuation
+		total.ReadingTime += stats.ReadingTime
+		total.Syllables += stats.Syllables
+		total.PositiveHits += stats.PositiveHits
+		total.NegativeHits += stats.NegativeHits
+		for word, count := range stats.WordFreq {
+			total.WordFreq[word] += count
+		}
+		for phrase, count := range stats.PhraseFreq {
+			total.PhraseFreq[phrase] += count
+		}
+		for word := range stats.WordSet {
+			total.WordSet[word] = true
+		}
+	}
+
+	if config.ShowWordFreq {
+		total.TopWords = getTopWords(total.WordFreq, config.TopWordsCount)
+	}
+	if config.NGram >= 2 {
+		total.TopPhrases = getTopWords(total.PhraseFreq, config.TopWordsCount)
+	}
+	if config.Sentiment {
+		computeSentiment(total)
+	}
+	detectLanguage(total)
+	computeReadability(total)
+	computeVocabulary(total)
+
+	return total
+}
+
+// 判断标准输入是否为管道或重定向（而非终端）
+func stdinHasData() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
 // 统计行数
 func countLines(text string) int {
 	scanner := bufio.NewScanner(strings.NewReader(text))
@@ -146,17 +634,26 @@ func countLines(text string) int {
 	return lines
 }
 
+// 段落分隔符：两个及以上换行（中间允许空白行）
+var paragraphSeparator = regexp.MustCompile(`\n\s*\n`)
+
 // 统计段落数
 func countParagraphs(text string) int {
-	paragraphs := strings.Split(strings.TrimSpace(text), "\n\n")
+	// 统一换行符，兼容 Windows 的 \r\n
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r", ""))
+	if text == "" {
+		return 0
+	}
+	paragraphs := paragraphSeparator.Split(text, -1)
 	return len(paragraphs)
 }
 
+// 句子结尾标点，连续的多个标点视为一个句子结尾
+var sentenceTerminator = regexp.MustCompile(`[.!?。！？]+`)
+
 // 统计句子数
 func countSentences(text string) int {
-	// 使用正则表达式匹配句子结尾
-	re := regexp.MustCompile(`[.!?。！？]+`)
-	matches := re.FindAllString(text, -1)
+	matches := sentenceTerminator.FindAllString(text, -1)
 	return len(matches)
 }
 
@@ -193,9 +690,12 @@ func getTopWords(wordFreq map[string]int, topCount int) []WordFrequency {
 		words = append(words, WordFrequency{Word: word, Count: count})
 	}
 
-	// 按频率排序
+	// 按频率排序，频率相同时按字母序
 	sort.Slice(words, func(i, j int) bool {
-		return words[i].Count > words[j].Count
+		if words[i].Count != words[j].Count {
+			return words[i].Count > words[j].Count
+		}
+		return words[i].Word < words[j].Word
 	})
 
 	// 返回前N个
@@ -215,6 +715,7 @@ func displayStats(stats *TextStats, config *AnalysisConfig) {
 	fmt.Printf("  总字符数: %d\n", stats.Characters)
 	fmt.Printf("  有效字符数: %d (不含空格)\n", stats.CharactersNoWS)
 	fmt.Printf("  单词数: %d\n", stats.Words)
+	fmt.Printf("  不重复单词数: %d\n", stats.UniqueWords)
 	fmt.Printf("  行数: %d\n", stats.Lines)
 	fmt.Printf("  段落数: %d\n", stats.Paragraphs)
 	fmt.Printf("  句子数: %d\n", stats.Sentences)
@@ -226,12 +727,23 @@ func displayStats(stats *TextStats, config *AnalysisConfig) {
 	fmt.Printf("  英文单词: %d\n", stats.EnglishWords)
 	fmt.Printf("  数字字符: %d\n", stats.Numbers)
 	fmt.Printf("  标点符号: %d\n", stats.Punctuation)
+	fmt.Printf("  主要语言: %s (置信度 %.0f%%)\n", languageNames[stats.Language], stats.LangConfidence*100)
 	fmt.Println()
 
 	// 阅读时间
 	fmt.Printf("⏱️  预估阅读时间: %.1f 分钟\n", stats.ReadingTime)
 	fmt.Println()
 
+	// 情感分析
+	if config.Sentiment {
+		fmt.Printf("💬 情感分析:\n")
+		fmt.Printf("  积极词: %d 次\n", stats.PositiveHits)
+		fmt.Printf("  消极词: %d 次\n", stats.NegativeHits)
+		fmt.Printf("  情感得分: %+.2f (范围 -1 到 +1)\n", stats.SentimentScore)
+		fmt.Printf("  情感倾向: %s\n", sentimentNames[stats.SentimentLabel])
+		fmt.Println()
+	}
+
 	// 词频分析
 	if config.ShowWordFreq && len(stats.TopWords) > 0 {
 		fmt.Printf("📊 高频词汇 (前 %d 个):\n", len(stats.TopWords))
@@ -241,13 +753,40 @@ func displayStats(stats *TextStats, config *AnalysisConfig) {
 		fmt.Println()
 	}
 
+	// 短语分析
+	if config.NGram >= 2 && len(stats.TopPhrases) > 0 {
+		fmt.Printf("🧩 高频短语 (%d-gram, 前 %d 个):\n", config.NGram, len(stats.TopPhrases))
+		for i, phrase := range stats.TopPhrases {
+			fmt.Printf("%2d. %-15s %d 次\n", i+1, phrase.Word, phrase.Count)
+		}
+		fmt.Println()
+	}
+
+	// 关键词分析
+	if len(stats.Keywords) > 0 {
+		fmt.Printf("🔑 关键词 (TF-IDF, 前 %d 个):\n", len(stats.Keywords))
+		for i, keyword := range stats.Keywords {
+			fmt.Printf("%2d. %-15s %.4f\n", i+1, keyword.Word, keyword.Score)
+		}
+		fmt.Println()
+	}
+
 	// 文本复杂度评估
 	fmt.Printf("📈 文本复杂度评估:\n")
-	avgWordsPerSentence := float64(stats.Words) / float64(stats.Sentences)
-	avgCharsPerWord := float64(stats.CharactersNoWS) / float64(stats.Words)
+	// 没有句子结尾标点时按一句计算，空文本时均为0，避免除以0
+	sentences := stats.Sentences
+	if sentences == 0 {
+		sentences = 1
+	}
+	avgWordsPerSentence := float64(stats.Words) / float64(sentences)
+	var avgCharsPerWord float64
+	if stats.Words > 0 {
+		avgCharsPerWord = float64(stats.CharactersNoWS) / float64(stats.Words)
+	}
 
 	fmt.Printf("  平均句长: %.1f 个单词\n", avgWordsPerSentence)
 	fmt.Printf("  平均词长: %.1f 个字符\n", avgCharsPerWord)
+	fmt.Printf("  词汇丰富度: %.2f (不重复单词数/总单词数)\n", stats.TypeTokenRatio)
 
 	// 复杂度评级
 	complexity := "简单"
@@ -257,12 +796,21 @@ func displayStats(stats *TextStats, config *AnalysisConfig) {
 		complexity = "中等"
 	}
 	fmt.Printf("  复杂度等级: %s\n", complexity)
+
+	// 可读性评分
+	if stats.Language == langChinese || stats.Language == langMixed {
+		fmt.Printf("  可读性评分: 不适用 (Flesch 评分仅适用于英文文本)\n")
+	} else if stats.EnglishWords > 0 {
+		fmt.Printf("  Flesch 易读度: %.1f (%s)\n", stats.FleschEase, fleschLevel(stats.FleschEase))
+		fmt.Printf("  Flesch-Kincaid 年级: %.1f\n", stats.FleschGrade)
+	}
 }
 
 // 显示帮助信息
 func showHelp() {
 	fmt.Println("文本分析工具")
-	fmt.Println("用法: text_analyzer [选项] <文件路径>")
+	fmt.Println("用法: text_analyzer [选项] [文件路径...]")
+	fmt.Println("      未指定文件时从标准输入读取")
 	fmt.Println()
 	fmt.Println("选项:")
 	fmt.Println("  -freq          显示词频分析 (默认: false)")
@@ -270,6 +818,11 @@ func showHelp() {
 	fmt.Println("  -minlen        最小单词长度 (默认: 2)")
 	fmt.Println("  -ignore-case   忽略大小写 (默认: true)")
 	fmt.Println("  -speed         阅读速度(字/分钟) (默认: 200)")
+	fmt.Println("  -stopwords     自定义停用词文件，每行一个，与内置停用词合并")
+	fmt.Println("  -no-stopwords  不过滤停用词 (默认: false)")
+	fmt.Println("  -ngram         统计 N 个词组成的高频短语，如 2 或 3 (默认: 0 不统计)")
+	fmt.Println("  -sentiment     基于情感词典进行情感分析 (默认: false)")
+	fmt.Println("  -lexicon       自定义情感词典文件，+词 为积极、-词 为消极，替换内置词典")
 	fmt.Println("  -help          显示帮助信息")
 	fmt.Println()
 	fmt.Println("示例:")
@@ -279,6 +832,21 @@ func showHelp() {
 	fmt.Println("  分析并显示词频统计:")
 	fmt.Println("  text_analyzer -freq -top 20 document.txt")
 	fmt.Println()
+	fmt.Println("  批量分析多个文件并输出汇总:")
+	fmt.Println("  text_analyzer -freq a.txt b.txt c.txt")
+	fmt.Println()
+	fmt.Println("  从管道读取文本:")
+	fmt.Println("  cat document.txt | text_analyzer -freq")
+	fmt.Println()
+	fmt.Println("  统计高频二元短语:")
+	fmt.Println("  text_analyzer -ngram 2 document.txt")
+	fmt.Println()
+	fmt.Println("  情感分析:")
+	fmt.Println("  text_analyzer -sentiment review.txt")
+	fmt.Println()
+	fmt.Println("  使用自定义停用词:")
+	fmt.Println("  text_analyzer -freq -stopwords my_stopwords.txt document.txt")
+	fmt.Println()
 	fmt.Println("  自定义分析参数:")
 	fmt.Println("  text_analyzer -freq -top 15 -minlen 3 -speed 180 document.txt")
 }
@@ -290,6 +858,11 @@ func main() {
 	minWordLength := flag.Int("minlen", 2, "最小单词长度")
 	ignoreCase := flag.Bool("ignore-case", true, "忽略大小写")
 	readingSpeed := flag.Int("speed", 200, "阅读速度(字/分钟)")
+	stopWordsFile := flag.String("stopwords", "", "自定义停用词文件")
+	noStopWords := flag.Bool("no-stopwords", false, "不过滤停用词")
+	nGram := flag.Int("ngram", 0, "统计 N 个词组成的高频短语")
+	sentiment := flag.Bool("sentiment", false, "情感分析")
+	lexiconFile := flag.String("lexicon", "", "自定义情感词典文件")
 	help := flag.Bool("help", false, "显示帮助信息")
 
 	flag.Parse()
@@ -300,42 +873,101 @@ func main() {
 		os.Exit(0)
 	}
 
+	// 创建分析配置
+	config := &AnalysisConfig{
+		ShowWordFreq:  *showWordFreq,
+		TopWordsCount: *topWordsCount,
+		MinWordLength: *minWordLength,
+		IgnoreCase:    *ignoreCase,
+		ReadingSpeed:  *readingSpeed,
+		NoStopWords:   *noStopWords,
+		NGram:         *nGram,
+		Sentiment:     *sentiment,
+	}
+
+	// 加载自定义停用词
+	if *stopWordsFile != "" && !config.NoStopWords {
+		count, err := loadStopWords(*stopWordsFile)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("已加载 %d 个自定义停用词\n", count)
+	}
+
+	// 加载自定义情感词典
+	if *lexiconFile != "" {
+		if !config.Sentiment {
+			fmt.Println("错误: -lexicon 需要与 -sentiment 一起使用")
+			os.Exit(1)
+		}
+		count, err := loadLexicon(*lexiconFile)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("已加载 %d 个情感词\n", count)
+	}
+
 	// 检查文件参数
 	args := flag.Args()
 	if len(args) < 1 {
-		fmt.Println("错误: 请指定要分析的文件路径")
-		fmt.Println("使用 -help 查看使用说明")
-		os.Exit(1)
-	}
+		if !stdinHasData() {
+			fmt.Println("错误: 请指定要分析的文件路径，或通过管道输入文本")
+			fmt.Println("使用 -help 查看使用说明")
+			os.Exit(1)
+		}
 
-	filename := args[0]
+		// 从标准输入分析
+		fmt.Printf("正在分析标准输入\n\n")
+		stats, err := analyzeReader("<stdin>", os.Stdin, config)
+		if err != nil {
+			fmt.Printf("分析失败: %v\n", err)
+			os.Exit(1)
+		}
+		displayStats(stats, config)
+		fmt.Println("========================================")
+		fmt.Println("分析完成!")
+		return
+	}
 
 	// 检查文件是否存在
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		fmt.Printf("错误: 文件 '%s' 不存在\n", filename)
-		os.Exit(1)
+	for _, filename := range args {
+		if _, err := os.Stat(filename); os.IsNotExist(err) {
+			fmt.Printf("错误: 文件 '%s' 不存在\n", filename)
+			os.Exit(1)
+		}
 	}
 
-	// 创建分析配置
-	config := &AnalysisConfig{
-		ShowWordFreq:  *showWordFreq,
-		TopWordsCount: *topWordsCount,
-		MinWordLength: *minWordLength,
-		IgnoreCase:    *ignoreCase,
-		ReadingSpeed:  *readingSpeed,
+	// 逐个分析文本
+	var allStats []*TextStats
+	for _, filename := range args {
+		stats, err := analyzeText(filename, config)
+		if err != nil {
+			fmt.Printf("分析失败: %v\n", err)
+			os.Exit(1)
+		}
+		allStats = append(allStats, stats)
 	}
 
-	// 分析文本
-	fmt.Printf("正在分析文件: %s\n\n", filename)
-	stats, err := analyzeText(filename, config)
-	if err != nil {
-		fmt.Printf("分析失败: %v\n", err)
-		os.Exit(1)
+	// 多文件时计算 TF-IDF 关键词
+	if len(allStats) > 1 && config.ShowWordFreq {
+		computeKeywords(allStats, config.TopWordsCount)
 	}
 
 	// 显示结果
-	displayStats(stats, config)
+	for _, stats := range allStats {
+		fmt.Printf("正在分析文件: %s\n\n", stats.Filename)
+		displayStats(stats, config)
+		fmt.Println("========================================")
+		fmt.Println()
+	}
+
+	// 多文件时输出汇总
+	if len(allStats) > 1 {
+		displayStats(mergeStats(allStats, config), config)
+		fmt.Println("========================================")
+	}
 
-	fmt.Println("========================================")
 	fmt.Println("分析完成!")
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// testConfig 与命令行默认值一致的分析配置
+func testConfig() *AnalysisConfig {
+	return &AnalysisConfig{
+		ShowWordFreq:  true,
+		TopWordsCount: 10,
+		MinWordLength: 1,
+		IgnoreCase:    true,
+		ReadingSpeed:  200,
+	}
+}
+
+// captureStdout 捕获 fn 执行期间写到标准输出的内容
+func captureStdout(t *testing.T, fn func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("创建管道失败: %v", err)
+	}
+	saved := os.Stdout
+	os.Stdout = w
+	done := make(chan string)
+	go func() {
+		data, _ := io.ReadAll(r)
+		done <- string(data)
+	}()
+
+	fn()
+	os.Stdout = saved
+	w.Close()
+	return <-done
+}
+
+func TestAnalyzeCRLF(t *testing.T) {
+	unix := "Hello world.\nSecond line here!\n\nNew paragraph?\n"
+	windows := strings.ReplaceAll(unix, "\n", "\r\n")
+
+	want, err := analyzeReader("unix", strings.NewReader(unix), testConfig())
+	if err != nil {
+		t.Fatalf("分析LF文本失败: %v", err)
+	}
+	got, err := analyzeReader("windows", strings.NewReader(windows), testConfig())
+	if err != nil {
+		t.Fatalf("分析CRLF文本失败: %v", err)
+	}
+
+	if got.Lines != 4 || got.Paragraphs != 2 || got.Sentences != 3 || got.Words != 7 {
+		t.Errorf("CRLF文本 行/段落/句子/单词 = %d/%d/%d/%d, 期望 4/2/3/7",
+			got.Lines, got.Paragraphs, got.Sentences, got.Words)
+	}
+	if got.Lines != want.Lines || got.Paragraphs != want.Paragraphs ||
+		got.Sentences != want.Sentences || got.Words != want.Words || got.UniqueWords != want.UniqueWords {
+		t.Errorf("CRLF与LF统计不一致: %+v vs %+v", got, want)
+	}
+	for word := range got.WordFreq {
+		if strings.ContainsRune(word, '\r') {
+			t.Errorf("单词 %q 中包含 \\r", word)
+		}
+	}
+}
+
+func TestAnalyzeEmptyInput(t *testing.T) {
+	for _, input := range []string{"", "   \n\r\n\t\n"} {
+		stats, err := analyzeReader("empty", strings.NewReader(input), testConfig())
+		if err != nil {
+			t.Fatalf("分析空输入失败: %v", err)
+		}
+		if stats.Words != 0 || stats.Sentences != 0 || stats.Paragraphs != 0 {
+			t.Errorf("空输入 %q 单词/句子/段落 = %d/%d/%d, 期望均为 0",
+				input, stats.Words, stats.Sentences, stats.Paragraphs)
+		}
+		if stats.Language != langUnknown {
+			t.Errorf("空输入 %q 语言 = %s, 期望 %s", input, stats.Language, langUnknown)
+		}
+
+		output := captureStdout(t, func() { displayStats(stats, testConfig()) })
+		if strings.Contains(output, "NaN") || strings.Contains(output, "Inf") {
+			t.Errorf("空输入 %q 的输出包含 NaN/Inf:\n%s", input, output)
+		}
+	}
+}
+
+func TestDisplayStatsWithoutSentenceEnd(t *testing.T) {
+	// 没有句子结尾标点时平均句长按一句计算
+	stats, err := analyzeReader("nopunct", strings.NewReader("one two three"), testConfig())
+	if err != nil {
+		t.Fatalf("分析文本失败: %v", err)
+	}
+	output := captureStdout(t, func() { displayStats(stats, testConfig()) })
+	if !strings.Contains(output, "平均句长: 3.0 个单词") {
+		t.Errorf("输出中缺少 平均句长: 3.0:\n%s", output)
+	}
+}
+
+func TestCountNGramsPerSentence(t *testing.T) {
+	config := testConfig()
+	config.NGram = 2
+	config.NoStopWords = true
+
+	phraseFreq := make(map[string]int)
+	countNGrams("Red apple. Green pear! Red apple?\n大家好。你好", config, phraseFreq)
+
+	want := map[string]int{"red apple": 2, "green pear": 1}
+	for phrase, count := range want {
+		if phraseFreq[phrase] != count {
+			t.Errorf("短语 %q 出现 %d 次, 期望 %d", phrase, phraseFreq[phrase], count)
+		}
+	}
+	// 跨句子边界的组合不应被统计
+	for _, phrase := range []string{"apple green", "pear red", "大家好 你好"} {
+		if phraseFreq[phrase] != 0 {
+			t.Errorf("跨句短语 %q 被统计了 %d 次", phrase, phraseFreq[phrase])
+		}
+	}
+	if len(phraseFreq) != len(want) {
+		t.Errorf("短语统计 = %v, 期望 %v", phraseFreq, want)
+	}
+}
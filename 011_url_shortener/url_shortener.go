@@ -2,16 +2,29 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"crypto/md5"
+	"encoding/csv"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"log"
 	"math/rand"
+	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
+	"unicode/utf8"
 )
 
 /**
@@ -26,22 +39,44 @@ URL短链接生成器
 
 // URLEntry URL条目结构体
 type URLEntry struct {
-	ID          string     // 短链接ID
-	OriginalURL string     // 原始URL
-	ShortCode   string     // 短链接代码
-	CreatedAt   time.Time  // 创建时间
-	ExpiresAt   *time.Time // 过期时间（可选）
-	AccessCount int        // 访问次数
-	LastAccess  *time.Time // 最后访问时间
-	CustomAlias string     // 自定义别名
-	Description string     // 描述信息
+	ID          string         // 短链接ID
+	OriginalURL string         // 原始URL
+	ShortCode   string         // 短链接代码
+	CreatedAt   time.Time      // 创建时间
+	ExpiresAt   *time.Time     // 过期时间（可选）
+	AccessCount int            // 访问次数
+	LastAccess  *time.Time     // 最后访问时间
+	CustomAlias string         // 自定义别名
+	Description string         // 描述信息
+	AccessLog   []AccessRecord // 访问记录（最多保留 maxAccessLog 条）
 }
 
+// AccessRecord 单次访问记录
+type AccessRecord struct {
+	Time      time.Time // 访问时间
+	Referrer  string    `json:",omitempty"` // 来源页面（HTTP服务模式）
+	UserAgent string    `json:",omitempty"` // 客户端标识（HTTP服务模式）
+}
+
+// 每个短链接最多保留的访问记录条数，避免无限增长
+const maxAccessLog = 1000
+
+// 访问记录中来源和客户端标识的最大字节数，避免超长请求头撑大存储文件
+const maxAccessFieldLength = 256
+
+// 访问统计写入存储文件的间隔，重定向时只在内存中更新
+const accessFlushInterval = 5 * time.Second
+
 // URLShortener URL短链接服务结构体
 type URLShortener struct {
+	mu         sync.RWMutex         // 保护 URLs 的并发访问
 	URLs       map[string]*URLEntry // 存储URL条目 (shortCode -> URLEntry)
 	BaseURL    string               // 基础URL
 	CodeLength int                  // 短链接代码长度
+	StorePath  string               // 持久化存储文件路径（为空则仅保存在内存）
+	Mode       string               // 短链接代码生成方式 (random/hash/sequential)
+	Counter    uint64               // 顺序模式的计数器
+	dirty      bool                 // 访问统计已更新但尚未写入存储文件
 }
 
 // ShortenerConfig 短链接服务配置
@@ -49,6 +84,48 @@ type ShortenerConfig struct {
 	BaseURL    string // 基础URL
 	CodeLength int    // 短链接代码长度
 	DefaultTTL int    // 默认过期时间（小时）
+	StorePath  string // 持久化存储文件路径
+	Mode       string // 短链接代码生成方式
+}
+
+// storeData 持久化存储文件内容
+type storeData struct {
+	URLs    map[string]*URLEntry
+	Counter uint64 // 顺序模式的计数器，保证重启后不重复
+}
+
+// 短链接代码生成方式
+const (
+	ModeRandom     = "random"     // 随机字符串
+	ModeHash       = "hash"       // 基于URL的MD5哈希
+	ModeSequential = "sequential" // 递增计数器的base62编码
+)
+
+// 随机和哈希模式下生成唯一代码的最大尝试次数
+const maxGenerateAttempts = 100
+
+// 短链接操作的错误类型，便于调用方（如HTTP服务）区分处理
+var (
+	errNotFound    = errors.New("不存在")
+	errExpired     = errors.New("已过期")
+	errAliasExists = errors.New("已存在")
+	errInvalidURL  = errors.New("无效的URL格式")
+)
+
+// createRequest HTTP创建短链接请求体
+type createRequest struct {
+	URL         string `json:"url"`
+	Alias       string `json:"alias"`
+	Description string `json:"description"`
+	TTLHours    int    `json:"ttl_hours"`
+}
+
+// createResponse HTTP创建短链接响应体
+type createResponse struct {
+	ShortCode   string     `json:"short_code"`
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
 }
 
 // 字符集用于生成短链接代码
@@ -60,6 +137,106 @@ func NewURLShortener(config *ShortenerConfig) *URLShortener {
 		URLs:       make(map[string]*URLEntry),
 		BaseURL:    config.BaseURL,
 		CodeLength: config.CodeLength,
+		StorePath:  config.StorePath,
+		Mode:       config.Mode,
+	}
+}
+
+// 从存储文件加载短链接数据
+func (us *URLShortener) Load() error {
+	if us.StorePath == "" {
+		return nil
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	data, err := os.ReadFile(us.StorePath)
+	if err != nil {
+		// 文件不存在时从空存储开始
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取存储文件失败: %v", err)
+	}
+
+	var store storeData
+	if err := json.Unmarshal(data, &store); err != nil {
+		return fmt.Errorf("解析存储文件失败: %v", err)
+	}
+	if store.URLs != nil {
+		us.URLs = store.URLs
+	}
+	us.Counter = store.Counter
+	return nil
+}
+
+// 将短链接数据保存到存储文件（调用方需持有锁）
+func (us *URLShortener) save() error {
+	if us.StorePath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(storeData{URLs: us.URLs, Counter: us.Counter}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化存储数据失败: %v", err)
+	}
+
+	// 先写临时文件再重命名，避免写入中断导致存储文件损坏
+	tmpPath := us.StorePath + ".tmp"
+	if dir := filepath.Dir(us.StorePath); dir != "." {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建存储目录失败: %v", err)
+		}
+	}
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("写入存储文件失败: %v", err)
+	}
+	if err := os.Rename(tmpPath, us.StorePath); err != nil {
+		return fmt.Errorf("写入存储文件失败: %v", err)
+	}
+	us.dirty = false
+	return nil
+}
+
+// 将尚未保存的访问统计写入存储文件
+func (us *URLShortener) Flush() error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	if !us.dirty {
+		return nil
+	}
+	return us.save()
+}
+
+// 启动后台定期保存访问统计的goroutine，返回的函数停止后台保存并做最后一次保存
+func (us *URLShortener) StartFlush(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ticker.C:
+				if err := us.Flush(); err != nil {
+					log.Printf("保存访问统计失败: %v", err)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		if err := us.Flush(); err != nil {
+			fmt.Printf("警告: %v\n", err)
+		}
 	}
 }
 
@@ -73,24 +250,162 @@ func (us *URLShortener) generateShortCode() string {
 	return string(b)
 }
 
-// 生成基于URL的哈希代码
-func (us *URLShortener) generateHashCode(originalURL string) string {
-	hash := md5.Sum([]byte(originalURL + strconv.FormatInt(time.Now().Unix(), 10)))
+// 生成基于URL的哈希代码，attempt 用于冲突时改变哈希输入
+func (us *URLShortener) generateHashCode(originalURL string, attempt int) string {
+	input := originalURL + strconv.FormatInt(time.Now().Unix(), 10) + strconv.Itoa(attempt)
+	hash := md5.Sum([]byte(input))
 	hashStr := hex.EncodeToString(hash[:])
-	return hashStr[:us.CodeLength]
+	if us.CodeLength < len(hashStr) {
+		return hashStr[:us.CodeLength]
+	}
+	return hashStr
 }
 
-// 验证URL格式
-func isValidURL(rawURL string) bool {
-	_, err := url.ParseRequestURI(rawURL)
-	return err == nil
+// 将数字编码为base62字符串
+func encodeBase62(n uint64) string {
+	if n == 0 {
+		return string(charset[0])
+	}
+	var b []byte
+	for n > 0 {
+		b = append([]byte{charset[n%uint64(len(charset))]}, b...)
+		n /= uint64(len(charset))
+	}
+	return string(b)
+}
+
+// 生成顺序短链接代码：递增计数器并跳过已被自定义别名占用的代码
+func (us *URLShortener) generateSequentialCode() string {
+	for {
+		us.Counter++
+		code := encodeBase62(us.Counter)
+		if _, exists := us.URLs[code]; !exists {
+			return code
+		}
+	}
+}
+
+// 按配置的生成方式获取唯一的短链接代码（调用方需持有锁）
+func (us *URLShortener) nextShortCode(originalURL string) (string, error) {
+	if us.Mode == ModeSequential {
+		return us.generateSequentialCode(), nil
+	}
+
+	for attempt := 0; attempt < maxGenerateAttempts; attempt++ {
+		var shortCode string
+		if us.Mode == ModeHash {
+			shortCode = us.generateHashCode(originalURL, attempt)
+		} else {
+			shortCode = us.generateShortCode()
+		}
+		if _, exists := us.URLs[shortCode]; !exists {
+			return shortCode, nil
+		}
+	}
+	return "", fmt.Errorf("生成短链接代码失败: 尝试 %d 次均冲突，请增大代码长度", maxGenerateAttempts)
+}
+
+// 验证并规范化URL：仅允许 http/https，要求主机名非空，
+// 协议和主机名转为小写，去掉默认端口，空路径补为 "/"
+func normalizeURL(rawURL string) (string, error) {
+	if strings.TrimSpace(rawURL) != rawURL || strings.ContainsAny(rawURL, " \t\r\n") {
+		return "", fmt.Errorf("%w: 包含空白字符: %q", errInvalidURL, rawURL)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errInvalidURL, rawURL)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("%w: 仅支持 http/https 协议: %s", errInvalidURL, rawURL)
+	}
+	if u.Opaque != "" || u.Hostname() == "" {
+		return "", fmt.Errorf("%w: 缺少主机名: %s", errInvalidURL, rawURL)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if strings.HasSuffix(u.Host, ":") {
+		return "", fmt.Errorf("%w: 端口为空: %s", errInvalidURL, rawURL)
+	}
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return "", fmt.Errorf("%w: 端口无效: %s", errInvalidURL, rawURL)
+		}
+	}
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+
+	// IPv6 地址需要保留方括号
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if port != "" {
+		host += ":" + port
+	}
+	u.Host = host
+
+	if u.Path == "" {
+		u.Path = "/"
+		u.RawPath = ""
+	}
+	return u.String(), nil
+}
+
+// 查找指向相同URL且未过期的短链接（调用方需持有锁）
+func (us *URLShortener) findActiveByURL(originalURL string) *URLEntry {
+	now := time.Now()
+	for _, entry := range us.URLs {
+		if entry.OriginalURL != originalURL {
+			continue
+		}
+		if entry.ExpiresAt != nil && now.After(*entry.ExpiresAt) {
+			continue
+		}
+		return entry
+	}
+	return nil
+}
+
+// 复制URL条目，避免调用方在锁外读取时与写操作产生竞争
+func copyEntry(entry *URLEntry) *URLEntry {
+	copied := *entry
+	copied.AccessLog = append([]AccessRecord(nil), entry.AccessLog...)
+	return &copied
 }
 
 // 创建短链接
 func (us *URLShortener) CreateShortURL(originalURL, customAlias, description string, ttlHours int) (*URLEntry, error) {
-	// 验证URL格式
-	if !isValidURL(originalURL) {
-		return nil, fmt.Errorf("无效的URL格式: %s", originalURL)
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	entry, created, err := us.createEntry(originalURL, customAlias, description, ttlHours)
+	if err != nil || !created {
+		return entry, err
+	}
+	if err := us.save(); err != nil {
+		return entry, err
+	}
+	return entry, nil
+}
+
+// 创建短链接但不保存（调用方需持有锁），复用已有短链接时 created 为 false
+func (us *URLShortener) createEntry(originalURL, customAlias, description string, ttlHours int) (entry *URLEntry, created bool, err error) {
+	// 验证并规范化URL格式
+	normalized, err := normalizeURL(originalURL)
+	if err != nil {
+		return nil, false, err
+	}
+	originalURL = normalized
+
+	// 未指定别名时，相同URL复用已有的有效短链接
+	if customAlias == "" {
+		if existing := us.findActiveByURL(originalURL); existing != nil {
+			return copyEntry(existing), false, nil
+		}
 	}
 
 	var shortCode string
@@ -98,21 +413,20 @@ func (us *URLShortener) CreateShortURL(originalURL, customAlias, description str
 	// 如果提供了自定义别名，检查是否已存在
 	if customAlias != "" {
 		if _, exists := us.URLs[customAlias]; exists {
-			return nil, fmt.Errorf("自定义别名 '%s' 已存在", customAlias)
+			return nil, false, fmt.Errorf("自定义别名 '%s' %w", customAlias, errAliasExists)
 		}
 		shortCode = customAlias
 	} else {
 		// 生成短链接代码，确保唯一性
-		for {
-			shortCode = us.generateShortCode()
-			if _, exists := us.URLs[shortCode]; !exists {
-				break
-			}
+		code, err := us.nextShortCode(originalURL)
+		if err != nil {
+			return nil, false, err
 		}
+		shortCode = code
 	}
 
 	// 创建URL条目
-	entry := &URLEntry{
+	entry = &URLEntry{
 		ID:          fmt.Sprintf("url_%d", time.Now().Unix()),
 		OriginalURL: originalURL,
 		ShortCode:   shortCode,
@@ -130,59 +444,145 @@ func (us *URLShortener) CreateShortURL(originalURL, customAlias, description str
 
 	// 存储URL条目
 	us.URLs[shortCode] = entry
-
-	return entry, nil
+	return copyEntry(entry), true, nil
 }
 
 // 解析短链接
 func (us *URLShortener) ResolveShortURL(shortCode string) (*URLEntry, error) {
-	entry, exists := us.URLs[shortCode]
-	if !exists {
-		return nil, fmt.Errorf("短链接 '%s' 不存在", shortCode)
-	}
+	return us.ResolveShortURLFrom(shortCode, "", "")
+}
 
-	// 检查是否过期
-	if entry.ExpiresAt != nil && time.Now().After(*entry.ExpiresAt) {
-		return nil, fmt.Errorf("短链接 '%s' 已过期", shortCode)
+// 解析短链接并记录访问来源信息
+// 访问统计只在内存中更新，由 Flush 或 StartFlush 批量写入存储文件
+func (us *URLShortener) ResolveShortURLFrom(shortCode, referrer, userAgent string) (*URLEntry, error) {
+	// 更新访问统计属于写操作，需要写锁
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	entry, err := us.activeEntry(shortCode)
+	if err != nil {
+		return nil, err
 	}
 
 	// 更新访问统计
 	entry.AccessCount++
 	now := time.Now()
 	entry.LastAccess = &now
+	entry.AccessLog = append(entry.AccessLog, AccessRecord{
+		Time:      now,
+		Referrer:  truncateField(referrer),
+		UserAgent: truncateField(userAgent),
+	})
+	if len(entry.AccessLog) > maxAccessLog {
+		entry.AccessLog = entry.AccessLog[len(entry.AccessLog)-maxAccessLog:]
+	}
+	us.dirty = true
 
+	return copyEntry(entry), nil
+}
+
+// 查找未过期的短链接但不记录访问（如HTTP HEAD请求）
+func (us *URLShortener) LookupShortURL(shortCode string) (*URLEntry, error) {
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+
+	entry, err := us.activeEntry(shortCode)
+	if err != nil {
+		return nil, err
+	}
+	return copyEntry(entry), nil
+}
+
+// 获取存在且未过期的短链接条目（调用方需持有锁）
+func (us *URLShortener) activeEntry(shortCode string) (*URLEntry, error) {
+	entry, exists := us.URLs[shortCode]
+	if !exists {
+		return nil, fmt.Errorf("短链接 '%s' %w", shortCode, errNotFound)
+	}
+	if entry.ExpiresAt != nil && time.Now().After(*entry.ExpiresAt) {
+		return nil, fmt.Errorf("短链接 '%s' %w", shortCode, errExpired)
+	}
 	return entry, nil
 }
 
+// 截断过长的访问记录字段，保证不截断在多字节字符中间
+func truncateField(s string) string {
+	if len(s) <= maxAccessFieldLength {
+		return s
+	}
+	s = s[:maxAccessFieldLength]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
 // 获取短链接统计信息
 func (us *URLShortener) GetStats(shortCode string) (*URLEntry, error) {
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+
 	entry, exists := us.URLs[shortCode]
 	if !exists {
-		return nil, fmt.Errorf("短链接 '%s' 不存在", shortCode)
+		return nil, fmt.Errorf("短链接 '%s' %w", shortCode, errNotFound)
 	}
-	return entry, nil
+	return copyEntry(entry), nil
 }
 
 // 列出所有短链接
 func (us *URLShortener) ListURLs() []*URLEntry {
+	us.mu.RLock()
+	defer us.mu.RUnlock()
+
 	var entries []*URLEntry
 	for _, entry := range us.URLs {
-		entries = append(entries, entry)
+		entries = append(entries, copyEntry(entry))
 	}
 	return entries
 }
 
+// 修改短链接指向的URL，保留代码、创建时间和访问统计
+func (us *URLShortener) UpdateShortURL(shortCode, newURL string) (*URLEntry, error) {
+	normalized, err := normalizeURL(newURL)
+	if err != nil {
+		return nil, err
+	}
+
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	entry, exists := us.URLs[shortCode]
+	if !exists {
+		return nil, fmt.Errorf("短链接 '%s' %w", shortCode, errNotFound)
+	}
+	if entry.ExpiresAt != nil && time.Now().After(*entry.ExpiresAt) {
+		return nil, fmt.Errorf("短链接 '%s' %w", shortCode, errExpired)
+	}
+
+	entry.OriginalURL = normalized
+	if err := us.save(); err != nil {
+		return copyEntry(entry), err
+	}
+	return copyEntry(entry), nil
+}
+
 // 删除短链接
 func (us *URLShortener) DeleteShortURL(shortCode string) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
 	if _, exists := us.URLs[shortCode]; !exists {
-		return fmt.Errorf("短链接 '%s' 不存在", shortCode)
+		return fmt.Errorf("短链接 '%s' %w", shortCode, errNotFound)
 	}
 	delete(us.URLs, shortCode)
-	return nil
+	return us.save()
 }
 
 // 清理过期链接
 func (us *URLShortener) CleanupExpired() int {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
 	var expired []string
 	now := time.Now()
 
@@ -195,10 +595,160 @@ func (us *URLShortener) CleanupExpired() int {
 	for _, shortCode := range expired {
 		delete(us.URLs, shortCode)
 	}
+	if len(expired) > 0 {
+		if err := us.save(); err != nil {
+			fmt.Printf("警告: %v\n", err)
+		}
+	}
 
 	return len(expired)
 }
 
+// importResult 批量导入中单行的处理结果
+type importResult struct {
+	Line  int       // 行号
+	Entry *URLEntry // 创建成功的条目
+	Err   error     // 失败原因
+}
+
+// importRow 导入文件中待创建的一行
+type importRow struct {
+	line     int
+	url      string
+	alias    string
+	ttlHours int
+}
+
+// 从CSV文件批量导入URL，每行格式: originalURL[,alias][,ttlHours]
+// 单行失败不会中断整个导入；全部行在同一次加锁中创建，最后只保存一次
+func importURLs(shortener *URLShortener, filename string) ([]importResult, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("打开导入文件失败: %v", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	var results []importResult
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			// CSV格式错误时记录并继续读取下一行
+			if parseErr, ok := err.(*csv.ParseError); ok {
+				results = append(results, importResult{Line: parseErr.Line, Err: parseErr.Err})
+				continue
+			}
+			return results, fmt.Errorf("读取导入文件失败: %v", err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		originalURL := strings.TrimSpace(record[0])
+		if originalURL == "" {
+			continue
+		}
+
+		var alias string
+		var ttlHours int
+		if len(record) > 1 {
+			alias = strings.TrimSpace(record[1])
+		}
+		if len(record) > 2 && strings.TrimSpace(record[2]) != "" {
+			hours, err := strconv.Atoi(strings.TrimSpace(record[2]))
+			if err != nil || hours < 0 {
+				results = append(results, importResult{Line: line, Err: fmt.Errorf("无效的过期小时数: %s", record[2])})
+				continue
+			}
+			ttlHours = hours
+		}
+
+		rows = append(rows, importRow{line: line, url: originalURL, alias: alias, ttlHours: ttlHours})
+	}
+
+	shortener.mu.Lock()
+	defer shortener.mu.Unlock()
+
+	created := 0
+	for _, row := range rows {
+		entry, isNew, err := shortener.createEntry(row.url, row.alias, "", row.ttlHours)
+		results = append(results, importResult{Line: row.line, Entry: entry, Err: err})
+		if isNew {
+			created++
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Line < results[j].Line })
+
+	if created > 0 {
+		if err := shortener.save(); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// 显示批量导入结果
+func displayImportResults(results []importResult, baseURL string) {
+	succeeded := 0
+	for _, result := range results {
+		if result.Err == nil {
+			succeeded++
+		}
+	}
+
+	if succeeded > 0 {
+		fmt.Printf("✅ 创建成功 (%d 条):\n", succeeded)
+		fmt.Printf("%-6s %-30s %s\n", "行号", "短链接", "原始URL")
+		for _, result := range results {
+			if result.Err == nil {
+				fmt.Printf("%-6d %-30s %s\n", result.Line, baseURL+"/"+result.Entry.ShortCode, result.Entry.OriginalURL)
+			}
+		}
+		fmt.Println()
+	}
+
+	if failed := len(results) - succeeded; failed > 0 {
+		fmt.Printf("❌ 导入失败 (%d 条):\n", failed)
+		for _, result := range results {
+			if result.Err != nil {
+				fmt.Printf("  第 %d 行: %v\n", result.Line, result.Err)
+			}
+		}
+		fmt.Println()
+	}
+
+	fmt.Printf("📊 导入汇总: 共 %d 条, 成功 %d 条, 失败 %d 条\n", len(results), succeeded, len(results)-succeeded)
+}
+
+// 启动后台定期清理过期链接的goroutine，返回用于停止的函数
+func (us *URLShortener) StartCleanup(interval time.Duration) func() {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				// CleanupExpired 内部持有写锁，可与其他操作并发执行
+				if count := us.CleanupExpired(); count > 0 {
+					log.Printf("后台清理: 已删除 %d 个过期链接", count)
+				}
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
+
 // 显示URL条目详细信息
 func displayURLEntry(entry *URLEntry, baseURL string) {
 	fmt.Printf("🔗 短链接信息:\n")
@@ -231,6 +781,53 @@ func displayURLEntry(entry *URLEntry, baseURL string) {
 	}
 }
 
+// 显示访问分析：每日访问量和最繁忙时段
+func displayAccessAnalytics(entry *URLEntry) {
+	if len(entry.AccessLog) == 0 {
+		fmt.Println("  暂无访问记录")
+		return
+	}
+
+	dailyHits := make(map[string]int)
+	var hourlyHits [24]int
+	referrers := make(map[string]int)
+	for _, record := range entry.AccessLog {
+		local := record.Time.Local()
+		dailyHits[local.Format("2006-01-02")]++
+		hourlyHits[local.Hour()]++
+		if record.Referrer != "" {
+			referrers[record.Referrer]++
+		}
+	}
+
+	var days []string
+	for day := range dailyHits {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+
+	fmt.Printf("📈 访问分析 (最近 %d 次访问):\n", len(entry.AccessLog))
+	fmt.Println("  每日访问量:")
+	for _, day := range days {
+		fmt.Printf("    %s  %d 次\n", day, dailyHits[day])
+	}
+
+	busiestHour := 0
+	for hour, count := range hourlyHits {
+		if count > hourlyHits[busiestHour] {
+			busiestHour = hour
+		}
+	}
+	fmt.Printf("  最繁忙时段: %02d:00-%02d:59 (%d 次)\n", busiestHour, busiestHour, hourlyHits[busiestHour])
+
+	if len(referrers) > 0 {
+		fmt.Println("  访问来源:")
+		for referrer, count := range referrers {
+			fmt.Printf("    %s  %d 次\n", referrer, count)
+		}
+	}
+}
+
 // 显示所有URL列表
 func displayURLList(entries []*URLEntry, baseURL string) {
 	if len(entries) == 0 {
@@ -341,6 +938,26 @@ func runInteractiveMode(shortener *URLShortener) {
 				fmt.Printf("获取统计失败: %v\n", err)
 			} else {
 				displayURLEntry(entry, shortener.BaseURL)
+				displayAccessAnalytics(entry)
+			}
+
+		case "update", "u":
+			if len(parts) < 3 {
+				fmt.Println("用法: update <短链接代码> <新URL>")
+				continue
+			}
+
+			oldEntry, err := shortener.GetStats(parts[1])
+			if err != nil {
+				fmt.Printf("修改失败: %v\n", err)
+				continue
+			}
+			entry, err := shortener.UpdateShortURL(parts[1], parts[2])
+			if err != nil {
+				fmt.Printf("修改失败: %v\n", err)
+			} else {
+				fmt.Printf("✅ 短链接 '%s' 已修改: %s -> %s\n", entry.ShortCode, oldEntry.OriginalURL, entry.OriginalURL)
+				displayURLEntry(entry, shortener.BaseURL)
 			}
 
 		case "delete", "d":
@@ -371,6 +988,120 @@ func runInteractiveMode(shortener *URLShortener) {
 	}
 }
 
+// HTTP服务模式：GET /{code} 重定向，POST / 创建短链接
+func runServer(shortener *URLShortener, addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			handleRedirect(shortener, w, r)
+		case http.MethodPost:
+			handleCreate(shortener, w, r)
+		default:
+			w.Header().Set("Allow", "GET, HEAD, POST")
+			http.Error(w, "不支持的请求方法", http.StatusMethodNotAllowed)
+		}
+	})
+
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	fmt.Printf("🌐 短链接服务已启动: http://%s\n", addr)
+	fmt.Println("  GET  /{代码}  重定向到原始URL")
+	fmt.Println("  POST /       创建短链接 (JSON: url, alias, description, ttl_hours)")
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.ListenAndServe() }()
+
+	// 收到中断信号时优雅关闭，使调用方能保存尚未写入的访问统计
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-sigCh:
+		fmt.Println("\n正在关闭服务...")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return server.Shutdown(ctx)
+	}
+}
+
+// 处理短链接重定向请求
+func handleRedirect(shortener *URLShortener, w http.ResponseWriter, r *http.Request) {
+	shortCode := strings.TrimPrefix(r.URL.Path, "/")
+	if shortCode == "" || strings.Contains(shortCode, "/") {
+		http.NotFound(w, r)
+		return
+	}
+
+	// HEAD 请求（如链接预览、健康检查）不计入访问统计
+	var entry *URLEntry
+	var err error
+	if r.Method == http.MethodHead {
+		entry, err = shortener.LookupShortURL(shortCode)
+	} else {
+		entry, err = shortener.ResolveShortURLFrom(shortCode, r.Referer(), r.UserAgent())
+	}
+	switch {
+	case errors.Is(err, errNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, errExpired):
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("重定向 %s -> %s", shortCode, entry.OriginalURL)
+	http.Redirect(w, r, entry.OriginalURL, http.StatusFound)
+}
+
+// 处理创建短链接请求
+func handleCreate(shortener *URLShortener, w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var req createRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("请求体解析失败: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	entry, err := shortener.CreateShortURL(req.URL, req.Alias, req.Description, req.TTLHours)
+	switch {
+	case errors.Is(err, errInvalidURL):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, errAliasExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	log.Printf("创建 %s -> %s", entry.ShortCode, entry.OriginalURL)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createResponse{
+		ShortCode:   entry.ShortCode,
+		ShortURL:    shortener.BaseURL + "/" + entry.ShortCode,
+		OriginalURL: entry.OriginalURL,
+		ExpiresAt:   entry.ExpiresAt,
+	})
+}
+
 // 显示交互模式帮助
 func showInteractiveHelp() {
 	fmt.Println("\n📖 可用命令:")
@@ -378,6 +1109,7 @@ func showInteractiveHelp() {
 	fmt.Println("  resolve <代码>                      - 解析短链接")
 	fmt.Println("  list                               - 列出所有短链接")
 	fmt.Println("  stats <代码>                       - 查看链接统计")
+	fmt.Println("  update <代码> <URL>                - 修改短链接指向的URL")
 	fmt.Println("  delete <代码>                      - 删除短链接")
 	fmt.Println("  cleanup                            - 清理过期链接")
 	fmt.Println("  help                               - 显示帮助")
@@ -400,7 +1132,12 @@ func showHelp() {
 	fmt.Println("  -ttl         过期时间(小时) (默认: 0, 永不过期)")
 	fmt.Println("  -base        基础URL (默认: http://short.ly)")
 	fmt.Println("  -length      短链接代码长度 (默认: 6)")
+	fmt.Println("  -mode        代码生成方式: random/hash/sequential (默认: random)")
+	fmt.Println("  -store       存储文件路径，为空则不持久化 (默认: urls.json)")
 	fmt.Println("  -interactive 交互模式")
+	fmt.Println("  -import      从CSV文件批量导入，每行: URL[,别名][,过期小时]")
+	fmt.Println("  -serve       启动HTTP重定向服务的监听地址，如 :8080")
+	fmt.Println("  -cleanup-interval 服务/交互模式下后台清理过期链接的间隔，如 1h (默认: 0, 不自动清理)")
 	fmt.Println("  -help        显示帮助信息")
 	fmt.Println()
 	fmt.Println("示例:")
@@ -415,6 +1152,12 @@ func showHelp() {
 	fmt.Println()
 	fmt.Println("  启动交互模式:")
 	fmt.Println("  url_shortener -interactive")
+	fmt.Println()
+	fmt.Println("  批量导入URL:")
+	fmt.Println("  url_shortener -import urls.csv")
+	fmt.Println()
+	fmt.Println("  启动HTTP重定向服务:")
+	fmt.Println("  url_shortener -serve :8080 -base http://localhost:8080")
 }
 
 func main() {
@@ -425,7 +1168,12 @@ func main() {
 	ttlHours := flag.Int("ttl", 0, "过期时间(小时)")
 	baseURL := flag.String("base", "http://short.ly", "基础URL")
 	codeLength := flag.Int("length", 6, "短链接代码长度")
+	storePath := flag.String("store", "urls.json", "存储文件路径")
+	mode := flag.String("mode", ModeRandom, "代码生成方式 (random/hash/sequential)")
 	interactive := flag.Bool("interactive", false, "交互模式")
+	serveAddr := flag.String("serve", "", "HTTP服务监听地址")
+	importFile := flag.String("import", "", "批量导入的CSV文件")
+	cleanupInterval := flag.Duration("cleanup-interval", 0, "后台清理过期链接的间隔")
 	help := flag.Bool("help", false, "显示帮助信息")
 
 	flag.Parse()
@@ -436,14 +1184,61 @@ func main() {
 		os.Exit(0)
 	}
 
+	// 检查代码生成方式
+	switch *mode {
+	case ModeRandom, ModeHash, ModeSequential:
+	default:
+		fmt.Printf("错误: 不支持的代码生成方式 '%s'，可选: random/hash/sequential\n", *mode)
+		os.Exit(1)
+	}
+
 	// 创建短链接服务配置
 	config := &ShortenerConfig{
 		BaseURL:    *baseURL,
 		CodeLength: *codeLength,
+		StorePath:  *storePath,
+		Mode:       *mode,
 	}
 
-	// 创建短链接服务
+	// 创建短链接服务并加载已保存的数据
 	shortener := NewURLShortener(config)
+	if err := shortener.Load(); err != nil {
+		fmt.Printf("加载失败: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 批量导入模式
+	if *importFile != "" {
+		fmt.Printf("正在从 %s 导入...\n\n", *importFile)
+		results, err := importURLs(shortener, *importFile)
+		if err != nil {
+			fmt.Printf("导入失败: %v\n", err)
+			os.Exit(1)
+		}
+		displayImportResults(results, shortener.BaseURL)
+		return
+	}
+
+	// 长时间运行的模式下定期保存访问统计，退出时再保存一次
+	if *serveAddr != "" || *interactive {
+		stopFlush := shortener.StartFlush(accessFlushInterval)
+		defer stopFlush()
+	}
+
+	// 长时间运行的模式下启动后台清理
+	if *cleanupInterval > 0 && (*serveAddr != "" || *interactive) {
+		stopCleanup := shortener.StartCleanup(*cleanupInterval)
+		defer stopCleanup()
+	}
+
+	// HTTP服务模式
+	if *serveAddr != "" {
+		if err := runServer(shortener, *serveAddr); err != nil {
+			fmt.Printf("服务启动失败: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
 
 	// 交互模式
 	if *interactive {
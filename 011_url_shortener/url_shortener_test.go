@@ -0,0 +1,279 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 多个goroutine同时创建和解析短链接，配合 go test -race 检查数据竞争
+func TestConcurrentCreateResolve(t *testing.T) {
+	storePath := filepath.Join(t.TempDir(), "urls.json")
+	config := &ShortenerConfig{BaseURL: "http://short.ly/", CodeLength: 6, StorePath: storePath, Mode: ModeRandom}
+	shortener := NewURLShortener(config)
+
+	shared, err := shortener.CreateShortURL("https://example.com/shared", "", "", 0)
+	if err != nil {
+		t.Fatalf("创建共享短链接失败: %v", err)
+	}
+
+	const goroutines = 16
+	const perGoroutine = 10
+
+	var wg sync.WaitGroup
+	errs := make(chan error, goroutines*perGoroutine*3)
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				entry, err := shortener.CreateShortURL(fmt.Sprintf("https://example.com/%d/%d", g, i), "", "", 0)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				if _, err := shortener.ResolveShortURLFrom(entry.ShortCode, "https://ref.example/", "test"); err != nil {
+					errs <- err
+				}
+				if _, err := shortener.ResolveShortURLFrom(shared.ShortCode, "", ""); err != nil {
+					errs <- err
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if got, want := len(shortener.ListURLs()), goroutines*perGoroutine+1; got != want {
+		t.Errorf("短链接数量 = %d, 期望 %d", got, want)
+	}
+	stats, err := shortener.GetStats(shared.ShortCode)
+	if err != nil {
+		t.Fatalf("获取共享短链接统计失败: %v", err)
+	}
+	if want := goroutines * perGoroutine; stats.AccessCount != want {
+		t.Errorf("共享短链接访问次数 = %d, 期望 %d", stats.AccessCount, want)
+	}
+
+	// 存储文件应包含全部短链接
+	reloaded := NewURLShortener(config)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("重新加载存储文件失败: %v", err)
+	}
+	if got, want := len(reloaded.ListURLs()), goroutines*perGoroutine+1; got != want {
+		t.Errorf("重新加载后短链接数量 = %d, 期望 %d", got, want)
+	}
+}
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// 协议和主机名不区分大小写，路径区分大小写
+		{"HTTPS://Example.COM", "https://example.com/"},
+		{"http://EXAMPLE.com/Path", "http://example.com/Path"},
+		{"HTTP://[2001:DB8::1]:8080", "http://[2001:db8::1]:8080/"},
+		// 去掉协议的默认端口，其他端口保留
+		{"http://example.com:80/a", "http://example.com/a"},
+		{"https://example.com:443", "https://example.com/"},
+		{"http://example.com:443/", "http://example.com:443/"},
+		{"http://[::1]:80/", "http://[::1]/"},
+		// 空路径补为 "/"，已有的末尾斜杠保留
+		{"https://example.com", "https://example.com/"},
+		{"https://example.com/a/", "https://example.com/a/"},
+		{"https://example.com/a", "https://example.com/a"},
+		// 查询参数和片段原样保留
+		{"https://example.com?q=1", "https://example.com/?q=1"},
+		{"https://example.com/a/?q=1&B=2", "https://example.com/a/?q=1&B=2"},
+		{"https://example.com#top", "https://example.com/#top"},
+		{"https://Example.com:443/a?q=1#Frag", "https://example.com/a?q=1#Frag"},
+	}
+	for _, tt := range tests {
+		got, err := normalizeURL(tt.input)
+		if err != nil {
+			t.Errorf("normalizeURL(%q) 返回错误: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("normalizeURL(%q) = %q, 期望 %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizeURLInvalid(t *testing.T) {
+	for _, input := range []string{
+		"ftp://example.com",
+		"mailto:a@b.c",
+		"https://",
+		"https://example.com:",
+		"https://example.com:99999",
+		" https://example.com",
+		"http://example.com/a b",
+	} {
+		if got, err := normalizeURL(input); !errors.Is(err, errInvalidURL) {
+			t.Errorf("normalizeURL(%q) = %q, %v, 期望 errInvalidURL", input, got, err)
+		}
+	}
+}
+
+// reloadedAccessCount 从存储文件重新加载后返回短链接的访问次数
+func reloadedAccessCount(t *testing.T, config *ShortenerConfig, shortCode string) int {
+	reloaded := NewURLShortener(config)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("重新加载存储文件失败: %v", err)
+	}
+	entry, err := reloaded.GetStats(shortCode)
+	if err != nil {
+		t.Fatalf("获取统计失败: %v", err)
+	}
+	return entry.AccessCount
+}
+
+func TestResolveDefersSaveUntilFlush(t *testing.T) {
+	config := &ShortenerConfig{CodeLength: 6, StorePath: filepath.Join(t.TempDir(), "urls.json"), Mode: ModeSequential}
+	shortener := NewURLShortener(config)
+	entry, err := shortener.CreateShortURL("https://example.com/", "", "", 0)
+	if err != nil {
+		t.Fatalf("创建短链接失败: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := shortener.ResolveShortURL(entry.ShortCode); err != nil {
+			t.Fatalf("解析短链接失败: %v", err)
+		}
+	}
+	if got := reloadedAccessCount(t, config, entry.ShortCode); got != 0 {
+		t.Errorf("Flush 前存储文件中的访问次数 = %d, 期望 0", got)
+	}
+
+	if err := shortener.Flush(); err != nil {
+		t.Fatalf("Flush 失败: %v", err)
+	}
+	if got := reloadedAccessCount(t, config, entry.ShortCode); got != 3 {
+		t.Errorf("Flush 后存储文件中的访问次数 = %d, 期望 3", got)
+	}
+
+	// 停止后台保存时会做最后一次保存
+	stop := shortener.StartFlush(time.Hour)
+	if _, err := shortener.ResolveShortURL(entry.ShortCode); err != nil {
+		t.Fatalf("解析短链接失败: %v", err)
+	}
+	stop()
+	if got := reloadedAccessCount(t, config, entry.ShortCode); got != 4 {
+		t.Errorf("停止后台保存后存储文件中的访问次数 = %d, 期望 4", got)
+	}
+}
+
+func TestHeadRequestNotCounted(t *testing.T) {
+	shortener := NewURLShortener(&ShortenerConfig{CodeLength: 6, Mode: ModeSequential})
+	entry, err := shortener.CreateShortURL("https://example.com/", "", "", 0)
+	if err != nil {
+		t.Fatalf("创建短链接失败: %v", err)
+	}
+
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		rec := httptest.NewRecorder()
+		handleRedirect(shortener, rec, httptest.NewRequest(method, "/"+entry.ShortCode, nil))
+		if rec.Code != http.StatusFound {
+			t.Errorf("%s 状态码 = %d, 期望 %d", method, rec.Code, http.StatusFound)
+		}
+	}
+
+	stats, err := shortener.GetStats(entry.ShortCode)
+	if err != nil {
+		t.Fatalf("获取统计失败: %v", err)
+	}
+	if stats.AccessCount != 1 {
+		t.Errorf("HEAD 和 GET 各一次后访问次数 = %d, 期望 1", stats.AccessCount)
+	}
+
+	rec := httptest.NewRecorder()
+	handleRedirect(shortener, rec, httptest.NewRequest(http.MethodHead, "/missing", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("HEAD 不存在的代码状态码 = %d, 期望 %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestAccessLogFieldsTruncated(t *testing.T) {
+	shortener := NewURLShortener(&ShortenerConfig{CodeLength: 6, Mode: ModeSequential})
+	entry, err := shortener.CreateShortURL("https://example.com/", "", "", 0)
+	if err != nil {
+		t.Fatalf("创建短链接失败: %v", err)
+	}
+
+	// 多字节字符跨越截断位置时整个字符被丢弃
+	userAgent := strings.Repeat("a", maxAccessFieldLength-1) + "浏览器"
+	resolved, err := shortener.ResolveShortURLFrom(entry.ShortCode, strings.Repeat("r", 4096), userAgent)
+	if err != nil {
+		t.Fatalf("解析短链接失败: %v", err)
+	}
+	record := resolved.AccessLog[0]
+	if len(record.Referrer) != maxAccessFieldLength {
+		t.Errorf("来源长度 = %d, 期望 %d", len(record.Referrer), maxAccessFieldLength)
+	}
+	if want := strings.Repeat("a", maxAccessFieldLength-1); record.UserAgent != want {
+		t.Errorf("客户端标识截断为 %d 字节, 期望 %d 字节", len(record.UserAgent), len(want))
+	}
+}
+
+func TestImportURLs(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "urls.csv")
+	content := `# url,alias,ttlHours
+https://www.github.com,github
+https://www.google.com,,24
+not-a-url
+https://www.example.com,github
+https://www.example.org,,abc
+https://www.github.com
+`
+	if err := os.WriteFile(csvPath, []byte(content), 0644); err != nil {
+		t.Fatalf("写入导入文件失败: %v", err)
+	}
+
+	config := &ShortenerConfig{CodeLength: 6, StorePath: filepath.Join(dir, "urls.json"), Mode: ModeSequential}
+	shortener := NewURLShortener(config)
+	results, err := importURLs(shortener, csvPath)
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+
+	wantOK := []bool{true, true, false, false, false, true}
+	if len(results) != len(wantOK) {
+		t.Fatalf("导入结果 %d 条, 期望 %d 条", len(results), len(wantOK))
+	}
+	for i, result := range results {
+		if result.Line != i+2 {
+			t.Errorf("第 %d 条结果的行号 = %d, 期望 %d", i+1, result.Line, i+2)
+		}
+		if (result.Err == nil) != wantOK[i] {
+			t.Errorf("第 %d 行 错误 = %v, 期望成功: %v", result.Line, result.Err, wantOK[i])
+		}
+	}
+	if !errors.Is(results[3].Err, errAliasExists) {
+		t.Errorf("重复别名的错误 = %v, 期望 errAliasExists", results[3].Err)
+	}
+	// 未指定别名的重复URL复用已有短链接
+	if results[5].Entry.ShortCode != "github" {
+		t.Errorf("重复URL的短链接 = %s, 期望复用 github", results[5].Entry.ShortCode)
+	}
+
+	reloaded := NewURLShortener(config)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("重新加载存储文件失败: %v", err)
+	}
+	if got := len(reloaded.ListURLs()); got != 2 {
+		t.Errorf("存储文件中的短链接数量 = %d, 期望 2", got)
+	}
+}
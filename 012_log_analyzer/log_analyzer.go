@@ -2,15 +2,28 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"html/template"
 	"io"
+	"math"
+	"math/big"
+	"net"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -35,24 +48,47 @@ type LogEntry struct {
 	Size      int       `json:"size,omitempty"`
 	UserAgent string    `json:"user_agent,omitempty"`
 	Source    string    `json:"source"`
+
+	ResponseTime    float64 `json:"response_time_ms,omitempty"` // 响应时间（毫秒）
+	HasResponseTime bool    `json:"-"`                          // 日志中是否包含响应时间
 }
 
 // LogStats 日志统计结构体
 type LogStats struct {
-	TotalLines   int            `json:"total_lines"`
-	ValidLines   int            `json:"valid_lines"`
-	ErrorLines   int            `json:"error_lines"`
-	LevelCounts  map[string]int `json:"level_counts"`
-	IPCounts     map[string]int `json:"ip_counts"`
-	StatusCounts map[string]int `json:"status_counts"`
-	MethodCounts map[string]int `json:"method_counts"`
-	HourlyCounts map[string]int `json:"hourly_counts"`
-	TopIPs       []string       `json:"top_ips"`
-	TopURLs      []string       `json:"top_urls"`
-	TopErrors    map[string]int `json:"top_errors"`
-	StartTime    *time.Time     `json:"start_time"`
-	EndTime      *time.Time     `json:"end_time"`
-	TimeRange    string         `json:"time_range"`
+	TotalLines   int               `json:"total_lines"`
+	ValidLines   int               `json:"valid_lines"`
+	ErrorLines   int               `json:"error_lines"`
+	LevelCounts  map[string]int    `json:"level_counts"`
+	IPCounts     map[string]int    `json:"ip_counts"`
+	StatusCounts map[string]int    `json:"status_counts"`
+	MethodCounts map[string]int    `json:"method_counts"`
+	URLCounts    map[string]int    `json:"url_counts"`
+	HourlyCounts map[string]int    `json:"hourly_counts"`
+	TopIPs       []string          `json:"top_ips"`
+	TopIPGeo     map[string]string `json:"top_ip_geo,omitempty"` // Top IP 的地理位置（指定 -geoip 时）
+	TopURLs      []string          `json:"top_urls"`
+	TopErrors    map[string]int    `json:"top_errors"`
+	StartTime    *time.Time        `json:"start_time"`
+	EndTime      *time.Time        `json:"end_time"`
+	TimeRange    string            `json:"time_range"`
+	FileCounts   map[string]int    `json:"file_counts,omitempty"`
+
+	ResponseTimes []float64 `json:"-"`                        // 所有响应时间（毫秒），用于计算百分位
+	LatencyP50    float64   `json:"latency_p50_ms,omitempty"` // 响应时间中位数
+	LatencyP90    float64   `json:"latency_p90_ms,omitempty"`
+	LatencyP99    float64   `json:"latency_p99_ms,omitempty"`
+
+	HourAnomalies []Anomaly `json:"hour_anomalies,omitempty"` // 请求量异常的小时
+	IPAnomalies   []Anomaly `json:"ip_anomalies,omitempty"`   // 请求量异常偏高的IP
+	AnomalyNote   string    `json:"anomaly_note,omitempty"`   // 数据不足等跳过异常检测的原因
+}
+
+// Anomaly 偏离均值超过阈值的统计项
+type Anomaly struct {
+	Key    string  `json:"key"`     // 小时（2006-01-02 15）或IP
+	Count  int     `json:"count"`   // 请求数
+	Mean   float64 `json:"mean"`    // 所有统计项的平均请求数
+	ZScore float64 `json:"z_score"` // 偏离均值的标准差倍数，负数表示低于均值
 }
 
 // LogAnalyzer 日志分析器结构体
@@ -61,28 +97,58 @@ type LogAnalyzer struct {
 	Stats    LogStats
 	Patterns map[string]*regexp.Regexp
 	Config   AnalyzerConfig
+	Format   string // 当前实际使用的日志格式（auto 模式下为检测结果）
+
+	GeoIP    *GeoIPReader      // GeoIP 数据库，为 nil 时不查询地理位置
+	geoCache map[string]string // IP 地理位置查询缓存
 }
 
 // AnalyzerConfig 分析器配置
 type AnalyzerConfig struct {
-	LogFormat     string // 日志格式类型
-	TimeFormat    string // 时间格式
-	FilterLevel   string // 过滤日志级别
-	FilterPattern string // 过滤模式
-	OutputFormat  string // 输出格式
-	TopN          int    // 显示前N项统计
-	ShowDetails   bool   // 显示详细信息
+	LogFormat     string         // 日志格式类型
+	TimeFormat    string         // 时间格式
+	CustomFields  map[string]int // 自定义格式的字段与捕获组序号映射
+	Workers       int            // 并发解析的worker数量（<=1 为串行）
+	FilterLevel   string         // 过滤日志级别
+	FilterPattern string         // 过滤模式
+	OutputFormat  string         // 输出格式
+	TopN          int            // 显示前N项统计
+	ShowDetails   bool           // 显示详细信息
+	Since         time.Time      // 起始时间（零值表示不限制）
+	Until         time.Time      // 结束时间（零值表示不限制）
+	AnomalySigma  float64        // 异常检测阈值（标准差倍数），<=0 表示不检测
 }
 
 // 预定义的日志格式正则表达式
 var logPatterns = map[string]string{
-	"apache": `^(\S+) \S+ \S+ \[([^\]]+)\] "(\S+) ([^"]*)" (\d+) (\d+|-) "([^"]*)" "([^"]*)"`,
-	"nginx":  `^(\S+) - - \[([^\]]+)\] "(\S+) ([^"]*)" (\d+) (\d+|-) "([^"]*)" "([^"]*)"`,
+	"apache": `^(\S+) \S+ \S+ \[([^\]]+)\] "(\S+) ([^"]*)" (\d+) (\d+|-) "([^"]*)" "([^"]*)"(?: (?:rt=)?(\d+(?:\.\d+)?))?`,
+	"nginx":  `^(\S+) - - \[([^\]]+)\] "(\S+) ([^"]*)" (\d+) (\d+|-) "([^"]*)" "([^"]*)"(?: (?:rt=)?(\d+(?:\.\d+)?))?`,
 	"common": `^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}) \[(\w+)\] (.*)`,
 	"json":   `^\{.*\}$`,
 	"syslog": `^(\w{3} \d{1,2} \d{2}:\d{2}:\d{2}) (\S+) (\S+): (.*)`,
 }
 
+// 自定义格式支持的字段名
+var customFieldNames = map[string]bool{
+	"ip": true, "ts": true, "level": true, "msg": true, "method": true,
+	"url": true, "status": true, "size": true, "ua": true, "rt": true,
+}
+
+// 自定义格式未指定时间格式时依次尝试的时间格式
+var customTimeLayouts = []string{
+	time.RFC3339,
+	"02/Jan/2006:15:04:05 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006/01/02 15:04:05",
+}
+
+// 自动检测格式时的候选顺序，匹配数相同时靠前的优先
+var detectOrder = []string{"nginx", "apache", "common", "syslog", "json"}
+
+// 自动检测格式时采样的非空行数
+const detectSampleLines = 20
+
 // 时间格式映射
 var timeFormats = map[string]string{
 	"apache":  "02/Jan/2006:15:04:05 -0700",
@@ -93,20 +159,34 @@ var timeFormats = map[string]string{
 	"syslog":  "Jan 2 15:04:05",
 }
 
+// 异常检测至少需要的数据点数（小时数或IP数），少于该值时跳过
+const minAnomalyPoints = 5
+
+// 补齐无请求小时时允许的最大小时跨度，超过时只使用有请求的小时
+const maxAnomalyHours = 24 * 366
+
+// newLogStats 创建初始化好各统计映射的LogStats
+func newLogStats() LogStats {
+	return LogStats{
+		LevelCounts:  make(map[string]int),
+		IPCounts:     make(map[string]int),
+		StatusCounts: make(map[string]int),
+		MethodCounts: make(map[string]int),
+		URLCounts:    make(map[string]int),
+		HourlyCounts: make(map[string]int),
+		TopErrors:    make(map[string]int),
+		FileCounts:   make(map[string]int),
+	}
+}
+
 // NewLogAnalyzer 创建新的日志分析器
 func NewLogAnalyzer(config AnalyzerConfig) *LogAnalyzer {
 	analyzer := &LogAnalyzer{
-		Entries: make([]LogEntry, 0),
-		Stats: LogStats{
-			LevelCounts:  make(map[string]int),
-			IPCounts:     make(map[string]int),
-			StatusCounts: make(map[string]int),
-			MethodCounts: make(map[string]int),
-			HourlyCounts: make(map[string]int),
-			TopErrors:    make(map[string]int),
-		},
+		Entries:  make([]LogEntry, 0),
+		Stats:    newLogStats(),
 		Patterns: make(map[string]*regexp.Regexp),
 		Config:   config,
+		geoCache: make(map[string]string),
 	}
 
 	// 编译正则表达式
@@ -119,7 +199,48 @@ func NewLogAnalyzer(config AnalyzerConfig) *LogAnalyzer {
 	return analyzer
 }
 
-// ParseLogFile 解析日志文件
+// gzip文件头魔数
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// SetCustomPattern 设置用户自定义的日志格式
+// fields 形如 "ip=1,ts=2,status=5"，将字段映射到正则表达式的捕获组序号
+func (la *LogAnalyzer) SetCustomPattern(pattern, fields string) error {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("自定义正则表达式无效: %v", err)
+	}
+
+	mapping := make(map[string]int)
+	for _, pair := range strings.Split(fields, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("字段映射格式错误: %s (应为 名称=序号)", pair)
+		}
+		name := strings.ToLower(strings.TrimSpace(parts[0]))
+		if !customFieldNames[name] {
+			return fmt.Errorf("不支持的字段: %s (可用: ip, ts, level, msg, method, url, status, size, ua, rt)", name)
+		}
+		index, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || index < 1 || index > compiled.NumSubexp() {
+			return fmt.Errorf("字段 %s 的捕获组序号无效: %s (正则共有 %d 个捕获组)", name, parts[1], compiled.NumSubexp())
+		}
+		mapping[name] = index
+	}
+	if len(mapping) == 0 {
+		return fmt.Errorf("使用自定义正则时必须通过 -fields 指定字段映射")
+	}
+
+	la.Patterns["custom"] = compiled
+	la.Config.CustomFields = mapping
+	la.Config.LogFormat = "custom"
+	return nil
+}
+
+// ParseLogFile 解析日志文件，支持gzip压缩的日志（.gz后缀或gzip文件头）
 func (la *LogAnalyzer) ParseLogFile(filename string) error {
 	file, err := os.Open(filename)
 	if err != nil {
@@ -127,47 +248,268 @@ func (la *LogAnalyzer) ParseLogFile(filename string) error {
 	}
 	defer file.Close()
 
-	return la.ParseLogReader(file, filename)
+	reader := bufio.NewReader(file)
+	magic, _ := reader.Peek(len(gzipMagic))
+	isGzip := len(magic) == len(gzipMagic) && magic[0] == gzipMagic[0] && magic[1] == gzipMagic[1]
+	if !isGzip {
+		if strings.HasSuffix(strings.ToLower(filename), ".gz") {
+			return fmt.Errorf("文件 %s 不是有效的gzip文件", filename)
+		}
+		return la.ParseLogReader(reader, filename)
+	}
+
+	gzReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return fmt.Errorf("无法解压gzip文件 %s: %v", filename, err)
+	}
+	defer gzReader.Close()
+
+	err = la.ParseLogReader(gzReader, filename)
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, gzip.ErrChecksum) || errors.Is(err, gzip.ErrHeader) {
+		return fmt.Errorf("gzip文件 %s 已截断或损坏: %v", filename, err)
+	}
+	return err
+}
+
+// stdinSource 从标准输入读取时使用的来源名称
+const stdinSource = "stdin"
+
+// stdinIsPipe 判断标准输入是否为管道或重定向（而非终端）
+func stdinIsPipe() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice == 0
+}
+
+// expandLogFiles 展开逗号分隔的文件列表和通配符，按修改时间从旧到新排序
+func expandLogFiles(spec string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		matches := []string{part}
+		if strings.ContainsAny(part, "*?[") {
+			globbed, err := filepath.Glob(part)
+			if err != nil {
+				return nil, fmt.Errorf("无效的通配符 %s: %v", part, err)
+			}
+			if len(globbed) == 0 {
+				return nil, fmt.Errorf("通配符 %s 未匹配到任何文件", part)
+			}
+			matches = globbed
+		}
+
+		for _, match := range matches {
+			if !seen[match] {
+				seen[match] = true
+				files = append(files, match)
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("未指定日志文件")
+	}
+
+	// 轮转日志中越旧的文件修改时间越早，按修改时间排序使时间范围连续
+	modTimes := make(map[string]time.Time)
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, fmt.Errorf("无法访问日志文件: %v", err)
+		}
+		modTimes[file] = info.ModTime()
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		if !modTimes[files[i]].Equal(modTimes[files[j]]) {
+			return modTimes[files[i]].Before(modTimes[files[j]])
+		}
+		return files[i] < files[j]
+	})
+
+	return files, nil
 }
 
 // ParseLogReader 解析日志读取器
 func (la *LogAnalyzer) ParseLogReader(reader io.Reader, source string) error {
 	scanner := bufio.NewScanner(reader)
-	lineNum := 0
+	la.Format = la.Config.LogFormat
+	startLines := la.Stats.TotalLines
+	defer func() { la.Stats.FileCounts[source] += la.Stats.TotalLines - startLines }()
+
+	// 默认串行处理，指定多个worker时分发到worker池并发解析
+	process := func(line string) { la.processLine(line, source) }
+	wait := func() {}
+	if la.Config.Workers > 1 {
+		process, wait = la.startWorkers(source)
+	}
+
+	// 自动检测模式下先缓存前几行用于判断格式
+	var pending []string
+	detecting := la.Config.LogFormat == "auto"
 
 	for scanner.Scan() {
-		lineNum++
 		line := strings.TrimSpace(scanner.Text())
 		if line == "" {
 			continue
 		}
 
-		la.Stats.TotalLines++
-
-		entry, err := la.parseLine(line, source)
-		if err != nil {
-			la.Stats.ErrorLines++
-			continue
-		}
-
-		// 应用过滤器
-		if la.shouldFilterEntry(entry) {
+		if detecting {
+			pending = append(pending, line)
+			if len(pending) < detectSampleLines {
+				continue
+			}
+			la.Format = la.detectFormat(pending)
+			for _, buffered := range pending {
+				process(buffered)
+			}
+			pending = nil
+			detecting = false
 			continue
 		}
 
-		la.Entries = append(la.Entries, entry)
-		la.Stats.ValidLines++
-		la.updateStats(entry)
+		process(line)
 	}
 
 	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("读取日志文件时出错: %v", err)
+		wait()
+		return fmt.Errorf("读取日志文件时出错: %w", err)
 	}
 
+	// 文件行数不足采样数时，用已读取的行检测
+	if detecting {
+		la.Format = la.detectFormat(pending)
+		for _, buffered := range pending {
+			process(buffered)
+		}
+	}
+
+	wait()
 	la.calculateDerivedStats()
 	return nil
 }
 
+// processLine 解析单行日志并更新统计
+func (la *LogAnalyzer) processLine(line, source string) {
+	if entry, ok := la.processLineInto(&la.Stats, line, source); ok {
+		la.Entries = append(la.Entries, entry)
+	}
+}
+
+// processLineInto 解析单行日志并将结果计入指定的统计，返回条目是否被保留
+func (la *LogAnalyzer) processLineInto(stats *LogStats, line, source string) (LogEntry, bool) {
+	stats.TotalLines++
+
+	entry, err := la.parseLine(line, source)
+	if err != nil {
+		stats.ErrorLines++
+		return LogEntry{}, false
+	}
+
+	// 应用过滤器
+	if la.shouldFilterEntry(entry) {
+		return LogEntry{}, false
+	}
+
+	stats.ValidLines++
+	la.updateStats(stats, entry)
+	return entry, true
+}
+
+// startWorkers 启动worker池并发解析日志行，每个worker维护本地统计，
+// 返回分发行的函数和等待全部完成并合并统计的函数
+func (la *LogAnalyzer) startWorkers(source string) (func(string), func()) {
+	lines := make(chan string, 1024)
+	partials := make([]LogStats, la.Config.Workers)
+	keepEntries := la.Config.ShowDetails || la.Config.OutputFormat == "csv"
+
+	var wg sync.WaitGroup
+	var entriesMu sync.Mutex
+	for i := range partials {
+		partials[i] = newLogStats()
+		wg.Add(1)
+		go func(stats *LogStats) {
+			defer wg.Done()
+			for line := range lines {
+				entry, ok := la.processLineInto(stats, line, source)
+				// 只有需要输出明细时才保存条目，此时需要加锁
+				if ok && keepEntries {
+					entriesMu.Lock()
+					la.Entries = append(la.Entries, entry)
+					entriesMu.Unlock()
+				}
+			}
+		}(&partials[i])
+	}
+
+	dispatch := func(line string) { lines <- line }
+	wait := func() {
+		close(lines)
+		wg.Wait()
+		for i := range partials {
+			la.Stats.merge(&partials[i])
+		}
+	}
+	return dispatch, wait
+}
+
+// merge 将另一份统计合并到当前统计
+func (s *LogStats) merge(other *LogStats) {
+	s.TotalLines += other.TotalLines
+	s.ValidLines += other.ValidLines
+	s.ErrorLines += other.ErrorLines
+
+	mergeCounts := func(dst, src map[string]int) {
+		for k, v := range src {
+			dst[k] += v
+		}
+	}
+	mergeCounts(s.LevelCounts, other.LevelCounts)
+	mergeCounts(s.IPCounts, other.IPCounts)
+	mergeCounts(s.StatusCounts, other.StatusCounts)
+	mergeCounts(s.MethodCounts, other.MethodCounts)
+	mergeCounts(s.URLCounts, other.URLCounts)
+	mergeCounts(s.HourlyCounts, other.HourlyCounts)
+	mergeCounts(s.TopErrors, other.TopErrors)
+	s.ResponseTimes = append(s.ResponseTimes, other.ResponseTimes...)
+
+	if other.StartTime != nil && (s.StartTime == nil || other.StartTime.Before(*s.StartTime)) {
+		s.StartTime = other.StartTime
+	}
+	if other.EndTime != nil && (s.EndTime == nil || other.EndTime.After(*s.EndTime)) {
+		s.EndTime = other.EndTime
+	}
+}
+
+// detectFormat 根据样本行检测日志格式，选择匹配行数最多的格式
+func (la *LogAnalyzer) detectFormat(lines []string) string {
+	best, bestCount := "generic", 0
+	for _, name := range detectOrder {
+		pattern := la.Patterns[name]
+		if pattern == nil {
+			continue
+		}
+
+		count := 0
+		for _, line := range lines {
+			if pattern.MatchString(line) {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = name, count
+		}
+	}
+	return best
+}
+
 // parseLine 解析单行日志
 func (la *LogAnalyzer) parseLine(line, source string) (LogEntry, error) {
 	entry := LogEntry{Source: source}
@@ -180,23 +522,95 @@ func (la *LogAnalyzer) parseLine(line, source string) (LogEntry, error) {
 	}
 
 	// 尝试预定义格式
-	switch la.Config.LogFormat {
+	switch la.Format {
 	case "apache", "nginx":
 		return la.parseWebLog(line, source)
 	case "common":
 		return la.parseCommonLog(line, source)
 	case "syslog":
 		return la.parseSyslog(line, source)
+	case "custom":
+		return la.parseCustomLog(line, source)
 	default:
 		return la.parseGenericLog(line, source)
 	}
 }
 
+// parseCustomLog 按用户自定义的正则和字段映射解析日志
+func (la *LogAnalyzer) parseCustomLog(line, source string) (LogEntry, error) {
+	pattern := la.Patterns["custom"]
+	matches := pattern.FindStringSubmatch(line)
+	if matches == nil {
+		return LogEntry{}, fmt.Errorf("日志格式不匹配")
+	}
+
+	field := func(name string) string {
+		if index, ok := la.Config.CustomFields[name]; ok {
+			return matches[index]
+		}
+		return ""
+	}
+
+	entry := LogEntry{
+		IP:        field("ip"),
+		Method:    field("method"),
+		URL:       field("url"),
+		UserAgent: field("ua"),
+		Message:   field("msg"),
+		Level:     strings.ToUpper(field("level")),
+		Source:    source,
+	}
+
+	// 解析时间
+	if ts := field("ts"); ts != "" {
+		layouts := customTimeLayouts
+		if la.Config.TimeFormat != "" {
+			layouts = []string{la.Config.TimeFormat}
+		}
+		for _, layout := range layouts {
+			if timestamp, err := time.Parse(layout, ts); err == nil {
+				entry.Timestamp = timestamp
+				break
+			}
+		}
+	}
+
+	// 解析状态码
+	if status, err := strconv.Atoi(field("status")); err == nil {
+		entry.Status = status
+		if entry.Level == "" {
+			entry.Level = la.getLogLevelFromStatus(status)
+		}
+	}
+
+	// 解析大小
+	if size, err := strconv.Atoi(field("size")); err == nil {
+		entry.Size = size
+	}
+
+	// 解析响应时间
+	if rt := field("rt"); rt != "" {
+		if ms, err := parseResponseTime(rt); err == nil {
+			entry.ResponseTime = ms
+			entry.HasResponseTime = true
+		}
+	}
+
+	if entry.Level == "" {
+		entry.Level = "INFO"
+	}
+	if entry.Message == "" {
+		entry.Message = line
+	}
+
+	return entry, nil
+}
+
 // parseWebLog 解析Web服务器日志
 func (la *LogAnalyzer) parseWebLog(line, source string) (LogEntry, error) {
-	pattern := la.Patterns[la.Config.LogFormat]
+	pattern := la.Patterns[la.Format]
 	if pattern == nil {
-		return LogEntry{}, fmt.Errorf("未找到格式模式: %s", la.Config.LogFormat)
+		return LogEntry{}, fmt.Errorf("未找到格式模式: %s", la.Format)
 	}
 
 	matches := pattern.FindStringSubmatch(line)
@@ -207,13 +621,17 @@ func (la *LogAnalyzer) parseWebLog(line, source string) (LogEntry, error) {
 	entry := LogEntry{
 		IP:        matches[1],
 		Method:    matches[3],
-		URL:       matches[4],
 		UserAgent: matches[8],
 		Source:    source,
 	}
 
+	// 请求行形如 "/index.html HTTP/1.1"，去掉协议版本只保留路径
+	if fields := strings.Fields(matches[4]); len(fields) > 0 {
+		entry.URL = fields[0]
+	}
+
 	// 解析时间
-	if timestamp, err := time.Parse(timeFormats[la.Config.LogFormat], matches[2]); err == nil {
+	if timestamp, err := time.Parse(timeFormats[la.Format], matches[2]); err == nil {
 		entry.Timestamp = timestamp
 	}
 
@@ -230,11 +648,54 @@ func (la *LogAnalyzer) parseWebLog(line, source string) (LogEntry, error) {
 		}
 	}
 
+	// 解析可选的响应时间（日志行末尾追加的 $request_time 或 %D）
+	if len(matches) > 9 && matches[9] != "" {
+		if ms, err := parseResponseTime(matches[9]); err == nil {
+			entry.ResponseTime = ms
+			entry.HasResponseTime = true
+		}
+	}
+
 	entry.Message = fmt.Sprintf("%s %s - %d", entry.Method, entry.URL, entry.Status)
 
 	return entry, nil
 }
 
+// parseResponseTime 将响应时间解析为毫秒
+// 带单位时按单位解析（如 120ms、0.5s）；带小数点时视为秒（Nginx $request_time）；
+// 纯整数视为微秒（Apache %D）
+func parseResponseTime(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if strings.IndexFunc(value, func(r rune) bool { return r >= 'a' && r <= 'z' }) >= 0 {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, err
+		}
+		return float64(d) / float64(time.Millisecond), nil
+	}
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	if strings.Contains(value, ".") {
+		return n * 1000, nil
+	}
+	return n / 1000, nil
+}
+
+// percentile 计算已排序数据的百分位（最近秩法）
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
 // parseCommonLog 解析通用日志格式
 func (la *LogAnalyzer) parseCommonLog(line, source string) (LogEntry, error) {
 	pattern := la.Patterns["common"]
@@ -284,13 +745,12 @@ func (la *LogAnalyzer) parseSyslog(line, source string) (LogEntry, error) {
 func (la *LogAnalyzer) parseGenericLog(line, source string) (LogEntry, error) {
 	// 简单的通用解析，提取时间戳和消息
 	entry := LogEntry{
-		Message:   line,
-		Level:     "INFO",
-		Source:    source,
-		Timestamp: time.Now(),
+		Message: line,
+		Level:   "INFO",
+		Source:  source,
 	}
 
-	// 尝试提取时间戳
+	// 尝试提取时间戳，提取不到时保持零值，不计入时间范围和小时分布
 	timeRegex := regexp.MustCompile(`(\d{4}-\d{2}-\d{2}[\sT]\d{2}:\d{2}:\d{2})`)
 	if matches := timeRegex.FindStringSubmatch(line); len(matches) > 1 {
 		if timestamp, err := time.Parse("2006-01-02 15:04:05", matches[1]); err == nil {
@@ -325,8 +785,38 @@ func (la *LogAnalyzer) getLogLevelFromStatus(status int) string {
 	}
 }
 
+// parseTimeFlag 解析时间范围参数，支持RFC3339和 "2006-01-02 15:04:05" 等格式
+// 不带时区的时间按UTC解析，与解析不带时区的日志时间戳保持一致
+func parseTimeFlag(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间 %q，请使用RFC3339或 2006-01-02 15:04:05 格式", value)
+}
+
 // shouldFilterEntry 检查是否应该过滤该条目
 func (la *LogAnalyzer) shouldFilterEntry(entry LogEntry) bool {
+	// 按时间范围过滤，设置了范围时丢弃无法解析时间的条目
+	if !la.Config.Since.IsZero() || !la.Config.Until.IsZero() {
+		if entry.Timestamp.IsZero() {
+			return true
+		}
+		if !la.Config.Since.IsZero() && entry.Timestamp.Before(la.Config.Since) {
+			return true
+		}
+		if !la.Config.Until.IsZero() && entry.Timestamp.After(la.Config.Until) {
+			return true
+		}
+	}
+
 	// 按级别过滤
 	if la.Config.FilterLevel != "" && entry.Level != la.Config.FilterLevel {
 		return true
@@ -342,41 +832,55 @@ func (la *LogAnalyzer) shouldFilterEntry(entry LogEntry) bool {
 }
 
 // updateStats 更新统计信息
-func (la *LogAnalyzer) updateStats(entry LogEntry) {
+func (la *LogAnalyzer) updateStats(stats *LogStats, entry LogEntry) {
 	// 更新级别统计
-	la.Stats.LevelCounts[entry.Level]++
+	stats.LevelCounts[entry.Level]++
 
 	// 更新IP统计
 	if entry.IP != "" {
-		la.Stats.IPCounts[entry.IP]++
+		stats.IPCounts[entry.IP]++
 	}
 
 	// 更新状态码统计
 	if entry.Status > 0 {
 		statusRange := fmt.Sprintf("%dxx", entry.Status/100)
-		la.Stats.StatusCounts[statusRange]++
+		stats.StatusCounts[statusRange]++
 	}
 
 	// 更新方法统计
 	if entry.Method != "" {
-		la.Stats.MethodCounts[entry.Method]++
+		stats.MethodCounts[entry.Method]++
 	}
 
-	// 更新小时统计
-	hourKey := entry.Timestamp.Format("2006-01-02 15")
-	la.Stats.HourlyCounts[hourKey]++
+	// 更新URL统计
+	if entry.URL != "" {
+		stats.URLCounts[entry.URL]++
+	}
+
+	// 更新小时统计（没有时间戳的条目不参与）
+	if !entry.Timestamp.IsZero() {
+		hourKey := entry.Timestamp.Format("2006-01-02 15")
+		stats.HourlyCounts[hourKey]++
+	}
 
 	// 更新错误统计
 	if entry.Level == "ERROR" || entry.Level == "FATAL" {
-		la.Stats.TopErrors[entry.Message]++
+		stats.TopErrors[entry.Message]++
 	}
 
 	// 更新时间范围
-	if la.Stats.StartTime == nil || entry.Timestamp.Before(*la.Stats.StartTime) {
-		la.Stats.StartTime = &entry.Timestamp
+	if !entry.Timestamp.IsZero() {
+		if stats.StartTime == nil || entry.Timestamp.Before(*stats.StartTime) {
+			stats.StartTime = &entry.Timestamp
+		}
+		if stats.EndTime == nil || entry.Timestamp.After(*stats.EndTime) {
+			stats.EndTime = &entry.Timestamp
+		}
 	}
-	if la.Stats.EndTime == nil || entry.Timestamp.After(*la.Stats.EndTime) {
-		la.Stats.EndTime = &entry.Timestamp
+
+	// 记录响应时间
+	if entry.HasResponseTime {
+		stats.ResponseTimes = append(stats.ResponseTimes, entry.ResponseTime)
 	}
 }
 
@@ -384,12 +888,123 @@ func (la *LogAnalyzer) updateStats(entry LogEntry) {
 func (la *LogAnalyzer) calculateDerivedStats() {
 	// 计算Top IPs
 	la.Stats.TopIPs = la.getTopItems(la.Stats.IPCounts, la.Config.TopN)
+	if la.GeoIP != nil {
+		la.Stats.TopIPGeo = make(map[string]string)
+		for _, ip := range la.Stats.TopIPs {
+			if location := la.geoLocate(ip); location != "" {
+				la.Stats.TopIPGeo[ip] = location
+			}
+		}
+	}
+
+	// 计算Top URLs
+	la.Stats.TopURLs = la.getTopItems(la.Stats.URLCounts, la.Config.TopN)
 
 	// 计算时间范围
 	if la.Stats.StartTime != nil && la.Stats.EndTime != nil {
 		duration := la.Stats.EndTime.Sub(*la.Stats.StartTime)
 		la.Stats.TimeRange = duration.String()
 	}
+
+	// 计算响应时间百分位
+	if len(la.Stats.ResponseTimes) > 0 {
+		sort.Float64s(la.Stats.ResponseTimes)
+		la.Stats.LatencyP50 = percentile(la.Stats.ResponseTimes, 50)
+		la.Stats.LatencyP90 = percentile(la.Stats.ResponseTimes, 90)
+		la.Stats.LatencyP99 = percentile(la.Stats.ResponseTimes, 99)
+	}
+
+	// 异常检测
+	if la.Config.AnomalySigma > 0 {
+		la.detectAnomalies()
+	}
+}
+
+// detectAnomalies 找出请求数偏离均值超过 AnomalySigma 个标准差的小时和IP
+func (la *LogAnalyzer) detectAnomalies() {
+	la.Stats.HourAnomalies = nil
+	la.Stats.IPAnomalies = nil
+	la.Stats.AnomalyNote = ""
+
+	var notes []string
+	hours := la.hourSeries()
+	if len(hours) < minAnomalyPoints {
+		notes = append(notes, fmt.Sprintf("小时数不足 %d 个", minAnomalyPoints))
+	} else {
+		// 小时请求量过高（突发流量）和过低（可能是服务中断）都视为异常
+		la.Stats.HourAnomalies = findOutliers(hours, la.Config.AnomalySigma, false)
+	}
+
+	if len(la.Stats.IPCounts) < minAnomalyPoints {
+		notes = append(notes, fmt.Sprintf("IP数不足 %d 个", minAnomalyPoints))
+	} else {
+		// IP只关心请求量异常偏高（可能是爬虫或攻击）
+		la.Stats.IPAnomalies = findOutliers(la.Stats.IPCounts, la.Config.AnomalySigma, true)
+	}
+
+	if len(notes) > 0 {
+		la.Stats.AnomalyNote = strings.Join(notes, "，") + "，跳过对应的异常检测"
+	}
+}
+
+// hourSeries 返回时间范围内每个小时的请求数，没有日志的小时补0
+func (la *LogAnalyzer) hourSeries() map[string]int {
+	series := make(map[string]int, len(la.Stats.HourlyCounts))
+	for hour, count := range la.Stats.HourlyCounts {
+		series[hour] = count
+	}
+	if la.Stats.StartTime == nil || la.Stats.EndTime == nil {
+		return series
+	}
+
+	start := la.Stats.StartTime.Truncate(time.Hour)
+	end := *la.Stats.EndTime
+	if end.Sub(start) > maxAnomalyHours*time.Hour {
+		return series
+	}
+	for t := start; !t.After(end); t = t.Add(time.Hour) {
+		key := t.In(la.Stats.StartTime.Location()).Format("2006-01-02 15")
+		if _, ok := series[key]; !ok {
+			series[key] = 0
+		}
+	}
+	return series
+}
+
+// findOutliers 计算均值和总体标准差，返回偏离超过 sigma 倍标准差的项，按偏离程度降序排列
+func findOutliers(counts map[string]int, sigma float64, highOnly bool) []Anomaly {
+	var sum float64
+	for _, count := range counts {
+		sum += float64(count)
+	}
+	mean := sum / float64(len(counts))
+
+	var variance float64
+	for _, count := range counts {
+		diff := float64(count) - mean
+		variance += diff * diff
+	}
+	stdDev := math.Sqrt(variance / float64(len(counts)))
+	if stdDev == 0 {
+		return nil
+	}
+
+	var anomalies []Anomaly
+	for key, count := range counts {
+		z := (float64(count) - mean) / stdDev
+		if z > sigma || (!highOnly && z < -sigma) {
+			anomalies = append(anomalies, Anomaly{Key: key, Count: count, Mean: mean, ZScore: z})
+		}
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		zi, zj := math.Abs(anomalies[i].ZScore), math.Abs(anomalies[j].ZScore)
+		if zi != zj {
+			return zi > zj
+		}
+		return anomalies[i].Key < anomalies[j].Key
+	})
+	return anomalies
 }
 
 // getTopItems 获取前N项统计
@@ -405,7 +1020,10 @@ func (la *LogAnalyzer) getTopItems(counts map[string]int, n int) []string {
 	}
 
 	sort.Slice(items, func(i, j int) bool {
-		return items[i].count > items[j].count
+		if items[i].count != items[j].count {
+			return items[i].count > items[j].count
+		}
+		return items[i].key < items[j].key
 	})
 
 	if n > len(items) {
@@ -439,6 +1057,19 @@ func (la *LogAnalyzer) GenerateReport() string {
 		report.WriteString(fmt.Sprintf("  持续时间: %s\n", la.Stats.TimeRange))
 	}
 
+	// 多文件统计
+	if len(la.Stats.FileCounts) > 1 {
+		report.WriteString("\n📁 文件统计:\n")
+		files := make([]string, 0, len(la.Stats.FileCounts))
+		for file := range la.Stats.FileCounts {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			report.WriteString(fmt.Sprintf("  %s: %d 行\n", file, la.Stats.FileCounts[file]))
+		}
+	}
+
 	// 日志级别统计
 	if len(la.Stats.LevelCounts) > 0 {
 		report.WriteString("\n🎯 日志级别统计:\n")
@@ -457,10 +1088,36 @@ func (la *LogAnalyzer) GenerateReport() string {
 			}
 			count := la.Stats.IPCounts[ip]
 			percentage := float64(count) / float64(la.Stats.ValidLines) * 100
-			report.WriteString(fmt.Sprintf("  %d. %s: %d (%.1f%%)\n", i+1, ip, count, percentage))
+			location := ""
+			if geo := la.Stats.TopIPGeo[ip]; geo != "" {
+				location = fmt.Sprintf(" [%s]", geo)
+			}
+			report.WriteString(fmt.Sprintf("  %d. %s: %d (%.1f%%)%s\n", i+1, ip, count, percentage, location))
+		}
+	}
+
+	// URL统计
+	if len(la.Stats.URLCounts) > 0 {
+		report.WriteString("\n🔗 Top URLs:\n")
+		for i, url := range la.Stats.TopURLs {
+			if i >= 10 { // 限制显示前10个
+				break
+			}
+			count := la.Stats.URLCounts[url]
+			percentage := float64(count) / float64(la.Stats.ValidLines) * 100
+			report.WriteString(fmt.Sprintf("  %d. %s: %d (%.1f%%)\n", i+1, url, count, percentage))
 		}
 	}
 
+	// 响应时间统计
+	if len(la.Stats.ResponseTimes) > 0 {
+		report.WriteString(fmt.Sprintf("\n⏱️  响应时间 (%d 个样本):\n", len(la.Stats.ResponseTimes)))
+		report.WriteString(fmt.Sprintf("  p50: %.1f ms\n", la.Stats.LatencyP50))
+		report.WriteString(fmt.Sprintf("  p90: %.1f ms\n", la.Stats.LatencyP90))
+		report.WriteString(fmt.Sprintf("  p99: %.1f ms\n", la.Stats.LatencyP99))
+		report.WriteString(fmt.Sprintf("  最大: %.1f ms\n", la.Stats.ResponseTimes[len(la.Stats.ResponseTimes)-1]))
+	}
+
 	// HTTP状态码统计
 	if len(la.Stats.StatusCounts) > 0 {
 		report.WriteString("\n📊 HTTP状态码统计:\n")
@@ -504,12 +1161,191 @@ func (la *LogAnalyzer) GenerateReport() string {
 		}
 	}
 
+	// 异常检测
+	if la.Config.AnomalySigma > 0 {
+		report.WriteString(fmt.Sprintf("\n🚨 异常检测 (阈值 %.1fσ):\n", la.Config.AnomalySigma))
+		if la.Stats.AnomalyNote != "" {
+			report.WriteString(fmt.Sprintf("  %s\n", la.Stats.AnomalyNote))
+		}
+		for i, a := range la.Stats.HourAnomalies {
+			if i >= la.Config.TopN {
+				break
+			}
+			kind := "流量突增"
+			if a.ZScore < 0 {
+				kind = "流量骤降"
+			}
+			report.WriteString(fmt.Sprintf("  ⚠️  %s:00 %s: %d 次请求 (均值 %.1f, %+.1fσ)\n", a.Key, kind, a.Count, a.Mean, a.ZScore))
+		}
+		for i, a := range la.Stats.IPAnomalies {
+			if i >= la.Config.TopN {
+				break
+			}
+			report.WriteString(fmt.Sprintf("  ⚠️  IP %s 请求过多: %d 次 (均值 %.1f, %.1f 倍, %+.1fσ)\n",
+				a.Key, a.Count, a.Mean, float64(a.Count)/a.Mean, a.ZScore))
+		}
+		if la.Stats.AnomalyNote == "" && len(la.Stats.HourAnomalies) == 0 && len(la.Stats.IPAnomalies) == 0 {
+			report.WriteString("  未发现异常\n")
+		}
+	}
+
 	report.WriteString("\n========================================\n")
 	report.WriteString("分析完成! 🎉\n")
 
 	return report.String()
 }
 
+// followPollInterval 实时模式下检查新内容的间隔
+const followPollInterval = 500 * time.Millisecond
+
+// FollowLogFile 类似 tail -f 实时跟踪日志文件，从文件末尾开始处理新追加的行，
+// 每隔 refresh 打印一次简要统计，收到中断信号时停止
+func (la *LogAnalyzer) FollowLogFile(filename string, refresh time.Duration) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return fmt.Errorf("无法打开日志文件: %v", err)
+	}
+	defer func() { file.Close() }()
+
+	// 自动检测模式下用已有内容的开头几行判断格式；
+	// 文件为空时保持 auto，等读到第一批新行后再检测
+	la.Format = la.Config.LogFormat
+	if la.Format == "auto" {
+		if sample := readSampleLines(file, detectSampleLines); len(sample) > 0 {
+			la.Format = la.detectFormat(sample)
+		}
+	}
+
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return fmt.Errorf("定位文件末尾失败: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("读取文件信息失败: %v", err)
+	}
+
+	reader := bufio.NewReader(file)
+	var partial string
+	var pending []string // 等待格式检测的行
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	fmt.Printf("👀 正在实时跟踪 %s (按 Ctrl+C 停止)\n", filename)
+
+	for {
+		// 读取当前所有可用的完整行
+		for {
+			chunk, err := reader.ReadString('\n')
+			offset += int64(len(chunk))
+			if err != nil {
+				// 行尚未写完，留到下次拼接
+				partial += chunk
+				break
+			}
+			line := strings.TrimSpace(partial + chunk)
+			partial = ""
+			if line == "" {
+				continue
+			}
+			if la.Format == "auto" {
+				pending = append(pending, line)
+				if len(pending) < detectSampleLines {
+					continue
+				}
+				la.Format = la.detectFormat(pending)
+				for _, buffered := range pending {
+					la.processLine(buffered, filename)
+				}
+				pending = nil
+				continue
+			}
+			la.processLine(line, filename)
+		}
+
+		// 本批新行不足采样数时，用已读到的行检测格式
+		if len(pending) > 0 {
+			la.Format = la.detectFormat(pending)
+			for _, buffered := range pending {
+				la.processLine(buffered, filename)
+			}
+			pending = nil
+		}
+
+		select {
+		case <-sigChan:
+			fmt.Println()
+			la.calculateDerivedStats()
+			return nil
+		case <-ticker.C:
+			la.calculateDerivedStats()
+			la.printLiveSummary()
+		case <-time.After(followPollInterval):
+		}
+
+		// 检测日志轮转：文件被替换（inode变化）或被截断时重新打开
+		current, err := os.Stat(filename)
+		if err != nil {
+			// 轮转过程中文件可能暂时不存在
+			continue
+		}
+		if !os.SameFile(info, current) || current.Size() < offset {
+			newFile, err := os.Open(filename)
+			if err != nil {
+				continue
+			}
+			file.Close()
+			file = newFile
+			info = current
+			offset = 0
+			partial = ""
+			reader = bufio.NewReader(file)
+			fmt.Printf("🔄 检测到日志轮转，重新打开 %s\n", filename)
+		}
+	}
+}
+
+// readSampleLines 从文件当前位置读取若干非空行用于格式检测（会移动文件偏移，调用方需自行重新定位）
+func readSampleLines(file *os.File, n int) []string {
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() && len(lines) < n {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// printLiveSummary 打印实时模式下的简要统计
+func (la *LogAnalyzer) printLiveSummary() {
+	var levels []string
+	for level := range la.Stats.LevelCounts {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+
+	var parts []string
+	for _, level := range levels {
+		parts = append(parts, fmt.Sprintf("%s=%d", level, la.Stats.LevelCounts[level]))
+	}
+
+	summary := fmt.Sprintf("[%s] 总行数: %d | 有效: %d | 错误: %d",
+		time.Now().Format("15:04:05"), la.Stats.TotalLines, la.Stats.ValidLines, la.Stats.ErrorLines)
+	if len(parts) > 0 {
+		summary += " | " + strings.Join(parts, " ")
+	}
+	if len(la.Stats.TopIPs) > 0 {
+		summary += fmt.Sprintf(" | Top IP: %s (%d)", la.Stats.TopIPs[0], la.Stats.IPCounts[la.Stats.TopIPs[0]])
+	}
+	fmt.Println(summary)
+}
+
 // ExportJSON 导出JSON格式报告
 func (la *LogAnalyzer) ExportJSON(filename string) error {
 	data, err := json.MarshalIndent(struct {
@@ -532,23 +1368,526 @@ func (la *LogAnalyzer) ExportJSON(filename string) error {
 	return os.WriteFile(filename, data, 0644)
 }
 
+// htmlRow HTML报告中的一行统计及其条形图宽度
+type htmlRow struct {
+	Label   string
+	Count   int
+	Percent float64 // 占有效行数的百分比
+	Width   float64 // 条形图宽度（相对本节最大值的百分比）
+}
+
+// htmlSection HTML报告中的一个统计表
+type htmlSection struct {
+	Title string
+	Rows  []htmlRow
+}
+
+// htmlReportTemplate 自包含的HTML报告模板，样式全部内联，所有数据由 html/template 转义
+const htmlReportTemplate = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>日志分析报告</title>
+</head>
+<body style="font-family: -apple-system, 'Segoe UI', 'Microsoft YaHei', sans-serif; margin: 24px; color: #222;">
+<h1 style="font-size: 22px;">日志分析报告</h1>
+<p style="color: #666;">生成时间: {{.Generated}} · 日志文件: {{.Files}}</p>
+
+<h2 style="font-size: 18px;">基础统计</h2>
+<table style="border-collapse: collapse;">
+{{range .Summary}}<tr><td style="padding: 4px 16px 4px 0; color: #666;">{{.Label}}</td><td style="padding: 4px 0;">{{.Value}}</td></tr>
+{{end}}</table>
+{{range .Sections}}
+<h2 style="font-size: 18px;">{{.Title}}</h2>
+<table style="border-collapse: collapse; min-width: 600px;">
+<tr style="background: #f0f0f0;"><th style="text-align: left; padding: 4px 8px;">项目</th><th style="text-align: right; padding: 4px 8px;">次数</th><th style="text-align: right; padding: 4px 8px;">占比</th><th style="padding: 4px 8px; width: 240px;"></th></tr>
+{{range .Rows}}<tr style="border-bottom: 1px solid #eee;"><td style="padding: 4px 8px; word-break: break-all;">{{.Label}}</td><td style="text-align: right; padding: 4px 8px;">{{.Count}}</td><td style="text-align: right; padding: 4px 8px;">{{printf "%.1f" .Percent}}%</td><td style="padding: 4px 8px;"><div style="background: #4a90d9; height: 12px; width: {{printf "%.1f" .Width}}%;"></div></td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`
+
+// htmlRows 把计数转换为按次数从多到少排序的表格行，limit<=0 表示不限制
+func (la *LogAnalyzer) htmlRows(counts map[string]int, keys []string, limit int) []htmlRow {
+	if keys == nil {
+		keys = la.getTopItems(counts, len(counts))
+	}
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+
+	maxCount := 0
+	for _, key := range keys {
+		if counts[key] > maxCount {
+			maxCount = counts[key]
+		}
+	}
+
+	rows := make([]htmlRow, 0, len(keys))
+	for _, key := range keys {
+		row := htmlRow{Label: key, Count: counts[key]}
+		if la.Stats.ValidLines > 0 {
+			row.Percent = float64(row.Count) / float64(la.Stats.ValidLines) * 100
+		}
+		if maxCount > 0 {
+			row.Width = float64(row.Count) / float64(maxCount) * 100
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// ExportHTML 生成带表格和条形图的自包含HTML报告
+func (la *LogAnalyzer) ExportHTML(w io.Writer, files []string) error {
+	type summaryItem struct {
+		Label string
+		Value string
+	}
+
+	summary := []summaryItem{
+		{"总行数", strconv.Itoa(la.Stats.TotalLines)},
+		{"有效行数", strconv.Itoa(la.Stats.ValidLines)},
+		{"错误行数", strconv.Itoa(la.Stats.ErrorLines)},
+	}
+	if la.Stats.StartTime != nil && la.Stats.EndTime != nil {
+		summary = append(summary,
+			summaryItem{"时间范围", la.Stats.StartTime.Format("2006-01-02 15:04:05") + " ~ " + la.Stats.EndTime.Format("2006-01-02 15:04:05")},
+			summaryItem{"持续时间", la.Stats.TimeRange})
+	}
+	if len(la.Stats.ResponseTimes) > 0 {
+		summary = append(summary, summaryItem{"响应时间 p50/p90/p99",
+			fmt.Sprintf("%.1f / %.1f / %.1f ms", la.Stats.LatencyP50, la.Stats.LatencyP90, la.Stats.LatencyP99)})
+	}
+
+	var sections []htmlSection
+	addSection := func(title string, rows []htmlRow) {
+		if len(rows) > 0 {
+			sections = append(sections, htmlSection{Title: title, Rows: rows})
+		}
+	}
+	addSection("日志级别", la.htmlRows(la.Stats.LevelCounts, nil, 0))
+	addSection("HTTP状态码", la.htmlRows(la.Stats.StatusCounts, nil, 0))
+	addSection("HTTP方法", la.htmlRows(la.Stats.MethodCounts, nil, 0))
+
+	ipRows := la.htmlRows(la.Stats.IPCounts, la.Stats.TopIPs, 0)
+	for i := range ipRows {
+		if geo := la.Stats.TopIPGeo[ipRows[i].Label]; geo != "" {
+			ipRows[i].Label += " [" + geo + "]"
+		}
+	}
+	addSection("Top IP地址", ipRows)
+	addSection("Top URLs", la.htmlRows(la.Stats.URLCounts, la.Stats.TopURLs, 0))
+
+	// 按小时分布按时间顺序排列
+	hours := make([]string, 0, len(la.Stats.HourlyCounts))
+	for hour := range la.Stats.HourlyCounts {
+		hours = append(hours, hour)
+	}
+	sort.Strings(hours)
+	addSection("按小时分布", la.htmlRows(la.Stats.HourlyCounts, hours, 0))
+	addSection("Top错误信息", la.htmlRows(la.Stats.TopErrors, nil, 5))
+
+	tmpl, err := template.New("report").Parse(htmlReportTemplate)
+	if err != nil {
+		return fmt.Errorf("解析HTML模板失败: %v", err)
+	}
+	data := struct {
+		Generated string
+		Files     string
+		Summary   []summaryItem
+		Sections  []htmlSection
+	}{
+		Generated: time.Now().Format("2006-01-02 15:04:05"),
+		Files:     strings.Join(files, ", "),
+		Summary:   summary,
+		Sections:  sections,
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("生成HTML报告失败: %v", err)
+	}
+	return nil
+}
+
+// ExportCSV 将过滤后保留的日志条目按行导出为CSV，时间使用RFC3339格式
+func (la *LogAnalyzer) ExportCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"timestamp", "level", "ip", "method", "url", "status", "size", "response_time_ms"}); err != nil {
+		return fmt.Errorf("写入CSV失败: %v", err)
+	}
+
+	for _, entry := range la.Entries {
+		var timestamp, status, size, responseTime string
+		if !entry.Timestamp.IsZero() {
+			timestamp = entry.Timestamp.Format(time.RFC3339)
+		}
+		if entry.Status > 0 {
+			status = strconv.Itoa(entry.Status)
+		}
+		if entry.Size > 0 {
+			size = strconv.Itoa(entry.Size)
+		}
+
+		if entry.HasResponseTime {
+			responseTime = strconv.FormatFloat(entry.ResponseTime, 'f', 3, 64)
+		}
+
+		record := []string{timestamp, entry.Level, entry.IP, entry.Method, entry.URL, status, size, responseTime}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("写入CSV失败: %v", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("写入CSV失败: %v", err)
+	}
+	return nil
+}
+
+// GeoIPReader MaxMind DB（.mmdb）格式的精简只读实现，只支持按IP查询记录
+type GeoIPReader struct {
+	data        []byte // 整个数据库文件
+	dataSection []byte // 数据段，指针偏移相对于数据段起始位置
+	nodeCount   uint
+	recordSize  uint // 每条记录的位数：24、28 或 32
+	ipVersion   uint // 4 或 6
+	ipv4Start   uint // IPv6 库中 IPv4 地址（::/96）子树的起始节点
+}
+
+// mmdb 元数据段的起始标记
+var mmdbMetadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// mmdb 指针和嵌套结构的最大解析深度，防止损坏的文件造成无限递归
+const mmdbMaxDepth = 32
+
+var errMMDBCorrupt = errors.New("MaxMind数据库格式错误")
+
+// OpenGeoIP 读取 MaxMind 格式的数据库文件（如 GeoLite2-City.mmdb）
+func OpenGeoIP(filename string) (*GeoIPReader, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("读取GeoIP数据库失败: %v", err)
+	}
+
+	metaStart := bytes.LastIndex(data, mmdbMetadataMarker)
+	if metaStart < 0 {
+		return nil, fmt.Errorf("%s 不是有效的MaxMind数据库: 未找到元数据", filename)
+	}
+	value, _, err := decodeMMDB(data[metaStart+len(mmdbMetadataMarker):], 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("解析GeoIP元数据失败: %v", err)
+	}
+	meta, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("解析GeoIP元数据失败: %v", errMMDBCorrupt)
+	}
+
+	reader := &GeoIPReader{
+		data:       data,
+		nodeCount:  mmdbUint(meta["node_count"]),
+		recordSize: mmdbUint(meta["record_size"]),
+		ipVersion:  mmdbUint(meta["ip_version"]),
+	}
+	if reader.recordSize != 24 && reader.recordSize != 28 && reader.recordSize != 32 {
+		return nil, fmt.Errorf("不支持的GeoIP记录长度: %d", reader.recordSize)
+	}
+	if reader.ipVersion != 4 && reader.ipVersion != 6 {
+		return nil, fmt.Errorf("不支持的GeoIP IP版本: %d", reader.ipVersion)
+	}
+
+	// 搜索树之后是16字节的分隔区，然后是数据段
+	treeSize := reader.nodeCount * reader.recordSize / 4
+	if treeSize+16 > uint(metaStart) {
+		return nil, fmt.Errorf("解析GeoIP数据库失败: %v", errMMDBCorrupt)
+	}
+	reader.dataSection = data[treeSize+16 : metaStart]
+
+	if reader.ipVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < reader.nodeCount; i++ {
+			node = reader.readNode(node, 0)
+		}
+		reader.ipv4Start = node
+	}
+	return reader, nil
+}
+
+// readNode 读取搜索树节点的左（bit=0）或右（bit=1）记录
+func (r *GeoIPReader) readNode(node, bit uint) uint {
+	b := r.data[node*r.recordSize/4:]
+	switch r.recordSize {
+	case 24:
+		if bit == 0 {
+			return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3])<<16 | uint(b[4])<<8 | uint(b[5])
+	case 28:
+		// 中间字节的高4位属于左记录，低4位属于右记录
+		if bit == 0 {
+			return (uint(b[3])&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return (uint(b[3])&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		if bit == 0 {
+			return uint(binary.BigEndian.Uint32(b[0:4]))
+		}
+		return uint(binary.BigEndian.Uint32(b[4:8]))
+	}
+}
+
+// Lookup 查询IP对应的记录，数据库中没有该IP时返回nil
+func (r *GeoIPReader) Lookup(ip net.IP) (map[string]interface{}, error) {
+	var bits []byte
+	node := uint(0)
+	if v4 := ip.To4(); v4 != nil {
+		bits = v4
+		if r.ipVersion == 6 {
+			node = r.ipv4Start
+		}
+	} else {
+		if r.ipVersion == 4 {
+			return nil, nil
+		}
+		bits = ip.To16()
+	}
+
+	for i := 0; i < len(bits)*8 && node < r.nodeCount; i++ {
+		bit := uint(bits[i/8]>>(7-uint(i%8))) & 1
+		node = r.readNode(node, bit)
+	}
+	// 等于节点数表示未收录，大于节点数时为指向数据段的指针
+	if node <= r.nodeCount {
+		return nil, nil
+	}
+
+	value, _, err := decodeMMDB(r.dataSection, node-r.nodeCount-16, 0)
+	if err != nil {
+		return nil, err
+	}
+	record, _ := value.(map[string]interface{})
+	return record, nil
+}
+
+// decodeMMDB 从 buf 的 offset 处解码一个值，返回该值和下一个值的偏移
+func decodeMMDB(buf []byte, offset uint, depth int) (interface{}, uint, error) {
+	if depth > mmdbMaxDepth || offset >= uint(len(buf)) {
+		return nil, 0, errMMDBCorrupt
+	}
+	ctrl := buf[offset]
+	offset++
+	typ := uint(ctrl >> 5)
+
+	// 指针：指向数据段中的另一个值，解码后从指针之后继续
+	if typ == 1 {
+		n := uint(ctrl>>3)&3 + 1
+		if offset+n > uint(len(buf)) {
+			return nil, 0, errMMDBCorrupt
+		}
+		b := buf[offset : offset+n]
+		p := uint(ctrl & 7)
+		switch n {
+		case 1:
+			p = p<<8 | uint(b[0])
+		case 2:
+			p = (p<<16 | uint(b[0])<<8 | uint(b[1])) + 2048
+		case 3:
+			p = (p<<24 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])) + 526336
+		default:
+			p = uint(binary.BigEndian.Uint32(b))
+		}
+		value, _, err := decodeMMDB(buf, p, depth+1)
+		return value, offset + n, err
+	}
+
+	// 扩展类型
+	if typ == 0 {
+		if offset >= uint(len(buf)) {
+			return nil, 0, errMMDBCorrupt
+		}
+		typ = 7 + uint(buf[offset])
+		offset++
+	}
+
+	size := uint(ctrl & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		if offset+n > uint(len(buf)) {
+			return nil, 0, errMMDBCorrupt
+		}
+		extra := uint(0)
+		for _, b := range buf[offset : offset+n] {
+			extra = extra<<8 | uint(b)
+		}
+		offset += n
+		switch size {
+		case 29:
+			size = 29 + extra
+		case 30:
+			size = 285 + extra
+		default:
+			size = 65821 + extra
+		}
+	}
+
+	// 每个元素至少占1字节，容量不超过剩余数据长度，避免损坏的长度值占用大量内存
+	capacity := size
+	if remaining := uint(len(buf)) - offset; capacity > remaining {
+		capacity = remaining
+	}
+
+	switch typ {
+	case 7: // map
+		m := make(map[string]interface{}, capacity)
+		for i := uint(0); i < size; i++ {
+			key, next, err := decodeMMDB(buf, offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, errMMDBCorrupt
+			}
+			value, next, err := decodeMMDB(buf, next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[name] = value
+			offset = next
+		}
+		return m, offset, nil
+	case 11: // array
+		list := make([]interface{}, 0, capacity)
+		for i := uint(0); i < size; i++ {
+			value, next, err := decodeMMDB(buf, offset, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			list = append(list, value)
+			offset = next
+		}
+		return list, offset, nil
+	case 14: // boolean，值保存在size中
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(buf)) {
+		return nil, 0, errMMDBCorrupt
+	}
+	b := buf[offset : offset+size]
+	next := offset + size
+	switch typ {
+	case 2: // UTF-8 字符串
+		return string(b), next, nil
+	case 3: // double
+		if size != 8 {
+			return nil, 0, errMMDBCorrupt
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), next, nil
+	case 4: // bytes
+		return append([]byte(nil), b...), next, nil
+	case 5, 6, 9: // uint16、uint32、uint64
+		var v uint64
+		for _, c := range b {
+			v = v<<8 | uint64(c)
+		}
+		return v, next, nil
+	case 8: // int32
+		var v uint32
+		for _, c := range b {
+			v = v<<8 | uint32(c)
+		}
+		return int64(int32(v)), next, nil
+	case 10: // uint128
+		return new(big.Int).SetBytes(b), next, nil
+	case 15: // float
+		if size != 4 {
+			return nil, 0, errMMDBCorrupt
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), next, nil
+	}
+	return nil, 0, errMMDBCorrupt
+}
+
+// mmdbUint 将元数据中的整数值转换为uint
+func mmdbUint(value interface{}) uint {
+	if v, ok := value.(uint64); ok {
+		return uint(v)
+	}
+	return 0
+}
+
+// mmdbName 读取记录中 country/city 等字段的名称，优先使用中文
+func mmdbName(record map[string]interface{}, field string) string {
+	item, ok := record[field].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	if names, ok := item["names"].(map[string]interface{}); ok {
+		for _, lang := range []string{"zh-CN", "en"} {
+			if name, ok := names[lang].(string); ok && name != "" {
+				return name
+			}
+		}
+	}
+	if code, ok := item["iso_code"].(string); ok {
+		return code
+	}
+	return ""
+}
+
+// geoLocate 查询IP所在的国家和城市，结果会被缓存，查询不到时返回空字符串
+func (la *LogAnalyzer) geoLocate(ip string) string {
+	if location, ok := la.geoCache[ip]; ok {
+		return location
+	}
+
+	location := ""
+	if parsed := net.ParseIP(ip); parsed != nil {
+		if record, err := la.GeoIP.Lookup(parsed); err == nil && record != nil {
+			var parts []string
+			for _, field := range []string{"country", "city"} {
+				if name := mmdbName(record, field); name != "" {
+					parts = append(parts, name)
+				}
+			}
+			location = strings.Join(parts, " ")
+		}
+	}
+	la.geoCache[ip] = location
+	return location
+}
+
 // 主函数
 func main() {
 	// 命令行参数
 	var (
-		logFile       = flag.String("file", "", "日志文件路径")
+		logFile       = flag.String("file", "", "日志文件路径 (多个文件用逗号分隔，支持通配符；- 或不指定且有管道输入时读取标准输入)")
 		logFormat     = flag.String("format", "auto", "日志格式 (apache/nginx/common/syslog/json/auto)")
 		filterLevel   = flag.String("level", "", "过滤日志级别 (ERROR/WARN/INFO/DEBUG)")
 		filterPattern = flag.String("pattern", "", "过滤模式 (正则表达式)")
-		outputFormat  = flag.String("output", "text", "输出格式 (text/json)")
+		outputFormat  = flag.String("output", "text", "输出格式 (text/json/csv/html)")
 		outputFile    = flag.String("out", "", "输出文件路径")
 		topN          = flag.Int("top", 10, "显示前N项统计")
 		showDetails   = flag.Bool("details", false, "显示详细信息")
+		since         = flag.String("since", "", "只分析此时间之后的日志 (RFC3339 或 2006-01-02 15:04:05)")
+		until         = flag.String("until", "", "只分析此时间之前的日志 (RFC3339 或 2006-01-02 15:04:05)")
+		patternRegex  = flag.String("pattern-regex", "", "自定义日志格式的正则表达式")
+		customFields  = flag.String("fields", "", "自定义格式的字段映射，如 ip=1,ts=2,status=5")
+		timeFormat    = flag.String("time-format", "", "自定义格式的时间格式 (Go时间布局，如 2006-01-02 15:04:05)")
+		workers       = flag.Int("workers", 1, "并发解析的worker数量 (大文件时可提速)")
+		follow        = flag.Bool("follow", false, "实时跟踪日志文件新增内容 (类似 tail -f)")
+		refresh       = flag.Duration("refresh", 5*time.Second, "实时模式下刷新统计的间隔")
+		geoipDB       = flag.String("geoip", "", "MaxMind格式的GeoIP数据库 (.mmdb)，为Top IP标注国家和城市")
+		anomalySigma  = flag.Float64("anomaly-sigma", 0, "异常检测阈值：请求数偏离均值超过N个标准差的小时和IP视为异常 (0表示不检测)")
 		showHelp      = flag.Bool("help", false, "显示帮助信息")
 	)
 	flag.Parse()
 
-	if *showHelp || *logFile == "" {
+	// -file 为 - 或未指定且标准输入来自管道/重定向时，从标准输入读取
+	fromStdin := *logFile == "-" || (*logFile == "" && stdinIsPipe())
+
+	if *showHelp || (*logFile == "" && !fromStdin) {
 		fmt.Println("🔍 日志分析器 - 使用帮助")
 		fmt.Println("========================================")
 		fmt.Println("用法: log_analyzer [选项]")
@@ -561,36 +1900,130 @@ func main() {
 		fmt.Println("  syslog  - 系统日志格式")
 		fmt.Println("  json    - JSON格式日志")
 		fmt.Println("  auto    - 自动检测格式")
+		fmt.Println("  custom  - 通过 -pattern-regex 和 -fields 自定义格式")
+		fmt.Println("\n自定义格式可用字段: ip, ts, level, msg, method, url, status, size, ua, rt")
 		fmt.Println("\n示例:")
 		fmt.Println("  log_analyzer -file access.log -format nginx")
 		fmt.Println("  log_analyzer -file app.log -level ERROR -output json")
+		fmt.Println("  log_analyzer -file access.log -output csv -out entries.csv")
+		fmt.Println("  log_analyzer -file access.log -output html -out report.html")
 		fmt.Println("  log_analyzer -file system.log -pattern \"database\" -top 5")
+		fmt.Println("  log_analyzer -file access.log.2.gz -format nginx")
+		fmt.Println("  log_analyzer -file \"access.log*\" -format nginx")
+		fmt.Println("  log_analyzer -file access.log -format nginx -follow -refresh 10s")
+		fmt.Println("  log_analyzer -file app.log -pattern-regex \"^(\\S+) (\\S+) (\\w+) (.*)$\" -fields ts=1,ip=2,level=3,msg=4")
+		fmt.Println("  log_analyzer -file access.log -format nginx -geoip GeoLite2-City.mmdb")
+		fmt.Println("  log_analyzer -file access.log -format nginx -anomaly-sigma 3")
+		fmt.Println("  tail -n 1000 access.log | log_analyzer -format nginx")
+		fmt.Println("  log_analyzer -file app.log -since \"2023-12-25 10:00:00\" -until \"2023-12-25 11:00:00\"")
 		return
 	}
 
+	// 解析时间范围
+	sinceTime, err := parseTimeFlag(*since)
+	if err != nil {
+		fmt.Printf("❌ -since 参数错误: %v\n", err)
+		os.Exit(1)
+	}
+	untilTime, err := parseTimeFlag(*until)
+	if err != nil {
+		fmt.Printf("❌ -until 参数错误: %v\n", err)
+		os.Exit(1)
+	}
+	if !sinceTime.IsZero() && !untilTime.IsZero() && untilTime.Before(sinceTime) {
+		fmt.Println("❌ -until 不能早于 -since")
+		os.Exit(1)
+	}
+
 	// 创建分析器配置
 	config := AnalyzerConfig{
 		LogFormat:     *logFormat,
+		TimeFormat:    *timeFormat,
 		FilterLevel:   *filterLevel,
 		FilterPattern: *filterPattern,
 		OutputFormat:  *outputFormat,
 		TopN:          *topN,
 		ShowDetails:   *showDetails,
+		Workers:       *workers,
+		Since:         sinceTime,
+		Until:         untilTime,
+		AnomalySigma:  *anomalySigma,
+	}
+	if config.AnomalySigma < 0 {
+		fmt.Println("❌ -anomaly-sigma 不能为负数")
+		os.Exit(1)
+	}
+
+	// 展开文件列表，标准输入作为名为 stdin 的单个来源
+	files := []string{stdinSource}
+	if !fromStdin {
+		files, err = expandLogFiles(*logFile)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
 	}
 
 	// 创建分析器
 	analyzer := NewLogAnalyzer(config)
+	if *patternRegex != "" {
+		if err := analyzer.SetCustomPattern(*patternRegex, *customFields); err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		*logFormat = "custom"
+	}
+	if *geoipDB != "" {
+		reader, err := OpenGeoIP(*geoipDB)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			os.Exit(1)
+		}
+		analyzer.GeoIP = reader
+	}
+
+	// 实时跟踪模式
+	if *follow {
+		if fromStdin {
+			fmt.Println("❌ 实时模式不支持标准输入，请通过 -file 指定日志文件")
+			os.Exit(1)
+		}
+		if len(files) != 1 {
+			fmt.Println("❌ 实时模式只支持单个日志文件")
+			os.Exit(1)
+		}
+		if *refresh <= 0 {
+			fmt.Println("❌ -refresh 必须大于0")
+			os.Exit(1)
+		}
+		if err := analyzer.FollowLogFile(files[0], *refresh); err != nil {
+			fmt.Printf("❌ 实时跟踪失败: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Print(analyzer.GenerateReport())
+		return
+	}
 
-	fmt.Printf("🔍 开始分析日志文件: %s\n", *logFile)
+	fmt.Printf("🔍 开始分析日志文件: %s\n", strings.Join(files, ", "))
 	fmt.Printf("📋 使用格式: %s\n", *logFormat)
 
-	// 解析日志文件
-	if err := analyzer.ParseLogFile(*logFile); err != nil {
-		fmt.Printf("❌ 解析失败: %v\n", err)
-		os.Exit(1)
+	// 逐个解析日志文件，统计结果合并到同一个分析器
+	for _, file := range files {
+		if fromStdin {
+			err = analyzer.ParseLogReader(os.Stdin, stdinSource)
+		} else {
+			err = analyzer.ParseLogFile(file)
+		}
+		if err != nil {
+			fmt.Printf("❌ 解析失败: %v\n", err)
+			os.Exit(1)
+		}
+		if *logFormat == "auto" {
+			fmt.Printf("🔎 %s 自动检测格式: %s\n", file, analyzer.Format)
+		}
 	}
 
-	fmt.Printf("✅ 解析完成! 处理了 %d 行日志\n\n", analyzer.Stats.TotalLines)
+	fmt.Printf("✅ 解析完成! 共 %d 个文件, 处理了 %d 行日志\n\n", len(files), analyzer.Stats.TotalLines)
 
 	// 生成报告
 	var output string
@@ -606,6 +2039,20 @@ func main() {
 			data, _ := json.MarshalIndent(analyzer.Stats, "", "  ")
 			output = string(data)
 		}
+	} else if *outputFormat == "csv" {
+		var buf strings.Builder
+		if err := analyzer.ExportCSV(&buf); err != nil {
+			fmt.Printf("❌ 导出CSV失败: %v\n", err)
+			os.Exit(1)
+		}
+		output = buf.String()
+	} else if *outputFormat == "html" {
+		var buf strings.Builder
+		if err := analyzer.ExportHTML(&buf, files); err != nil {
+			fmt.Printf("❌ 导出HTML失败: %v\n", err)
+			os.Exit(1)
+		}
+		output = buf.String()
 	} else {
 		output = analyzer.GenerateReport()
 	}
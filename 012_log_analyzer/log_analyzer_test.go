@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sampleNginxLog 生成超过自动检测采样行数的nginx日志，末尾带一行无法解析的内容
+func sampleNginxLog() string {
+	var b strings.Builder
+	for i := 0; i < detectSampleLines+10; i++ {
+		fmt.Fprintf(&b, "192.168.1.%d - - [10/Oct/2023:13:%02d:00 +0800] \"GET /page/%d HTTP/1.1\" %d 512 \"-\" \"Mozilla/5.0\"\n",
+			i%5, i, i, 200+(i%3)*100)
+	}
+	b.WriteString("not a log line\n")
+	return b.String()
+}
+
+// gzipBytes 压缩测试数据
+func gzipBytes(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatalf("gzip压缩失败: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("gzip压缩失败: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// parseFixture 用自动检测格式的新分析器解析文件
+func parseFixture(t *testing.T, path string) *LogAnalyzer {
+	la := NewLogAnalyzer(AnalyzerConfig{LogFormat: "auto", TopN: 10})
+	if err := la.ParseLogFile(path); err != nil {
+		t.Fatalf("解析 %s 失败: %v", filepath.Base(path), err)
+	}
+	return la
+}
+
+func TestParseGzipLogMatchesPlain(t *testing.T) {
+	dir := t.TempDir()
+	content := sampleNginxLog()
+	compressed := gzipBytes(t, content)
+
+	plainPath := filepath.Join(dir, "access.log")
+	gzPath := filepath.Join(dir, "access.log.gz")
+	// 没有 .gz 后缀时通过文件头识别
+	rotatedPath := filepath.Join(dir, "access.log.1")
+	for path, data := range map[string][]byte{plainPath: []byte(content), gzPath: compressed, rotatedPath: compressed} {
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("写入测试文件失败: %v", err)
+		}
+	}
+
+	plain := parseFixture(t, plainPath)
+	if len(plain.Entries) != detectSampleLines+10 || plain.Stats.ErrorLines != 1 {
+		t.Fatalf("纯文本日志解析出 %d 条、%d 行错误, 期望 %d 条、1 行错误",
+			len(plain.Entries), plain.Stats.ErrorLines, detectSampleLines+10)
+	}
+
+	for _, path := range []string{gzPath, rotatedPath} {
+		la := parseFixture(t, path)
+		name := filepath.Base(path)
+		if len(la.Entries) != len(plain.Entries) {
+			t.Errorf("%s 解析出 %d 条, 纯文本为 %d 条", name, len(la.Entries), len(plain.Entries))
+		}
+		if la.Stats.TotalLines != plain.Stats.TotalLines || la.Stats.ErrorLines != plain.Stats.ErrorLines {
+			t.Errorf("%s 总行数/错误行数 = %d/%d, 纯文本为 %d/%d", name,
+				la.Stats.TotalLines, la.Stats.ErrorLines, plain.Stats.TotalLines, plain.Stats.ErrorLines)
+		}
+		if la.Format != plain.Format {
+			t.Errorf("%s 检测格式 = %s, 纯文本为 %s", name, la.Format, plain.Format)
+		}
+	}
+}
+
+func TestParseGzipLogTruncated(t *testing.T) {
+	compressed := gzipBytes(t, sampleNginxLog())
+	path := filepath.Join(t.TempDir(), "access.log.gz")
+	if err := os.WriteFile(path, compressed[:len(compressed)/2], 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+
+	la := NewLogAnalyzer(AnalyzerConfig{LogFormat: "auto", TopN: 10})
+	if err := la.ParseLogFile(path); err == nil {
+		t.Error("截断的gzip文件应返回错误")
+	}
+}
+
+func TestParseGenericLogTimestamp(t *testing.T) {
+	la := NewLogAnalyzer(AnalyzerConfig{LogFormat: "generic", TopN: 10})
+	la.Format = "generic"
+
+	entry, err := la.parseGenericLog("something happened without a time", "test")
+	if err != nil {
+		t.Fatalf("parseGenericLog 返回错误: %v", err)
+	}
+	if !entry.Timestamp.IsZero() {
+		t.Errorf("没有时间的行 Timestamp = %v, 期望零值", entry.Timestamp)
+	}
+
+	entry, err = la.parseGenericLog("2023-10-10T13:55:36 [WARN] disk almost full", "test")
+	if err != nil {
+		t.Fatalf("parseGenericLog 返回错误: %v", err)
+	}
+	if want := time.Date(2023, 10, 10, 13, 55, 36, 0, time.UTC); !entry.Timestamp.Equal(want) {
+		t.Errorf("Timestamp = %v, 期望 %v", entry.Timestamp, want)
+	}
+	if entry.Level != "WARN" {
+		t.Errorf("Level = %s, 期望 WARN", entry.Level)
+	}
+
+	// 没有时间的条目不影响时间范围和小时分布
+	content := "no time here\n2023-10-10 13:00:00 [INFO] started\nstill no time\n"
+	if err := la.ParseLogReader(strings.NewReader(content), "test"); err != nil {
+		t.Fatalf("ParseLogReader 返回错误: %v", err)
+	}
+	if la.Stats.ValidLines != 3 {
+		t.Errorf("有效行数 = %d, 期望 3", la.Stats.ValidLines)
+	}
+	if la.Stats.StartTime == nil || !la.Stats.StartTime.Equal(*la.Stats.EndTime) {
+		t.Errorf("时间范围 = %v ~ %v, 期望只包含 2023-10-10 13:00:00", la.Stats.StartTime, la.Stats.EndTime)
+	}
+	if len(la.Stats.HourlyCounts) != 1 || la.Stats.HourlyCounts["2023-10-10 13"] != 1 {
+		t.Errorf("小时分布 = %v, 期望只有 2023-10-10 13: 1", la.Stats.HourlyCounts)
+	}
+}
+
+// mmdb 测试数据的编码函数，只覆盖解析器用到的类型
+func mmdbString(v string) []byte {
+	return append([]byte{2<<5 | byte(len(v))}, v...)
+}
+
+func mmdbUint32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return append([]byte{6<<5 | 4}, b...)
+}
+
+// mmdbMap 按给定顺序编码 map，pairs 依次为键和已编码的值
+func mmdbMap(pairs ...interface{}) []byte {
+	out := []byte{7<<5 | byte(len(pairs)/2)}
+	for i := 0; i < len(pairs); i += 2 {
+		out = append(out, mmdbString(pairs[i].(string))...)
+		out = append(out, pairs[i+1].([]byte)...)
+	}
+	return out
+}
+
+// mmdbPointer 编码指向数据段偏移 p（小于2048）的指针
+func mmdbPointer(p int) []byte {
+	return []byte{1<<5 | byte(p>>8&7), byte(p)}
+}
+
+// mmdbNetwork 测试数据库中的一个网段及其记录在数据段中的偏移
+type mmdbNetwork struct {
+	cidr   string
+	offset int
+}
+
+// buildMMDB 构造内存中的 MaxMind 数据库：搜索树 + 16字节分隔区 + 数据段 + 元数据
+func buildMMDB(t *testing.T, ipVersion, recordSize int, networks []mmdbNetwork, data []byte) []byte {
+	t.Helper()
+
+	// 记录的取值: <0 为空，否则为节点序号；data 标记为数据段偏移
+	type record struct {
+		node int
+		data int
+	}
+	empty := record{node: -1, data: -1}
+	nodes := [][2]record{{empty, empty}}
+
+	for _, network := range networks {
+		_, ipNet, err := net.ParseCIDR(network.cidr)
+		if err != nil {
+			t.Fatalf("解析网段 %s 失败: %v", network.cidr, err)
+		}
+		ones, _ := ipNet.Mask.Size()
+		ip := ipNet.IP.To16()
+		if ipVersion == 4 {
+			ip = ipNet.IP.To4()
+		} else if v4 := ipNet.IP.To4(); v4 != nil {
+			// IPv6 库中的 IPv4 地址位于 ::/96 子树
+			ip = append(make([]byte, 12), v4...)
+			ones += 96
+		}
+
+		node := 0
+		for i := 0; i < ones; i++ {
+			bit := ip[i/8] >> (7 - uint(i%8)) & 1
+			if i == ones-1 {
+				nodes[node][bit] = record{node: -1, data: network.offset}
+				break
+			}
+			if nodes[node][bit].node < 0 {
+				nodes = append(nodes, [2]record{empty, empty})
+				nodes[node][bit] = record{node: len(nodes) - 1, data: -1}
+			}
+			node = nodes[node][bit].node
+		}
+	}
+
+	nodeCount := len(nodes)
+	value := func(r record) uint32 {
+		switch {
+		case r.data >= 0:
+			return uint32(nodeCount + 16 + r.data)
+		case r.node >= 0:
+			return uint32(r.node)
+		default:
+			return uint32(nodeCount)
+		}
+	}
+
+	var tree []byte
+	for _, n := range nodes {
+		left, right := value(n[0]), value(n[1])
+		switch recordSize {
+		case 24:
+			tree = append(tree, byte(left>>16), byte(left>>8), byte(left),
+				byte(right>>16), byte(right>>8), byte(right))
+		case 28:
+			tree = append(tree, byte(left>>16), byte(left>>8), byte(left),
+				byte(left>>24&0x0F)<<4|byte(right>>24&0x0F),
+				byte(right>>16), byte(right>>8), byte(right))
+		default:
+			tree = binary.BigEndian.AppendUint32(tree, left)
+			tree = binary.BigEndian.AppendUint32(tree, right)
+		}
+	}
+
+	var file []byte
+	file = append(file, tree...)
+	file = append(file, make([]byte, 16)...)
+	file = append(file, data...)
+	file = append(file, mmdbMetadataMarker...)
+	file = append(file, mmdbMap(
+		"node_count", mmdbUint32(uint32(nodeCount)),
+		"record_size", mmdbUint32(uint32(recordSize)),
+		"ip_version", mmdbUint32(uint32(ipVersion)),
+	)...)
+	return file
+}
+
+// sampleGeoData 返回测试数据段和两条记录的偏移：
+// 北京的记录直接编码，柏林的国家信息通过指针引用数据段开头的共享结构
+func sampleGeoData() (data []byte, beijing, berlin int) {
+	germany := mmdbMap("iso_code", mmdbString("DE"), "names", mmdbMap("en", mmdbString("Germany")))
+	data = append(data, germany...)
+
+	beijing = len(data)
+	data = append(data, mmdbMap(
+		"country", mmdbMap("iso_code", mmdbString("CN"), "names", mmdbMap("en", mmdbString("China"), "zh-CN", mmdbString("中国"))),
+		"city", mmdbMap("names", mmdbMap("zh-CN", mmdbString("北京"))),
+	)...)
+
+	berlin = len(data)
+	data = append(data, mmdbMap(
+		"country", mmdbPointer(0),
+		"city", mmdbMap("names", mmdbMap("en", mmdbString("Berlin"))),
+	)...)
+	return data, beijing, berlin
+}
+
+// writeMMDB 将数据库写入临时文件并返回路径
+func writeMMDB(t *testing.T, content []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("写入测试数据库失败: %v", err)
+	}
+	return path
+}
+
+func TestGeoIPLookup(t *testing.T) {
+	data, beijing, berlin := sampleGeoData()
+	networks := []mmdbNetwork{{"1.2.3.0/24", beijing}, {"2001:db8::/32", berlin}}
+
+	for _, recordSize := range []int{24, 28, 32} {
+		reader, err := OpenGeoIP(writeMMDB(t, buildMMDB(t, 6, recordSize, networks, data)))
+		if err != nil {
+			t.Fatalf("记录长度 %d: 打开数据库失败: %v", recordSize, err)
+		}
+		la := NewLogAnalyzer(AnalyzerConfig{LogFormat: "auto", TopN: 10})
+		la.GeoIP = reader
+
+		tests := []struct {
+			ip   string
+			want string
+		}{
+			{"1.2.3.4", "中国 北京"},
+			{"1.2.3.255", "中国 北京"},
+			{"2001:db8::1", "Germany Berlin"},
+			{"2001:db8:ffff::1", "Germany Berlin"},
+			{"1.2.4.1", ""},
+			{"8.8.8.8", ""},
+			{"2001:db9::1", ""},
+		}
+		for _, tt := range tests {
+			if got := la.geoLocate(tt.ip); got != tt.want {
+				t.Errorf("记录长度 %d: geoLocate(%s) = %q, 期望 %q", recordSize, tt.ip, got, tt.want)
+			}
+		}
+
+		record, err := reader.Lookup(net.ParseIP("10.0.0.1"))
+		if err != nil || record != nil {
+			t.Errorf("记录长度 %d: 未收录的地址返回 %v, %v, 期望 nil, nil", recordSize, record, err)
+		}
+	}
+}
+
+func TestGeoIPLookupIPv4Database(t *testing.T) {
+	data, beijing, _ := sampleGeoData()
+	reader, err := OpenGeoIP(writeMMDB(t, buildMMDB(t, 4, 24, []mmdbNetwork{{"1.2.3.0/24", beijing}}, data)))
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+
+	record, err := reader.Lookup(net.ParseIP("1.2.3.4"))
+	if err != nil {
+		t.Fatalf("查询失败: %v", err)
+	}
+	if got := mmdbName(record, "country"); got != "中国" {
+		t.Errorf("国家 = %q, 期望 中国", got)
+	}
+	// IPv4 库中查询 IPv6 地址
+	if record, err := reader.Lookup(net.ParseIP("2001:db8::1")); err != nil || record != nil {
+		t.Errorf("IPv4 库查询IPv6地址返回 %v, %v, 期望 nil, nil", record, err)
+	}
+}
+
+func TestGeoIPCorruptDatabase(t *testing.T) {
+	data, beijing, berlin := sampleGeoData()
+	networks := []mmdbNetwork{{"1.2.3.0/24", beijing}, {"2001:db8::/32", berlin}}
+	valid := buildMMDB(t, 6, 28, networks, data)
+
+	// 任意位置截断都应返回错误或正常结果，不能panic
+	for n := 0; n < len(valid); n++ {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("截断为 %d 字节时panic: %v", n, r)
+				}
+			}()
+			reader, err := OpenGeoIP(writeMMDB(t, valid[:n]))
+			if err != nil {
+				return
+			}
+			for _, ip := range []string{"1.2.3.4", "2001:db8::1", "8.8.8.8"} {
+				reader.Lookup(net.ParseIP(ip))
+			}
+		}()
+	}
+	if _, err := OpenGeoIP(writeMMDB(t, valid[:len(valid)/2])); err == nil {
+		t.Error("截断一半的数据库应返回错误")
+	}
+
+	// 数据段被截断：元数据完整，但记录指向数据段之外
+	reader, err := OpenGeoIP(writeMMDB(t, buildMMDB(t, 6, 28, networks, data[:berlin+2])))
+	if err != nil {
+		t.Fatalf("打开数据段截断的数据库失败: %v", err)
+	}
+	if _, err := reader.Lookup(net.ParseIP("2001:db8::1")); err == nil {
+		t.Error("记录数据被截断时查询应返回错误")
+	}
+
+	// 元数据中的节点数超出文件长度
+	oversized := append([]byte{}, valid[:bytes.LastIndex(valid, mmdbMetadataMarker)]...)
+	oversized = append(oversized, mmdbMetadataMarker...)
+	oversized = append(oversized, mmdbMap(
+		"node_count", mmdbUint32(1<<20),
+		"record_size", mmdbUint32(28),
+		"ip_version", mmdbUint32(6),
+	)...)
+	if _, err := OpenGeoIP(writeMMDB(t, oversized)); err == nil {
+		t.Error("节点数超出文件长度时应返回错误")
+	}
+
+	// 不支持的记录长度（树按32位写入，元数据声明为16位）
+	unsupported := buildMMDB(t, 6, 32, networks, data)
+	unsupported = append(unsupported[:bytes.LastIndex(unsupported, mmdbMetadataMarker)+len(mmdbMetadataMarker)], mmdbMap(
+		"node_count", mmdbUint32(1),
+		"record_size", mmdbUint32(16),
+		"ip_version", mmdbUint32(6),
+	)...)
+	if _, err := OpenGeoIP(writeMMDB(t, unsupported)); err == nil {
+		t.Error("不支持的记录长度应返回错误")
+	}
+}
+
+// 28位记录的最高4位保存在节点中间字节，测试数据库的节点数太小覆盖不到
+func TestGeoIPReadNodeRecordSizes(t *testing.T) {
+	tests := []struct {
+		recordSize  uint
+		node        []byte
+		left, right uint
+	}{
+		{24, []byte{0xAB, 0xCD, 0xEF, 0x12, 0x34, 0x56}, 0xABCDEF, 0x123456},
+		{28, []byte{0xBC, 0xDE, 0xF1, 0xA2, 0x34, 0x56, 0x78}, 0xABCDEF1, 0x2345678},
+		{32, []byte{0xFA, 0xBC, 0xDE, 0xF1, 0x12, 0x34, 0x56, 0x78}, 0xFABCDEF1, 0x12345678},
+	}
+	for _, tt := range tests {
+		// 第二个节点验证按节点序号计算偏移
+		reader := &GeoIPReader{data: append(make([]byte, len(tt.node)), tt.node...), recordSize: tt.recordSize}
+		if got := reader.readNode(1, 0); got != tt.left {
+			t.Errorf("记录长度 %d: 左记录 = %#x, 期望 %#x", tt.recordSize, got, tt.left)
+		}
+		if got := reader.readNode(1, 1); got != tt.right {
+			t.Errorf("记录长度 %d: 右记录 = %#x, 期望 %#x", tt.recordSize, got, tt.right)
+		}
+	}
+}
@@ -4,12 +4,17 @@ import (
 	"bufio"
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -26,39 +31,184 @@ import (
 
 // SyncConfig 同步配置
 type SyncConfig struct {
-	SourceDir      string        // 源目录
-	TargetDir      string        // 目标目录
-	SyncMode       string        // 同步模式: unidirectional(单向), bidirectional(双向)
-	CheckInterval  time.Duration // 检查间隔
-	MaxFileSize    int64         // 最大文件大小
-	IncludePattern string        // 包含模式
-	ExcludePattern string        // 排除模式
-	DryRun         bool          // 干运行模式
-	Verbose        bool          // 详细输出
+	SourceDir       string        // 源目录
+	TargetDir       string        // 目标目录
+	SyncMode        string        // 同步模式: unidirectional(单向), bidirectional(双向)
+	CheckInterval   time.Duration // 检查间隔
+	MaxFileSize     int64         // 最大文件大小
+	IncludePatterns []string      // 包含模式，匹配任意一个即包含
+	ExcludePatterns []string      // 排除模式，匹配任意一个即排除（优先于包含模式）
+	RegexFilter     bool          // 包含/排除模式按正则表达式匹配相对路径
+	DryRun          bool          // 干运行模式
+	Verbose         bool          // 详细输出
+	Workers         int           // 并发复制的worker数量
+	ConflictPolicy  string        // 冲突处理策略，为空时交互式询问
+	OutputFormat    string        // 同步摘要输出格式: text/json
+	IgnoreRules     []IgnoreRule  // 忽略文件中的规则
+	FollowSymlinks  bool          // 是否跟随符号链接复制其指向的内容
+	CacheFile       string        // 校验和缓存文件路径，为空时不使用缓存
+	TrashDir        string        // 回收站目录，为空时直接删除
+	BandwidthLimit  int64         // 所有复制共享的带宽上限(字节/秒)，0表示不限制
+	PruneEmpty      bool          // 同步后删除目标目录中源目录没有的空目录
+}
+
+// IgnoreRule 忽略文件中的一条规则（gitignore语义）
+type IgnoreRule struct {
+	Pattern string // 匹配相对路径的模式，使用 / 分隔
+	Negate  bool   // 以 ! 开头，重新包含之前被忽略的路径
+	DirOnly bool   // 以 / 结尾，只匹配目录
+}
+
+// SyncSummary 一次同步的结果摘要
+type SyncSummary struct {
+	StartTime         time.Time     `json:"start_time"`
+	Copied            int           `json:"copied"`
+	Deleted           int           `json:"deleted"`
+	Failed            int           `json:"failed"`
+	ConflictsResolved int           `json:"conflicts_resolved"`
+	ConflictsSkipped  int           `json:"conflicts_skipped"`
+	BytesCopied       int64         `json:"bytes_copied"`
+	SymlinksCopied    int           `json:"symlinks_recreated"`
+	SymlinksSkipped   int           `json:"symlinks_skipped"`
+	DirsPruned        int           `json:"dirs_pruned"`
+	Elapsed           time.Duration `json:"-"`
+	ElapsedSeconds    float64       `json:"elapsed_seconds"`
+	DryRun            bool          `json:"dry_run"`
 }
 
 // FileInfo 文件信息结构体
 type FileInfo struct {
-	Path     string    `json:"path"`
-	Size     int64     `json:"size"`
-	ModTime  time.Time `json:"mod_time"`
-	Checksum string    `json:"checksum,omitempty"`
-	IsDir    bool      `json:"is_dir"`
+	Path     string      `json:"path"`
+	Size     int64       `json:"size"`
+	ModTime  time.Time   `json:"mod_time"`
+	Checksum string      `json:"checksum,omitempty"`
+	IsDir    bool        `json:"is_dir"`
+	Mode     os.FileMode `json:"mode"`
+	Link     string      `json:"link,omitempty"` // 符号链接指向的路径
 }
 
 // FileSyncTool 文件同步工具
 type FileSyncTool struct {
-	Config    SyncConfig
-	FileCache map[string]FileInfo
-	mutex     sync.RWMutex
+	Config       SyncConfig
+	FileCache    map[string]FileInfo // 校验和缓存，键为文件的绝对路径
+	skippedLinks []string            // 最近一次扫描源目录时跳过的符号链接
+	trashStamp   string              // 本轮同步使用的回收站子目录名
+	limiter      *rateLimiter        // 带宽限制，为nil时不限速
+	includeRegex []*regexp.Regexp    // 正则模式下编译好的包含模式
+	excludeRegex []*regexp.Regexp    // 正则模式下编译好的排除模式
+	out          io.Writer           // 过程信息的输出位置，JSON摘要模式下为标准错误
+	mutex        sync.RWMutex
 }
 
 // NewFileSyncTool 创建新的文件同步工具
 func NewFileSyncTool(config SyncConfig) *FileSyncTool {
-	return &FileSyncTool{
+	fst := &FileSyncTool{
 		Config:    config,
 		FileCache: make(map[string]FileInfo),
+		out:       progressOutput(config.OutputFormat),
+	}
+	if config.BandwidthLimit > 0 {
+		fst.limiter = newRateLimiter(config.BandwidthLimit)
+	}
+	return fst
+}
+
+// progressOutput JSON摘要模式下过程信息写到标准错误，保证标准输出只包含可解析的JSON
+func progressOutput(format string) io.Writer {
+	if format == "json" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// rateLimiter 令牌桶限速器，被所有worker共享以限制总带宽
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // 每秒产生的令牌(字节)数
+	tokens float64 // 当前令牌数，为负表示已被预支
+	last   time.Time
+}
+
+// limiterChunkSize 每次读取的最大字节数，避免单次读取占用过多令牌
+const limiterChunkSize = 32 * 1024
+
+// newRateLimiter 创建指定速率(字节/秒)的限速器
+func newRateLimiter(bytesPerSecond int64) *rateLimiter {
+	return &rateLimiter{
+		rate: float64(bytesPerSecond),
+		last: time.Now(),
+	}
+}
+
+// wait 取走n个令牌，令牌不足时等待到足够为止
+// 先预支再等待，多个goroutine按请求顺序依次获得带宽
+func (l *rateLimiter) wait(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	// 最多积攒1秒的令牌，避免空闲后突发超速
+	if l.tokens > l.rate {
+		l.tokens = l.rate
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	deficit := -l.tokens
+	l.mu.Unlock()
+
+	if deficit > 0 {
+		time.Sleep(time.Duration(deficit / l.rate * float64(time.Second)))
+	}
+}
+
+// limitedReader 读取前按限速器申请带宽
+type limitedReader struct {
+	reader  io.Reader
+	limiter *rateLimiter
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	if len(p) > limiterChunkSize {
+		p = p[:limiterChunkSize]
+	}
+	n, err := lr.reader.Read(p)
+	if n > 0 {
+		lr.limiter.wait(n)
+	}
+	return n, err
+}
+
+// copyData 复制数据，设置了带宽限制时经过限速器
+func (fst *FileSyncTool) copyData(dst io.Writer, src io.Reader) (int64, error) {
+	if fst.limiter != nil {
+		src = &limitedReader{reader: src, limiter: fst.limiter}
+	}
+	return io.Copy(dst, src)
+}
+
+// parseByteSize 解析带单位的大小，如 512K、5MB、1.5G，单位按1024计算，可带 /s 后缀
+func parseByteSize(value string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	s = strings.TrimSuffix(s, "/S")
+	s = strings.TrimSuffix(s, "B")
+
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(s, "K"):
+		multiplier = 1024
+	case strings.HasSuffix(s, "M"):
+		multiplier = 1024 * 1024
+	case strings.HasSuffix(s, "G"):
+		multiplier = 1024 * 1024 * 1024
 	}
+	if multiplier > 1 {
+		s = s[:len(s)-1]
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("无效的大小: %s", value)
+	}
+	return int64(number * multiplier), nil
 }
 
 // CalculateChecksum 计算文件校验和
@@ -77,69 +227,213 @@ func (fst *FileSyncTool) CalculateChecksum(filePath string) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-// ScanDirectory 扫描目录
-func (fst *FileSyncTool) ScanDirectory(dirPath string) (map[string]FileInfo, error) {
-	files := make(map[string]FileInfo)
+// walkTree 遍历目录树，对每个通过过滤的条目调用fn
+// 不跟随符号链接时，链接本身作为条目返回；跟随时使用目标的信息，
+// 指向祖先目录的链接（循环）和断开的链接会被跳过并返回
+func (fst *FileSyncTool) walkTree(root string, fn func(relPath, fullPath string, info os.FileInfo)) ([]string, error) {
+	var skipped []string
+	ancestors := make(map[string]bool)
 
-	err := filepath.Walk(dirPath, func(path string, info os.FileInfo, err error) error {
+	var walk func(dir, relDir string) error
+	walk = func(dir, relDir string) error {
+		realDir, err := filepath.EvalSymlinks(dir)
 		if err != nil {
 			return err
 		}
+		ancestors[realDir] = true
+		defer delete(ancestors, realDir)
 
-		// 跳过目录本身
-		if path == dirPath {
-			return nil
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return err
 		}
 
-		// 应用文件过滤
-		relPath, _ := filepath.Rel(dirPath, path)
-		if !fst.shouldIncludeFile(relPath, info) {
-			return nil
-		}
+		for _, entry := range entries {
+			fullPath := filepath.Join(dir, entry.Name())
+			relPath := filepath.Join(relDir, entry.Name())
+
+			info, err := os.Lstat(fullPath)
+			if err != nil {
+				return err
+			}
 
-		fileInfo := FileInfo{
-			Path:    relPath,
-			Size:    info.Size(),
-			ModTime: info.ModTime(),
-			IsDir:   info.IsDir(),
+			if info.Mode()&os.ModeSymlink != 0 && fst.Config.FollowSymlinks {
+				targetInfo, err := os.Stat(fullPath)
+				if err != nil {
+					skipped = append(skipped, relPath+" (链接断开)")
+					continue
+				}
+				if targetInfo.IsDir() {
+					realTarget, err := filepath.EvalSymlinks(fullPath)
+					if err != nil || ancestors[realTarget] {
+						skipped = append(skipped, relPath+" (循环链接)")
+						continue
+					}
+				}
+				info = targetInfo
+			}
+
+			// 应用文件过滤，被忽略的目录不再进入
+			if !fst.shouldIncludeFile(relPath, info) {
+				continue
+			}
+
+			fn(relPath, fullPath, info)
+
+			if info.IsDir() {
+				if err := walk(fullPath, relPath); err != nil {
+					return err
+				}
+			}
 		}
+		return nil
+	}
 
-		// 计算非目录文件的校验和
-		if !info.IsDir() && info.Size() <= fst.Config.MaxFileSize {
-			checksum, err := fst.CalculateChecksum(path)
-			if err == nil {
+	return skipped, walk(root, "")
+}
+
+// newFileInfo 根据文件元数据创建FileInfo，符号链接记录其指向
+func newFileInfo(relPath, fullPath string, info os.FileInfo) FileInfo {
+	fileInfo := FileInfo{
+		Path:    relPath,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		IsDir:   info.IsDir(),
+		Mode:    info.Mode(),
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		fileInfo.Link, _ = os.Readlink(fullPath)
+	}
+	return fileInfo
+}
+
+// ScanDirectory 扫描目录
+func (fst *FileSyncTool) ScanDirectory(dirPath string) (map[string]FileInfo, error) {
+	files := make(map[string]FileInfo)
+	absDir, _ := filepath.Abs(dirPath)
+
+	skipped, err := fst.walkTree(dirPath, func(relPath, fullPath string, info os.FileInfo) {
+		fileInfo := newFileInfo(relPath, fullPath, info)
+
+		// 计算普通文件的校验和，大小和修改时间未变时复用缓存
+		if info.Mode().IsRegular() && info.Size() <= fst.Config.MaxFileSize {
+			fst.mutex.RLock()
+			cached, ok := fst.FileCache[filepath.Join(absDir, relPath)]
+			fst.mutex.RUnlock()
+
+			if ok && cached.Size == info.Size() && cached.ModTime.Equal(info.ModTime()) {
+				fileInfo.Checksum = cached.Checksum
+			} else if checksum, err := fst.CalculateChecksum(fullPath); err == nil {
 				fileInfo.Checksum = checksum
 			}
 		}
 
 		files[relPath] = fileInfo
-		return nil
 	})
 
+	if dirPath == fst.Config.SourceDir {
+		fst.mutex.Lock()
+		fst.skippedLinks = skipped
+		fst.mutex.Unlock()
+	}
+
 	return files, err
 }
 
+// DefaultCacheFile 返回默认的缓存文件路径：目标目录旁边的隐藏文件
+// 不放在目标目录内，以免被当作需要删除的多余文件
+func DefaultCacheFile(targetDir string) string {
+	absTarget, err := filepath.Abs(targetDir)
+	if err != nil {
+		absTarget = filepath.Clean(targetDir)
+	}
+	return filepath.Join(filepath.Dir(absTarget), "."+filepath.Base(absTarget)+".synccache.json")
+}
+
+// LoadCache 从磁盘加载校验和缓存，文件不存在时使用空缓存
+func (fst *FileSyncTool) LoadCache() error {
+	if fst.Config.CacheFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(fst.Config.CacheFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("读取缓存文件失败: %v", err)
+	}
+
+	cache := make(map[string]FileInfo)
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return fmt.Errorf("解析缓存文件失败: %v", err)
+	}
+
+	fst.mutex.Lock()
+	fst.FileCache = cache
+	fst.mutex.Unlock()
+	return nil
+}
+
+// SaveCache 将校验和缓存写入磁盘
+func (fst *FileSyncTool) SaveCache() error {
+	if fst.Config.CacheFile == "" {
+		return nil
+	}
+
+	fst.mutex.RLock()
+	data, err := json.Marshal(fst.FileCache)
+	fst.mutex.RUnlock()
+	if err != nil {
+		return fmt.Errorf("序列化缓存失败: %v", err)
+	}
+
+	// 先写临时文件再重命名，避免中断时留下损坏的缓存
+	tmpFile := fst.Config.CacheFile + ".tmp"
+	if err := os.WriteFile(tmpFile, data, 0644); err != nil {
+		return fmt.Errorf("写入缓存文件失败: %v", err)
+	}
+	if err := os.Rename(tmpFile, fst.Config.CacheFile); err != nil {
+		return fmt.Errorf("保存缓存文件失败: %v", err)
+	}
+	return nil
+}
+
+// rebuildCache 用本次扫描结果替换缓存，已删除的文件随之移出缓存
+// 调用者需持有锁
+func (fst *FileSyncTool) rebuildCache(scans map[string]map[string]FileInfo) {
+	cache := make(map[string]FileInfo)
+	for dir, files := range scans {
+		absDir, _ := filepath.Abs(dir)
+		for relPath, info := range files {
+			if info.Checksum != "" {
+				cache[filepath.Join(absDir, relPath)] = info
+			}
+		}
+	}
+	fst.FileCache = cache
+}
+
 // shouldIncludeFile 检查文件是否应该包含
 func (fst *FileSyncTool) shouldIncludeFile(relPath string, info os.FileInfo) bool {
+	// 忽略文件规则同时作用于文件和目录
+	if isIgnored(fst.Config.IgnoreRules, relPath, info.IsDir()) {
+		return false
+	}
+
 	// 排除目录
 	if info.IsDir() {
 		return true
 	}
 
-	// 应用包含模式
-	if fst.Config.IncludePattern != "" {
-		matched, _ := filepath.Match(fst.Config.IncludePattern, filepath.Base(relPath))
-		if !matched {
-			return false
-		}
+	// 应用排除模式，排除优先于包含
+	if fst.matchAny(fst.Config.ExcludePatterns, fst.excludeRegex, relPath) {
+		return false
 	}
 
-	// 应用排除模式
-	if fst.Config.ExcludePattern != "" {
-		matched, _ := filepath.Match(fst.Config.ExcludePattern, filepath.Base(relPath))
-		if matched {
-			return false
-		}
+	// 应用包含模式，指定了包含模式时至少匹配一个
+	if len(fst.Config.IncludePatterns) > 0 && !fst.matchAny(fst.Config.IncludePatterns, fst.includeRegex, relPath) {
+		return false
 	}
 
 	// 文件大小限制
@@ -150,6 +444,163 @@ func (fst *FileSyncTool) shouldIncludeFile(relPath string, info os.FileInfo) boo
 	return true
 }
 
+// matchAny 判断相对路径是否匹配任意一个过滤模式
+// 正则模式下匹配完整相对路径；通配符模式下含 / 的模式匹配完整相对路径，否则只匹配文件名
+func (fst *FileSyncTool) matchAny(patterns []string, regexps []*regexp.Regexp, relPath string) bool {
+	relPath = filepath.ToSlash(relPath)
+	if fst.Config.RegexFilter {
+		for _, re := range regexps {
+			if re.MatchString(relPath) {
+				return true
+			}
+		}
+		return false
+	}
+
+	base := path.Base(relPath)
+	for _, pattern := range patterns {
+		name := base
+		if strings.Contains(pattern, "/") {
+			name = relPath
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// CompileFilters 检查包含/排除模式的语法，正则模式下预先编译
+func (fst *FileSyncTool) CompileFilters() error {
+	fst.includeRegex = nil
+	fst.excludeRegex = nil
+	lists := []struct {
+		name     string
+		patterns []string
+		compiled *[]*regexp.Regexp
+	}{
+		{"包含", fst.Config.IncludePatterns, &fst.includeRegex},
+		{"排除", fst.Config.ExcludePatterns, &fst.excludeRegex},
+	}
+
+	for _, list := range lists {
+		for _, pattern := range list.patterns {
+			if fst.Config.RegexFilter {
+				re, err := regexp.Compile(pattern)
+				if err != nil {
+					return fmt.Errorf("无效的%s正则表达式 %q: %v", list.name, pattern, err)
+				}
+				*list.compiled = append(*list.compiled, re)
+			} else if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("无效的%s模式 %q: %v", list.name, pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// splitPatterns 拆分逗号分隔的模式列表，忽略空项
+func splitPatterns(spec string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(spec, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// LoadIgnoreFile 读取gitignore风格的忽略文件
+// 支持 # 注释、! 取反、结尾 / 表示目录、** 匹配任意层级目录
+func LoadIgnoreFile(filename string) ([]IgnoreRule, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("打开忽略文件失败: %v", err)
+	}
+	defer file.Close()
+
+	var rules []IgnoreRule
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule IgnoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.Negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, "\\") {
+			// \# 和 \! 表示字面字符
+			line = line[1:]
+		}
+
+		if strings.HasSuffix(line, "/") {
+			rule.DirOnly = true
+			line = strings.TrimRight(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		// 包含 / 的模式相对于根目录匹配，否则匹配任意层级
+		if strings.Contains(line, "/") {
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+		rule.Pattern = line
+		rules = append(rules, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取忽略文件失败: %v", err)
+	}
+	return rules, nil
+}
+
+// isIgnored 按顺序应用所有规则，最后一条匹配的规则生效
+func isIgnored(rules []IgnoreRule, relPath string, isDir bool) bool {
+	relPath = filepath.ToSlash(relPath)
+	ignored := false
+	for _, rule := range rules {
+		if rule.DirOnly && !isDir {
+			continue
+		}
+		if matchPathPattern(strings.Split(rule.Pattern, "/"), strings.Split(relPath, "/")) {
+			ignored = !rule.Negate
+		}
+	}
+	return ignored
+}
+
+// matchPathPattern 逐段匹配路径，** 可以匹配零个或多个目录
+func matchPathPattern(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(parts); i++ {
+				if matchPathPattern(rest, parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(parts) == 0 {
+			return false
+		}
+		matched, err := path.Match(pattern[0], parts[0])
+		if err != nil || !matched {
+			return false
+		}
+		pattern = pattern[1:]
+		parts = parts[1:]
+	}
+	return len(parts) == 0
+}
+
 // CompareDirectories 比较两个目录
 func (fst *FileSyncTool) CompareDirectories() (toCopy, toDelete, conflicts []string) {
 	sourceFiles, err := fst.ScanDirectory(fst.Config.SourceDir)
@@ -167,6 +618,11 @@ func (fst *FileSyncTool) CompareDirectories() (toCopy, toDelete, conflicts []str
 	fst.mutex.Lock()
 	defer fst.mutex.Unlock()
 
+	fst.rebuildCache(map[string]map[string]FileInfo{
+		fst.Config.SourceDir: sourceFiles,
+		fst.Config.TargetDir: targetFiles,
+	})
+
 	// 找出需要复制的文件
 	for relPath, sourceFile := range sourceFiles {
 		targetFile, exists := targetFiles[relPath]
@@ -175,8 +631,14 @@ func (fst *FileSyncTool) CompareDirectories() (toCopy, toDelete, conflicts []str
 			continue
 		}
 
+		// 类型或权限不同（如链接变为文件、目录权限修改）时重新同步
+		if sourceFile.Mode != targetFile.Mode || sourceFile.Link != targetFile.Link {
+			toCopy = append(toCopy, relPath)
+			continue
+		}
+
 		// 文件存在，比较差异
-		if !sourceFile.IsDir && !targetFile.IsDir {
+		if !sourceFile.IsDir && !targetFile.IsDir && sourceFile.Link == "" {
 			if sourceFile.Size != targetFile.Size ||
 				sourceFile.ModTime.After(targetFile.ModTime.Add(time.Second)) ||
 				sourceFile.Checksum != targetFile.Checksum {
@@ -214,19 +676,36 @@ func (fst *FileSyncTool) CopyFile(relPath string) error {
 	sourcePath := filepath.Join(fst.Config.SourceDir, relPath)
 	targetPath := filepath.Join(fst.Config.TargetDir, relPath)
 
-	// 确保目标目录存在
-	targetDir := filepath.Dir(targetPath)
-	if err := os.MkdirAll(targetDir, 0755); err != nil {
-		return fmt.Errorf("创建目录失败: %v", err)
+	// 不跟随时重建符号链接本身
+	if info, err := os.Lstat(sourcePath); err == nil && info.Mode()&os.ModeSymlink != 0 && !fst.Config.FollowSymlinks {
+		return fst.copySymlink(sourcePath, targetPath)
+	}
+
+	// 目录只需创建，并发复制时可能与其中的文件同时处理
+	// 先保证所有者可写，最终权限在全部复制完成后由applyDirModes设置
+	if info, err := os.Stat(sourcePath); err == nil && info.IsDir() {
+		if fst.Config.DryRun {
+			return nil
+		}
+		if err := os.MkdirAll(targetPath, info.Mode().Perm()|0700); err != nil {
+			return fmt.Errorf("创建目录失败: %v", err)
+		}
+		return os.Chmod(targetPath, info.Mode().Perm()|0700)
 	}
 
 	if fst.Config.DryRun {
 		if fst.Config.Verbose {
-			fmt.Printf("[DRY RUN] 复制: %s -> %s\n", sourcePath, targetPath)
+			fmt.Fprintf(fst.out, "[DRY RUN] 复制: %s -> %s\n", sourcePath, targetPath)
 		}
 		return nil
 	}
 
+	// 确保目标目录存在
+	targetDir := filepath.Dir(targetPath)
+	if err := os.MkdirAll(targetDir, 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+
 	// 复制文件
 	sourceFile, err := os.Open(sourcePath)
 	if err != nil {
@@ -234,13 +713,20 @@ func (fst *FileSyncTool) CopyFile(relPath string) error {
 	}
 	defer sourceFile.Close()
 
+	// 目标位置原来是符号链接时先删除，避免写入链接指向的文件
+	if info, err := os.Lstat(targetPath); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		if err := os.Remove(targetPath); err != nil {
+			return err
+		}
+	}
+
 	targetFile, err := os.Create(targetPath)
 	if err != nil {
 		return err
 	}
 	defer targetFile.Close()
 
-	if _, err := io.Copy(targetFile, sourceFile); err != nil {
+	if _, err := fst.copyData(targetFile, sourceFile); err != nil {
 		return err
 	}
 
@@ -252,19 +738,81 @@ func (fst *FileSyncTool) CopyFile(relPath string) error {
 	}
 
 	if fst.Config.Verbose {
-		fmt.Printf("复制: %s -> %s\n", sourcePath, targetPath)
+		fmt.Fprintf(fst.out, "复制: %s -> %s\n", sourcePath, targetPath)
 	}
 
 	return nil
 }
 
-// DeleteFile 删除文件
+// copySymlink 在目标目录中重建符号链接
+func (fst *FileSyncTool) copySymlink(sourcePath, targetPath string) error {
+	link, err := os.Readlink(sourcePath)
+	if err != nil {
+		return fmt.Errorf("读取符号链接失败: %v", err)
+	}
+
+	if fst.Config.DryRun {
+		if fst.Config.Verbose {
+			fmt.Fprintf(fst.out, "[DRY RUN] 重建链接: %s -> %s\n", targetPath, link)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+		return fmt.Errorf("创建目录失败: %v", err)
+	}
+
+	// 替换已存在的文件或链接，但不覆盖真实目录
+	if info, err := os.Lstat(targetPath); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("目标已存在同名目录: %s", targetPath)
+		}
+		if err := os.Remove(targetPath); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Symlink(link, targetPath); err != nil {
+		return fmt.Errorf("创建符号链接失败: %v", err)
+	}
+
+	if fst.Config.Verbose {
+		fmt.Fprintf(fst.out, "重建链接: %s -> %s\n", targetPath, link)
+	}
+	return nil
+}
+
+// applyDirModes 按源目录设置目标目录的最终权限
+// 从最深的目录开始，避免只读目录妨碍其子目录的修改
+func (fst *FileSyncTool) applyDirModes(dirs []string) {
+	if fst.Config.DryRun {
+		return
+	}
+
+	sort.Sort(sort.Reverse(sort.StringSlice(dirs)))
+	for _, relPath := range dirs {
+		info, err := os.Stat(filepath.Join(fst.Config.SourceDir, relPath))
+		if err != nil {
+			continue
+		}
+		targetPath := filepath.Join(fst.Config.TargetDir, relPath)
+		if err := os.Chmod(targetPath, info.Mode().Perm()); err != nil {
+			log.Printf("设置目录权限失败 %s: %v", relPath, err)
+		}
+	}
+}
+
+// DeleteFile 删除文件，设置了回收站时移动到回收站
 func (fst *FileSyncTool) DeleteFile(relPath string) error {
 	targetPath := filepath.Join(fst.Config.TargetDir, relPath)
 
+	if fst.Config.TrashDir != "" {
+		return fst.moveToTrash(relPath)
+	}
+
 	if fst.Config.DryRun {
 		if fst.Config.Verbose {
-			fmt.Printf("[DRY RUN] 删除: %s\n", targetPath)
+			fmt.Fprintf(fst.out, "[DRY RUN] 删除: %s\n", targetPath)
 		}
 		return nil
 	}
@@ -274,12 +822,166 @@ func (fst *FileSyncTool) DeleteFile(relPath string) error {
 	}
 
 	if fst.Config.Verbose {
-		fmt.Printf("删除: %s\n", targetPath)
+		fmt.Fprintf(fst.out, "删除: %s\n", targetPath)
+	}
+
+	return nil
+}
+
+// pruneInto 清理空目录并把结果计入摘要，deleted 为本轮要删除的相对路径。
+// 实际执行时以文件系统的当前状态为准，只有干运行才需要把这些路径视为已删除
+func (fst *FileSyncTool) pruneInto(summary *SyncSummary, deleted []string) {
+	var removed map[string]bool
+	if fst.Config.DryRun {
+		removed = make(map[string]bool, len(deleted))
+		for _, relPath := range deleted {
+			removed[relPath] = true
+		}
+	}
+	pruned, failed := fst.PruneEmptyDirs(removed)
+	summary.DirsPruned += pruned
+	summary.Failed += failed
+}
+
+// PruneEmptyDirs 自底向上删除目标目录中的空目录，源目录中存在同名目录的空目录保留。
+// 子目录被删除后父目录变空时一并删除；被过滤规则排除的路径不处理。
+// removed 中的路径视为已删除，使干运行模式也能得到与实际执行一致的结果
+func (fst *FileSyncTool) PruneEmptyDirs(removed map[string]bool) (pruned, failed int) {
+	// prune 返回目录在清理后是否为空（干运行时为"将被删除"）
+	var prune func(relPath string) bool
+	prune = func(relPath string) bool {
+		targetPath := filepath.Join(fst.Config.TargetDir, relPath)
+		entries, err := os.ReadDir(targetPath)
+		if err != nil {
+			return false
+		}
+
+		empty := true
+		for _, entry := range entries {
+			childRel := filepath.Join(relPath, entry.Name())
+			if removed[childRel] {
+				continue
+			}
+			if !entry.IsDir() {
+				empty = false
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil || !fst.shouldIncludeFile(childRel, info) {
+				empty = false
+				continue
+			}
+			if !prune(childRel) {
+				empty = false
+			}
+		}
+
+		// 目标根目录本身不删除
+		if relPath == "" || !empty {
+			return false
+		}
+		if info, err := os.Stat(filepath.Join(fst.Config.SourceDir, relPath)); err == nil && info.IsDir() {
+			return false
+		}
+
+		if fst.Config.DryRun {
+			if fst.Config.Verbose {
+				fmt.Fprintf(fst.out, "[DRY RUN] 删除空目录: %s\n", targetPath)
+			}
+			pruned++
+			return true
+		}
+		if err := os.Remove(targetPath); err != nil {
+			log.Printf("删除空目录失败 %s: %v", relPath, err)
+			failed++
+			return false
+		}
+		if fst.Config.Verbose {
+			fmt.Fprintf(fst.out, "删除空目录: %s\n", targetPath)
+		}
+		pruned++
+		return true
+	}
+
+	prune("")
+	return pruned, failed
+}
+
+// moveToTrash 将目标文件移动到 回收站/时间戳/相对路径
+func (fst *FileSyncTool) moveToTrash(relPath string) error {
+	targetPath := filepath.Join(fst.Config.TargetDir, relPath)
+
+	fst.mutex.Lock()
+	if fst.trashStamp == "" {
+		fst.trashStamp = time.Now().Format("20060102_150405")
 	}
+	trashPath := filepath.Join(fst.Config.TrashDir, fst.trashStamp, relPath)
+	fst.mutex.Unlock()
 
+	if fst.Config.DryRun {
+		if fst.Config.Verbose {
+			fmt.Fprintf(fst.out, "[DRY RUN] 移到回收站: %s -> %s\n", targetPath, trashPath)
+		}
+		return nil
+	}
+
+	info, err := os.Lstat(targetPath)
+	if err != nil {
+		return err
+	}
+
+	// 子文件已先行移走的空目录直接删除，回收站中会随文件重建目录结构
+	if info.IsDir() {
+		if err := os.Remove(targetPath); err == nil {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(trashPath), 0755); err != nil {
+		return fmt.Errorf("创建回收站目录失败: %v", err)
+	}
+
+	if err := os.Rename(targetPath, trashPath); err != nil {
+		// 跨文件系统时无法重命名，普通文件改为复制后删除
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("移动到回收站失败: %v", err)
+		}
+		if err := copyRegularFile(targetPath, trashPath, info); err != nil {
+			return fmt.Errorf("移动到回收站失败: %v", err)
+		}
+		if err := os.Remove(targetPath); err != nil {
+			return err
+		}
+	}
+
+	if fst.Config.Verbose {
+		fmt.Fprintf(fst.out, "移到回收站: %s -> %s\n", targetPath, trashPath)
+	}
 	return nil
 }
 
+// copyRegularFile 复制普通文件并保留权限和修改时间
+func copyRegularFile(src, dst string, info os.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, time.Now(), info.ModTime())
+}
+
 // ResolveConflict 解决文件冲突
 func (fst *FileSyncTool) ResolveConflict(relPath string, choice string) error {
 	sourcePath := filepath.Join(fst.Config.SourceDir, relPath)
@@ -292,7 +994,7 @@ func (fst *FileSyncTool) ResolveConflict(relPath string, choice string) error {
 		// 将目标文件复制回源目录
 		if fst.Config.DryRun {
 			if fst.Config.Verbose {
-				fmt.Printf("[DRY RUN] 保留目标文件: %s\n", targetPath)
+				fmt.Fprintf(fst.out, "[DRY RUN] 保留目标文件: %s\n", targetPath)
 			}
 			return nil
 		}
@@ -309,12 +1011,32 @@ func (fst *FileSyncTool) ResolveConflict(relPath string, choice string) error {
 		}
 		defer targetFile.Close()
 
-		if _, err := io.Copy(targetFile, sourceFile); err != nil {
+		if _, err := fst.copyData(targetFile, sourceFile); err != nil {
 			return err
 		}
 
 		if fst.Config.Verbose {
-			fmt.Printf("保留目标文件: %s\n", targetPath)
+			fmt.Fprintf(fst.out, "保留目标文件: %s\n", targetPath)
+		}
+
+	case "newer":
+		// 保留修改时间较晚的一方
+		sourceInfo, err := os.Stat(sourcePath)
+		if err != nil {
+			return err
+		}
+		targetInfo, err := os.Stat(targetPath)
+		if err != nil {
+			return err
+		}
+		if targetInfo.ModTime().After(sourceInfo.ModTime()) {
+			return fst.ResolveConflict(relPath, "target")
+		}
+		return fst.ResolveConflict(relPath, "source")
+
+	case "skip":
+		if fst.Config.Verbose {
+			fmt.Fprintf(fst.out, "跳过冲突文件: %s\n", relPath)
 		}
 
 	case "both":
@@ -324,7 +1046,7 @@ func (fst *FileSyncTool) ResolveConflict(relPath string, choice string) error {
 
 		if fst.Config.DryRun {
 			if fst.Config.Verbose {
-				fmt.Printf("[DRY RUN] 重命名冲突文件: %s -> %s\n", targetPath, newPath)
+				fmt.Fprintf(fst.out, "[DRY RUN] 重命名冲突文件: %s -> %s\n", targetPath, newPath)
 			}
 			return nil
 		}
@@ -339,64 +1061,285 @@ func (fst *FileSyncTool) ResolveConflict(relPath string, choice string) error {
 		}
 
 		if fst.Config.Verbose {
-			fmt.Printf("处理冲突: %s -> %s (保留两个版本)\n", targetPath, newPath)
+			fmt.Fprintf(fst.out, "处理冲突: %s -> %s (保留两个版本)\n", targetPath, newPath)
 		}
+
+	default:
+		return fmt.Errorf("未知的冲突处理方式: %s", choice)
 	}
 
 	return nil
 }
 
+// conflictPolicies 支持的冲突处理策略
+var conflictPolicies = []string{"source", "target", "both", "newer", "skip"}
+
+// isValidConflictPolicy 检查冲突处理策略是否有效
+func isValidConflictPolicy(policy string) bool {
+	for _, p := range conflictPolicies {
+		if p == policy {
+			return true
+		}
+	}
+	return false
+}
+
+// stdinIsTerminal 检查标准输入是否为终端
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// copyError 复制失败的文件及原因
+type copyError struct {
+	Path string
+	Err  error
+}
+
+// copyResult 一批复制操作的结果
+type copyResult struct {
+	Copied int         // 复制的普通文件数量
+	Bytes  int64       // 复制的字节数
+	Links  int         // 重建的符号链接数量
+	Failed []copyError // 失败列表
+}
+
+// copyFiles 使用worker池并发复制文件
+// 复制进度输出到标准错误，避免干扰标准输出中的摘要
+func (fst *FileSyncTool) copyFiles(files []string) copyResult {
+	workers := fst.Config.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(files) {
+		workers = len(files)
+	}
+
+	jobs := make(chan string)
+	errs := make(chan copyError, len(files))
+	var result copyResult
+	var dirs []string
+	done := 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for file := range jobs {
+				if err := fst.CopyFile(file); err != nil {
+					errs <- copyError{Path: file, Err: err}
+					continue
+				}
+
+				info, err := os.Lstat(filepath.Join(fst.Config.SourceDir, file))
+				if err == nil && info.Mode()&os.ModeSymlink != 0 && fst.Config.FollowSymlinks {
+					info, err = os.Stat(filepath.Join(fst.Config.SourceDir, file))
+				}
+
+				fst.mutex.Lock()
+				done++
+				switch {
+				case err != nil:
+				case info.IsDir():
+					dirs = append(dirs, file)
+				case info.Mode()&os.ModeSymlink != 0:
+					result.Links++
+				default:
+					result.Copied++
+					result.Bytes += info.Size()
+				}
+				if !fst.Config.Verbose {
+					fmt.Fprintf(os.Stderr, "\r📦 已复制 %d/%d, %.1f MB", done, len(files), float64(result.Bytes)/1024/1024)
+				}
+				fst.mutex.Unlock()
+			}
+		}()
+	}
+
+	for _, file := range files {
+		jobs <- file
+	}
+	close(jobs)
+	wg.Wait()
+	close(errs)
+
+	if done > 0 && !fst.Config.Verbose {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	fst.applyDirModes(dirs)
+
+	for e := range errs {
+		result.Failed = append(result.Failed, e)
+	}
+	return result
+}
+
 // RunSync 执行同步
-func (fst *FileSyncTool) RunSync() error {
-	fmt.Printf("🔍 开始同步: %s -> %s\n", fst.Config.SourceDir, fst.Config.TargetDir)
-	fmt.Printf("📋 模式: %s\n", fst.Config.SyncMode)
+func (fst *FileSyncTool) RunSync() (SyncSummary, error) {
+	summary := SyncSummary{StartTime: time.Now(), DryRun: fst.Config.DryRun}
+
+	fmt.Fprintf(fst.out, "🔍 开始同步: %s -> %s\n", fst.Config.SourceDir, fst.Config.TargetDir)
+	fmt.Fprintf(fst.out, "📋 模式: %s\n", fst.Config.SyncMode)
 
 	toCopy, toDelete, conflicts := fst.CompareDirectories()
+	toCopy = withoutConflicts(toCopy, conflicts)
 
-	fmt.Printf("📊 检测到变化:\n")
-	fmt.Printf("  需要复制: %d 个文件\n", len(toCopy))
-	fmt.Printf("  需要删除: %d 个文件\n", len(toDelete))
-	fmt.Printf("  冲突文件: %d 个\n", len(conflicts))
+	// 干运行模式不写入任何文件，包括缓存
+	if !fst.Config.DryRun {
+		if err := fst.SaveCache(); err != nil {
+			log.Printf("警告: %v", err)
+		}
+	}
 
-	// 处理删除
+	fmt.Fprintf(fst.out, "📊 检测到变化:\n")
+	fmt.Fprintf(fst.out, "  需要复制: %d 个文件\n", len(toCopy))
+	fmt.Fprintf(fst.out, "  需要删除: %d 个文件\n", len(toDelete))
+	fmt.Fprintf(fst.out, "  冲突文件: %d 个\n", len(conflicts))
+
+	// 处理删除，先删除子路径再删除父目录
+	fst.trashStamp = summary.StartTime.Format("20060102_150405")
+	sort.Sort(sort.Reverse(sort.StringSlice(toDelete)))
 	for _, file := range toDelete {
 		if err := fst.DeleteFile(file); err != nil {
 			log.Printf("删除失败 %s: %v", file, err)
+			summary.Failed++
+			continue
 		}
+		summary.Deleted++
 	}
 
 	// 处理复制
-	for _, file := range toCopy {
-		if err := fst.CopyFile(file); err != nil {
-			log.Printf("复制失败 %s: %v", file, err)
-		}
+	if fst.Config.Workers > 1 && len(toCopy) > 1 {
+		fmt.Fprintf(fst.out, "⚙️  使用 %d 个worker并发复制\n", fst.Config.Workers)
+	}
+	result := fst.copyFiles(toCopy)
+	summary.addCopyResult(result)
+
+	fst.mutex.RLock()
+	summary.SymlinksSkipped = len(fst.skippedLinks)
+	for _, link := range fst.skippedLinks {
+		fmt.Fprintf(fst.out, "⚠️  跳过符号链接: %s\n", link)
 	}
+	fst.mutex.RUnlock()
 
 	// 处理冲突
 	if len(conflicts) > 0 {
-		fmt.Printf("\n⚠️  检测到文件冲突:\n")
-		reader := bufio.NewReader(os.Stdin)
+		fmt.Fprintf(fst.out, "\n⚠️  检测到文件冲突:\n")
 
-		for _, file := range conflicts {
-			fmt.Printf("冲突文件: %s\n", file)
-			fmt.Printf("请选择处理方式 (source/target/both): ")
+		policy := fst.Config.ConflictPolicy
+		if policy == "" && !stdinIsTerminal() {
+			// 非交互环境（如cron）无法询问，保守地跳过
+			fmt.Fprintf(fst.out, "标准输入不是终端，跳过 %d 个冲突文件 (可使用 -conflict 指定策略)\n", len(conflicts))
+			policy = "skip"
+		}
 
-			choice, _ := reader.ReadString('\n')
-			choice = strings.TrimSpace(choice)
+		reader := bufio.NewReader(os.Stdin)
+		for _, file := range conflicts {
+			choice := policy
+			if choice == "" {
+				fmt.Fprintf(fst.out, "冲突文件: %s\n", file)
+				fmt.Fprintf(fst.out, "请选择处理方式 (%s): ", strings.Join(conflictPolicies, "/"))
+				choice, _ = reader.ReadString('\n')
+				choice = strings.TrimSpace(choice)
+			} else {
+				fmt.Fprintf(fst.out, "冲突文件: %s (策略: %s)\n", file, choice)
+			}
 
 			if err := fst.ResolveConflict(file, choice); err != nil {
 				log.Printf("处理冲突失败 %s: %v", file, err)
+				summary.Failed++
+				continue
+			}
+			if choice == "skip" {
+				summary.ConflictsSkipped++
+			} else {
+				summary.ConflictsResolved++
 			}
 		}
 	}
 
-	fmt.Printf("✅ 同步完成!\n")
-	return nil
+	if fst.Config.PruneEmpty {
+		fst.pruneInto(&summary, toDelete)
+	}
+
+	summary.Elapsed = time.Since(summary.StartTime)
+	summary.ElapsedSeconds = summary.Elapsed.Seconds()
+	fmt.Fprintf(fst.out, "✅ 同步完成!\n")
+	return summary, nil
+}
+
+// withoutConflicts 从待复制列表中去掉冲突文件，冲突文件只按 -conflict 策略处理，
+// 否则源文件会在处理冲突之前就覆盖目标文件
+func withoutConflicts(toCopy, conflicts []string) []string {
+	if len(conflicts) == 0 {
+		return toCopy
+	}
+	conflictSet := make(map[string]bool, len(conflicts))
+	for _, file := range conflicts {
+		conflictSet[file] = true
+	}
+	var filtered []string
+	for _, file := range toCopy {
+		if !conflictSet[file] {
+			filtered = append(filtered, file)
+		}
+	}
+	return filtered
+}
+
+// addCopyResult 将复制结果计入摘要并记录失败
+func (summary *SyncSummary) addCopyResult(result copyResult) {
+	summary.Copied += result.Copied
+	summary.BytesCopied += result.Bytes
+	summary.SymlinksCopied += result.Links
+	summary.Failed += len(result.Failed)
+	for _, e := range result.Failed {
+		log.Printf("复制失败 %s: %v", e.Path, e.Err)
+	}
+}
+
+// PrintSummary 按配置的格式输出同步摘要
+func (fst *FileSyncTool) PrintSummary(summary SyncSummary) {
+	if fst.Config.OutputFormat == "json" {
+		// 单行JSON，便于追加到日志文件
+		data, err := json.Marshal(summary)
+		if err != nil {
+			log.Printf("生成JSON摘要失败: %v", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	fmt.Fprintf(fst.out, "📋 同步摘要:\n")
+	if summary.DryRun {
+		fmt.Fprintf(fst.out, "  (干运行模式，未实际修改文件)\n")
+	}
+	fmt.Fprintf(fst.out, "  复制: %d 个文件 (%.2f MB)\n", summary.Copied, float64(summary.BytesCopied)/1024/1024)
+	if fst.Config.TrashDir != "" {
+		fmt.Fprintf(fst.out, "  删除: %d 个文件 (已移到回收站 %s)\n", summary.Deleted, filepath.Join(fst.Config.TrashDir, fst.trashStamp))
+	} else {
+		fmt.Fprintf(fst.out, "  删除: %d 个文件\n", summary.Deleted)
+	}
+	if fst.Config.PruneEmpty {
+		fmt.Fprintf(fst.out, "  清理空目录: %d 个\n", summary.DirsPruned)
+	}
+	if summary.SymlinksCopied > 0 || summary.SymlinksSkipped > 0 {
+		fmt.Fprintf(fst.out, "  符号链接: 重建 %d 个, 跳过 %d 个\n", summary.SymlinksCopied, summary.SymlinksSkipped)
+	}
+	fmt.Fprintf(fst.out, "  冲突: 已解决 %d 个, 跳过 %d 个\n", summary.ConflictsResolved, summary.ConflictsSkipped)
+	fmt.Fprintf(fst.out, "  失败: %d 个\n", summary.Failed)
+	fmt.Fprintf(fst.out, "  耗时: %v\n", summary.Elapsed.Round(time.Millisecond))
 }
 
 // ContinuousSync 持续同步模式
 func (fst *FileSyncTool) ContinuousSync() {
-	fmt.Printf("🔄 启动持续同步模式，检查间隔: %v\n", fst.Config.CheckInterval)
+	fmt.Fprintf(fst.out, "🔄 启动持续同步模式，检查间隔: %v\n", fst.Config.CheckInterval)
 
 	ticker := time.NewTicker(fst.Config.CheckInterval)
 	defer ticker.Stop()
@@ -404,12 +1347,170 @@ func (fst *FileSyncTool) ContinuousSync() {
 	for {
 		select {
 		case <-ticker.C:
-			fmt.Printf("\n⏰ %s - 执行定期同步检查\n", time.Now().Format("2006-01-02 15:04:05"))
-			fst.RunSync()
+			fmt.Fprintf(fst.out, "\n⏰ %s - 执行定期同步检查\n", time.Now().Format("2006-01-02 15:04:05"))
+			summary, err := fst.RunSync()
+			if err != nil {
+				log.Printf("同步失败: %v", err)
+				continue
+			}
+			fst.PrintSummary(summary)
 		}
 	}
 }
 
+// watchPollInterval 无法使用文件系统通知时，监视模式下扫描源目录的间隔
+const watchPollInterval = time.Second
+
+// watchNotifyInterval 使用文件系统通知时检查是否需要重新扫描和同步的间隔，
+// 只有收到通知后才会扫描，空闲时不读取目录
+const watchNotifyInterval = 200 * time.Millisecond
+
+// statTree 只读取文件元数据（不计算校验和），用于快速检测变化
+func (fst *FileSyncTool) statTree(dirPath string) (map[string]FileInfo, error) {
+	files := make(map[string]FileInfo)
+
+	_, err := fst.walkTree(dirPath, func(relPath, fullPath string, info os.FileInfo) {
+		files[relPath] = newFileInfo(relPath, fullPath, info)
+	})
+
+	return files, err
+}
+
+// diffSnapshots 比较两次扫描结果，返回新增/修改和被删除的路径
+func diffSnapshots(oldFiles, newFiles map[string]FileInfo) (changed, removed []string) {
+	for relPath, info := range newFiles {
+		old, exists := oldFiles[relPath]
+		if !exists || old.Mode != info.Mode || old.Link != info.Link ||
+			(!info.IsDir && (old.Size != info.Size || !old.ModTime.Equal(info.ModTime))) {
+			changed = append(changed, relPath)
+		}
+	}
+	for relPath := range oldFiles {
+		if _, exists := newFiles[relPath]; !exists {
+			removed = append(removed, relPath)
+		}
+	}
+	return changed, removed
+}
+
+// syncPaths 只同步发生变化的路径
+func (fst *FileSyncTool) syncPaths(changed, removed []string) SyncSummary {
+	summary := SyncSummary{StartTime: time.Now(), DryRun: fst.Config.DryRun}
+
+	// 先删除子路径再删除父目录
+	fst.trashStamp = summary.StartTime.Format("20060102_150405")
+	sort.Sort(sort.Reverse(sort.StringSlice(removed)))
+	for _, file := range removed {
+		if err := fst.DeleteFile(file); err != nil {
+			if !os.IsNotExist(err) {
+				log.Printf("删除失败 %s: %v", file, err)
+				summary.Failed++
+			}
+			continue
+		}
+		summary.Deleted++
+	}
+
+	summary.addCopyResult(fst.copyFiles(changed))
+
+	if fst.Config.PruneEmpty {
+		fst.pruneInto(&summary, removed)
+	}
+
+	summary.Elapsed = time.Since(summary.StartTime)
+	summary.ElapsedSeconds = summary.Elapsed.Seconds()
+	return summary
+}
+
+// WatchSync 监视模式：检测源目录变化，合并短时间内的连续变化后只同步改动的文件
+// Linux 上通过 inotify 得知目录树发生了变化，再比较文件元数据找出具体的路径；
+// 其他平台或跟随符号链接时（链接指向的目录不在监视范围内）退回每秒轮询元数据
+func (fst *FileSyncTool) WatchSync(debounce time.Duration) {
+	var notify <-chan struct{}
+	interval := watchPollInterval
+	if !fst.Config.FollowSymlinks {
+		events, err := watchTree(fst.Config.SourceDir)
+		if err == nil {
+			notify = events
+			interval = watchNotifyInterval
+		} else if fst.Config.Verbose {
+			fmt.Fprintf(fst.out, "无法使用文件系统通知 (%v)，改为轮询\n", err)
+		}
+	}
+	if notify != nil {
+		fmt.Fprintf(fst.out, "👀 启动监视模式 (inotify, 防抖: %v)，按 Ctrl+C 退出\n", debounce)
+	} else {
+		fmt.Fprintf(fst.out, "👀 启动监视模式 (轮询间隔: %v, 防抖: %v)，按 Ctrl+C 退出\n", watchPollInterval, debounce)
+	}
+
+	snapshot, err := fst.statTree(fst.Config.SourceDir)
+	if err != nil {
+		log.Printf("扫描源目录失败: %v", err)
+		snapshot = make(map[string]FileInfo)
+	}
+
+	pendingChanged := make(map[string]bool)
+	pendingRemoved := make(map[string]bool)
+	var lastEvent time.Time
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	dirty := notify == nil
+	for {
+		select {
+		case <-notify:
+			dirty = true
+			continue
+		case <-ticker.C:
+		}
+
+		var changed, removed []string
+		if dirty {
+			current, err := fst.statTree(fst.Config.SourceDir)
+			if err != nil {
+				log.Printf("扫描源目录失败: %v", err)
+				continue
+			}
+			changed, removed = diffSnapshots(snapshot, current)
+			snapshot = current
+			dirty = notify == nil
+		}
+		if len(changed)+len(removed) > 0 {
+			lastEvent = time.Now()
+			for _, file := range changed {
+				pendingChanged[file] = true
+				delete(pendingRemoved, file)
+			}
+			for _, file := range removed {
+				pendingRemoved[file] = true
+				delete(pendingChanged, file)
+			}
+		}
+
+		// 等待变化平息后再同步
+		if len(pendingChanged)+len(pendingRemoved) == 0 || time.Since(lastEvent) < debounce {
+			continue
+		}
+
+		var toCopy, toDelete []string
+		for file := range pendingChanged {
+			toCopy = append(toCopy, file)
+		}
+		for file := range pendingRemoved {
+			toDelete = append(toDelete, file)
+		}
+		sort.Strings(toCopy)
+
+		fmt.Fprintf(fst.out, "\n⚡ %s - 检测到变化: %d 个更新, %d 个删除\n",
+			time.Now().Format("2006-01-02 15:04:05"), len(toCopy), len(toDelete))
+		fst.PrintSummary(fst.syncPaths(toCopy, toDelete))
+
+		pendingChanged = make(map[string]bool)
+		pendingRemoved = make(map[string]bool)
+	}
+}
+
 func main() {
 	var (
 		sourceDir      = flag.String("source", "", "源目录路径")
@@ -417,11 +1518,24 @@ func main() {
 		syncMode       = flag.String("mode", "unidirectional", "同步模式: unidirectional(单向)/bidirectional(双向)")
 		checkInterval  = flag.Duration("interval", 30*time.Second, "检查间隔(持续模式)")
 		maxFileSize    = flag.Int64("maxsize", 100*1024*1024, "最大文件大小(字节)")
-		includePattern = flag.String("include", "", "包含文件模式")
-		excludePattern = flag.String("exclude", "", "排除文件模式")
+		includePattern = flag.String("include", "", "包含文件模式，多个用逗号分隔")
+		excludePattern = flag.String("exclude", "", "排除文件模式，多个用逗号分隔 (优先于包含模式)")
+		regexFilter    = flag.Bool("regex-filter", false, "包含/排除模式按正则表达式匹配相对路径")
+		followSymlinks = flag.Bool("follow-symlinks", false, "跟随符号链接复制其指向的内容(默认重建链接本身)")
+		cacheFile      = flag.String("cache-file", "", "校验和缓存文件路径 (默认为目标目录旁的 .<目录名>.synccache.json)")
+		noCache        = flag.Bool("no-cache", false, "不使用校验和缓存，每次重新计算")
+		trashDir       = flag.String("trash", "", "回收站目录，删除的文件移到此处而不是直接删除")
+		bwLimit        = flag.String("bwlimit", "", "所有复制共享的带宽上限，如 5MB、512K (每秒)")
+		pruneEmpty     = flag.Bool("prune-empty", false, "同步后删除目标目录中源目录没有的空目录")
+		ignoreFile     = flag.String("ignore-file", "", "gitignore风格的忽略文件路径 (如 .syncignore)")
 		dryRun         = flag.Bool("dryrun", false, "干运行模式(不实际执行)")
 		continuous     = flag.Bool("continuous", false, "持续同步模式")
+		watch          = flag.Bool("watch", false, "监视模式(只同步发生变化的文件)")
+		outputFormat   = flag.String("output", "text", "同步摘要输出格式: text/json")
+		conflict       = flag.String("conflict", "", "冲突处理策略: source/target/both/newer/skip (默认交互式询问)")
+		debounce       = flag.Duration("debounce", 2*time.Second, "监视模式下合并连续变化的等待时间")
 		verbose        = flag.Bool("verbose", false, "详细输出")
+		workers        = flag.Int("workers", 4, "并发复制的worker数量")
 		showHelp       = flag.Bool("help", false, "显示帮助信息")
 	)
 	flag.Parse()
@@ -435,48 +1549,134 @@ func main() {
 		fmt.Println("\n同步模式说明:")
 		fmt.Println("  unidirectional - 单向同步: 源目录 -> 目标目录")
 		fmt.Println("  bidirectional  - 双向同步: 检测并解决冲突")
+		fmt.Println("\n冲突处理策略 (-conflict):")
+		fmt.Println("  source - 使用源文件覆盖目标文件")
+		fmt.Println("  target - 保留目标文件并复制回源目录")
+		fmt.Println("  both   - 保留两个版本，重命名目标文件")
+		fmt.Println("  newer  - 保留修改时间较晚的文件")
+		fmt.Println("  skip   - 跳过冲突文件")
 		fmt.Println("\n示例:")
 		fmt.Println("  file_sync_tool -source ./src -target ./backup -mode unidirectional")
 		fmt.Println("  file_sync_tool -source ./docs -target ./backup -include *.txt -dryrun")
+		fmt.Println("  file_sync_tool -source ./www -target ./backup -include \"*.php,*.html\" -exclude \"tmp/*,cache/*\"")
+		fmt.Println("  file_sync_tool -source ./src -target ./backup -regex-filter -exclude \"(^|/)test_.*\\.go$\"")
 		fmt.Println("  file_sync_tool -source ./data -target ./sync -continuous -interval 1m")
+		fmt.Println("  file_sync_tool -source ./photos -target ./backup -workers 8")
+		fmt.Println("  file_sync_tool -source ./src -target ./mirror -watch -debounce 1s")
+		fmt.Println("  file_sync_tool -source ./a -target ./b -mode bidirectional -conflict newer")
+		fmt.Println("  file_sync_tool -source ./data -target ./sync -continuous -output json")
+		fmt.Println("  file_sync_tool -source ./project -target ./backup -ignore-file .syncignore")
+		fmt.Println("  file_sync_tool -source ./docs -target ./backup -trash ./backup_trash")
+		fmt.Println("  file_sync_tool -source ./media -target /mnt/nas/media -bwlimit 5MB")
+		fmt.Println("  file_sync_tool -source ./src -target ./mirror -prune-empty")
 		return
 	}
 
+	if *conflict != "" && !isValidConflictPolicy(*conflict) {
+		fmt.Printf("❌ 无效的冲突处理策略: %s (可选: %s)\n", *conflict, strings.Join(conflictPolicies, "/"))
+		os.Exit(1)
+	}
+
+	if *outputFormat != "text" && *outputFormat != "json" {
+		fmt.Printf("❌ 无效的输出格式: %s (可选: text/json)\n", *outputFormat)
+		os.Exit(1)
+	}
+
+	humanOut := progressOutput(*outputFormat)
+
 	// 验证目录存在
 	if _, err := os.Stat(*sourceDir); os.IsNotExist(err) {
-		fmt.Printf("❌ 源目录不存在: %s\n", *sourceDir)
+		fmt.Fprintf(humanOut, "❌ 源目录不存在: %s\n", *sourceDir)
 		os.Exit(1)
 	}
 
 	if _, err := os.Stat(*targetDir); os.IsNotExist(err) {
-		fmt.Printf("⚠️  目标目录不存在，将创建: %s\n", *targetDir)
+		fmt.Fprintf(humanOut, "⚠️  目标目录不存在，将创建: %s\n", *targetDir)
 		if err := os.MkdirAll(*targetDir, 0755); err != nil {
-			fmt.Printf("❌ 创建目标目录失败: %v\n", err)
+			fmt.Fprintf(humanOut, "❌ 创建目标目录失败: %v\n", err)
 			os.Exit(1)
 		}
 	}
 
 	// 创建配置
 	config := SyncConfig{
-		SourceDir:      *sourceDir,
-		TargetDir:      *targetDir,
-		SyncMode:       *syncMode,
-		CheckInterval:  *checkInterval,
-		MaxFileSize:    *maxFileSize,
-		IncludePattern: *includePattern,
-		ExcludePattern: *excludePattern,
-		DryRun:         *dryRun,
-		Verbose:        *verbose,
+		SourceDir:       *sourceDir,
+		TargetDir:       *targetDir,
+		SyncMode:        *syncMode,
+		CheckInterval:   *checkInterval,
+		MaxFileSize:     *maxFileSize,
+		IncludePatterns: splitPatterns(*includePattern),
+		ExcludePatterns: splitPatterns(*excludePattern),
+		RegexFilter:     *regexFilter,
+		DryRun:          *dryRun,
+		Verbose:         *verbose,
+		Workers:         *workers,
+		ConflictPolicy:  *conflict,
+		OutputFormat:    *outputFormat,
+		FollowSymlinks:  *followSymlinks,
+		TrashDir:        *trashDir,
+		PruneEmpty:      *pruneEmpty,
+	}
+
+	if *bwLimit != "" {
+		limit, err := parseByteSize(*bwLimit)
+		if err != nil || limit <= 0 {
+			fmt.Fprintf(humanOut, "❌ 无效的带宽限制: %s (示例: 5MB、512K)\n", *bwLimit)
+			os.Exit(1)
+		}
+		config.BandwidthLimit = limit
+		fmt.Fprintf(humanOut, "🚦 带宽限制: %.2f MB/s\n", float64(limit)/1024/1024)
+	}
+
+	if !*noCache {
+		config.CacheFile = *cacheFile
+		if config.CacheFile == "" {
+			config.CacheFile = DefaultCacheFile(*targetDir)
+		}
+	}
+
+	if *ignoreFile != "" {
+		rules, err := LoadIgnoreFile(*ignoreFile)
+		if err != nil {
+			fmt.Fprintf(humanOut, "❌ %v\n", err)
+			os.Exit(1)
+		}
+		config.IgnoreRules = rules
+		fmt.Fprintf(humanOut, "🙈 已加载 %d 条忽略规则: %s\n", len(rules), *ignoreFile)
+	}
+
+	// 回收站不能位于目标目录内，否则会被当作多余文件再次删除
+	if *trashDir != "" {
+		if rel, err := filepath.Rel(*targetDir, *trashDir); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			fmt.Fprintf(humanOut, "❌ 回收站目录不能位于目标目录内: %s\n", *trashDir)
+			os.Exit(1)
+		}
 	}
 
 	// 创建同步工具
 	syncTool := NewFileSyncTool(config)
+	if err := syncTool.CompileFilters(); err != nil {
+		fmt.Fprintf(humanOut, "❌ %v\n", err)
+		os.Exit(1)
+	}
+	if err := syncTool.LoadCache(); err != nil {
+		// 缓存只用于加速，损坏时重新计算即可
+		fmt.Fprintf(humanOut, "⚠️  %v，将重新计算校验和\n", err)
+	}
 
 	// 执行同步
-	if err := syncTool.RunSync(); err != nil {
-		fmt.Printf("❌ 同步失败: %v\n", err)
+	summary, err := syncTool.RunSync()
+	if err != nil {
+		fmt.Fprintf(humanOut, "❌ 同步失败: %v\n", err)
 		os.Exit(1)
 	}
+	syncTool.PrintSummary(summary)
+
+	// 监视模式优先于持续同步模式
+	if *watch {
+		syncTool.WatchSync(*debounce)
+		return
+	}
 
 	// 持续同步模式
 	if *continuous {
@@ -0,0 +1,101 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask 监视的事件：内容修改、属性变化、创建、删除和移动
+const inotifyMask = syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE |
+	syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_DELETE_SELF |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// inotifyWatcher 基于 inotify 的目录树监视，inotify 只对单个目录生效，
+// 因此启动时为每个子目录添加监视，之后新建或移入的目录也会自动加入
+type inotifyWatcher struct {
+	fd     int
+	dirs   map[int]string // 监视描述符 -> 目录路径
+	events chan struct{}
+}
+
+// watchTree 监视 root 整个目录树，发生变化时向返回的通道发送信号（多次变化可能合并为一次）
+func watchTree(root string) (<-chan struct{}, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	w := &inotifyWatcher{fd: fd, dirs: make(map[int]string), events: make(chan struct{}, 1)}
+	if err := w.addTree(root); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	go w.readLoop()
+	return w.events, nil
+}
+
+// addTree 为 dir 及其所有子目录添加监视，不跟随符号链接
+func (w *inotifyWatcher) addTree(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// 遍历期间被删除的目录忽略即可，删除事件会触发重新扫描
+			if path != dir {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return nil
+		}
+		wd, err := syscall.InotifyAddWatch(w.fd, path, inotifyMask)
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			return nil
+		}
+		w.dirs[wd] = path
+		return nil
+	})
+}
+
+// readLoop 读取 inotify 事件，为新目录添加监视并通知监视循环
+func (w *inotifyWatcher) readLoop() {
+	var buf [64 * 1024]byte
+	for {
+		n, err := syscall.Read(w.fd, buf[:])
+		if err != nil {
+			if err == syscall.EINTR {
+				continue
+			}
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			name := strings.TrimRight(string(buf[nameStart:nameStart+int(event.Len)]), "\x00")
+			offset = nameStart + int(event.Len)
+
+			switch {
+			case event.Mask&syscall.IN_IGNORED != 0:
+				delete(w.dirs, int(event.Wd))
+			case event.Mask&syscall.IN_ISDIR != 0 && event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+				if dir, ok := w.dirs[int(event.Wd)]; ok && name != "" {
+					w.addTree(filepath.Join(dir, name))
+				}
+			}
+		}
+
+		// 队列溢出时同样只需要通知一次，监视循环会全量比较元数据
+		select {
+		case w.events <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+// +build !linux
+
+package main
+
+import "errors"
+
+// watchTree 非 Linux 平台没有使用系统级文件通知，监视模式退回轮询
+func watchTree(root string) (<-chan struct{}, error) {
+	return nil, errors.New("当前平台不支持文件系统通知")
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// getDiskUsage 其他平台暂不支持磁盘监控
+func getDiskUsage(path string) (DiskInfo, error) {
+	return DiskInfo{}, fmt.Errorf("不支持的操作系统: %s", runtime.GOOS)
+}
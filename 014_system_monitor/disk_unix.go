@@ -0,0 +1,29 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package main
+
+import "syscall"
+
+// getDiskUsage 通过statfs获取指定路径所在文件系统的使用情况
+func getDiskUsage(path string) (DiskInfo, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return DiskInfo{}, err
+	}
+
+	blockSize := uint64(stat.Bsize)
+	disk := DiskInfo{
+		Path:      path,
+		Total:     stat.Blocks * blockSize,
+		Used:      (stat.Blocks - stat.Bfree) * blockSize,
+		Available: stat.Bavail * blockSize,
+	}
+
+	// 与df一致，使用率按普通用户可用的空间计算（不含为root保留的块）
+	if disk.Used+disk.Available > 0 {
+		disk.Usage = float64(disk.Used) / float64(disk.Used+disk.Available) * 100
+	}
+
+	return disk, nil
+}
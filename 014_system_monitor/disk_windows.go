@@ -0,0 +1,39 @@
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceEx = kernel32.NewProc("GetDiskFreeSpaceExW")
+
+// getDiskUsage 通过GetDiskFreeSpaceEx获取指定路径所在磁盘的使用情况
+func getDiskUsage(path string) (DiskInfo, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return DiskInfo{}, err
+	}
+
+	var available, total, free uint64
+	ret, _, callErr := procGetDiskFreeSpaceEx.Call(
+		uintptr(unsafe.Pointer(pathPtr)),
+		uintptr(unsafe.Pointer(&available)),
+		uintptr(unsafe.Pointer(&total)),
+		uintptr(unsafe.Pointer(&free)),
+	)
+	if ret == 0 {
+		return DiskInfo{}, callErr
+	}
+
+	disk := DiskInfo{
+		Path:      path,
+		Total:     total,
+		Used:      total - free,
+		Available: available,
+	}
+	if total > 0 {
+		disk.Usage = float64(disk.Used) / float64(total) * 100
+	}
+
+	return disk, nil
+}
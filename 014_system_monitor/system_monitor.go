@@ -2,28 +2,37 @@ package main
 
 import (
 	"bufio"
+	"encoding/csv"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
+	"os/exec"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type SystemInfo struct {
-	Timestamp time.Time `json:"timestamp"`
-	CPU       CPUInfo   `json:"cpu"`
-	Memory    MemInfo   `json:"memory"`
-	Disk      DiskInfo  `json:"disk"`
-	System    SysInfo   `json:"system"`
+	Timestamp time.Time    `json:"timestamp"`
+	CPU       CPUInfo      `json:"cpu"`
+	Memory    MemInfo      `json:"memory"`
+	Disk      DiskInfo     `json:"disk"`
+	Network   NetInfo      `json:"network"`
+	System    SysInfo      `json:"system"`
+	Processes *ProcessList `json:"processes,omitempty"` // 仅在指定 -processes 时输出
 }
 
 type CPUInfo struct {
-	Usage   float64 `json:"usage"`
-	Cores   int     `json:"cores"`
-	LoadAvg float64 `json:"load_avg"`
+	Usage   float64    `json:"usage"`
+	Cores   int        `json:"cores"`
+	LoadAvg [3]float64 `json:"load_avg"` // 1、5、15分钟负载平均值
+	PerCore []float64  `json:"per_core"` // 每个核心的使用率，目前仅Linux提供
 }
 
 type MemInfo struct {
@@ -31,15 +40,49 @@ type MemInfo struct {
 	Available uint64  `json:"available"`
 	Used      uint64  `json:"used"`
 	Usage     float64 `json:"usage"`
+	Free      uint64  `json:"free"`       // 完全空闲的内存，目前仅Linux提供
+	Buffers   uint64  `json:"buffers"`    // 块设备缓冲区，目前仅Linux提供
+	Cached    uint64  `json:"cached"`     // 页缓存（含可回收slab），目前仅Linux提供
+	AppUsed   uint64  `json:"app_used"`   // 程序占用 = 总内存 - 空闲 - 缓冲区 - 缓存，目前仅Linux提供
+	SwapTotal uint64  `json:"swap_total"` // 交换分区总大小
+	SwapFree  uint64  `json:"swap_free"`
+	SwapUsed  uint64  `json:"swap_used"`
+	SwapUsage float64 `json:"swap_usage"`
 }
 
 type DiskInfo struct {
+	Path      string  `json:"path"`
 	Total     uint64  `json:"total"`
 	Used      uint64  `json:"used"`
 	Available uint64  `json:"available"`
 	Usage     float64 `json:"usage"`
 }
 
+type NetInfo struct {
+	Interfaces []InterfaceInfo `json:"interfaces"`
+}
+
+type InterfaceInfo struct {
+	Name    string  `json:"name"`
+	RxBytes uint64  `json:"rx_bytes"` // 累计接收字节数
+	TxBytes uint64  `json:"tx_bytes"` // 累计发送字节数
+	RxRate  float64 `json:"rx_rate"`  // 当前接收速率(字节/秒)
+	TxRate  float64 `json:"tx_rate"`  // 当前发送速率(字节/秒)
+}
+
+type ProcessList struct {
+	TopCPU    []ProcessInfo `json:"top_cpu"`
+	TopMemory []ProcessInfo `json:"top_memory"`
+	Note      string        `json:"note,omitempty"` // 平台不支持等说明
+}
+
+type ProcessInfo struct {
+	PID  int     `json:"pid"`
+	Name string  `json:"name"`
+	CPU  float64 `json:"cpu"` // 占整机CPU的百分比
+	RSS  uint64  `json:"rss"` // 常驻内存(字节)
+}
+
 type SysInfo struct {
 	OS       string `json:"os"`
 	Arch     string `json:"arch"`
@@ -49,11 +92,22 @@ type SysInfo struct {
 
 func main() {
 	var (
-		interval = flag.Duration("interval", 5*time.Second, "监控间隔时间")
-		count    = flag.Int("count", 1, "监控次数 (-1 表示持续监控)")
-		output   = flag.String("output", "console", "输出格式: console, json")
-		file     = flag.String("file", "", "输出到文件")
-		help     = flag.Bool("help", false, "显示帮助信息")
+		interval      = flag.Duration("interval", 5*time.Second, "监控间隔时间")
+		count         = flag.Int("count", 1, "监控次数 (-1 表示持续监控)")
+		output        = flag.String("output", "console", "输出格式: console, json")
+		file          = flag.String("file", "", "输出到文件")
+		perCore       = flag.Bool("per-core", false, "显示每个CPU核心的使用率")
+		processes     = flag.Int("processes", 0, "显示CPU和内存占用最高的N个进程")
+		iface         = flag.String("iface", "", "只显示指定网络接口，如 eth0")
+		cpuThreshold  = flag.Float64("cpu-threshold", 0, "CPU使用率告警阈值(%)，0表示不检查")
+		memThreshold  = flag.Float64("mem-threshold", 0, "内存使用率告警阈值(%)，0表示不检查")
+		diskThreshold = flag.Float64("disk-threshold", 0, "磁盘使用率告警阈值(%)，0表示不检查")
+		alertCmd      = flag.String("alert-cmd", "", "触发告警时执行的shell命令")
+		diskPath      = flag.String("path", defaultDiskPath(), "监控该路径所在磁盘的使用情况")
+		graph         = flag.Bool("graph", false, "多次监控时在控制台用迷你图显示CPU和内存使用率趋势")
+		graphWidth    = flag.Int("graph-width", 30, "迷你图显示的最近采样数")
+		serve         = flag.String("serve", "", "以HTTP服务方式运行的监听地址，如 :9100，提供 /metrics 和 /json")
+		help          = flag.Bool("help", false, "显示帮助信息")
 	)
 	flag.Parse()
 
@@ -62,11 +116,41 @@ func main() {
 		return
 	}
 
+	// 接口不存在时每次采样都会失败，启动时先检查
+	if *iface != "" && runtime.GOOS == "linux" {
+		sample, err := readNetSampleLinux()
+		if err == nil {
+			if _, ok := sample.counters[*iface]; !ok {
+				fmt.Printf("找不到网络接口: %s\n", *iface)
+				os.Exit(1)
+			}
+		}
+	}
+
+	if *serve != "" {
+		if err := runMetricsServer(*serve, *diskPath, *iface, *processes); err != nil {
+			fmt.Printf("HTTP服务运行失败: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var outputFile *os.File
 	var err error
 
+	// CSV输出追加到已有文件，只有新文件（或空文件）才写表头
+	csvHeader := *output == "csv"
 	if *file != "" {
-		outputFile, err = os.Create(*file)
+		if *output == "csv" {
+			outputFile, err = os.OpenFile(*file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+			if err == nil {
+				if stat, statErr := outputFile.Stat(); statErr == nil && stat.Size() > 0 {
+					csvHeader = false
+				}
+			}
+		} else {
+			outputFile, err = os.Create(*file)
+		}
 		if err != nil {
 			fmt.Printf("创建输出文件失败: %v\n", err)
 			return
@@ -74,13 +158,24 @@ func main() {
 		defer outputFile.Close()
 	}
 
+	alerts := newAlertMonitor(*cpuThreshold, *memThreshold, *diskThreshold, *alertCmd)
+
+	// 迷你图只在多次监控、输出到终端的控制台格式下显示
+	var trends *usageTrends
+	if *graph && *count != 1 && *output == "console" && outputFile == nil && stdoutIsTerminal() {
+		if *graphWidth < 1 {
+			*graphWidth = 1
+		}
+		trends = newUsageTrends(*graphWidth)
+	}
+
 	monitorCount := 0
 	for {
 		if *count > 0 && monitorCount >= *count {
 			break
 		}
 
-		info, err := getSystemInfo()
+		info, err := getSystemInfo(*diskPath, *iface, *processes)
 		if err != nil {
 			fmt.Printf("获取系统信息失败: %v\n", err)
 			time.Sleep(*interval)
@@ -90,15 +185,26 @@ func main() {
 		switch *output {
 		case "json":
 			outputJSON(info, outputFile)
+		case "csv":
+			outputCSV(info, outputFile, csvHeader)
+			csvHeader = false
 		default:
-			outputConsole(info, outputFile)
+			trends.add(info)
+			outputConsole(info, outputFile, *perCore, trends)
 		}
 
+		alerts.check(info)
+
 		monitorCount++
 		if *count == -1 || (*count > 1 && monitorCount < *count) {
 			time.Sleep(*interval)
 		}
 	}
+
+	// 非持续模式下，出现过告警则以非零状态退出，便于脚本判断
+	if alerts.fired > 0 {
+		os.Exit(2)
+	}
 }
 
 func showHelp() {
@@ -110,8 +216,19 @@ func showHelp() {
 	fmt.Println("选项:")
 	fmt.Println("  -interval duration  监控间隔时间 (默认: 5s)")
 	fmt.Println("  -count int          监控次数, -1表示持续监控 (默认: 1)")
-	fmt.Println("  -output string      输出格式: console, json (默认: console)")
+	fmt.Println("  -output string      输出格式: console, json, csv (默认: console)")
 	fmt.Println("  -file string        输出到文件")
+	fmt.Println("  -per-core           显示每个CPU核心的使用率")
+	fmt.Println("  -processes int      显示CPU和内存占用最高的N个进程")
+	fmt.Println("  -iface string       只显示指定网络接口")
+	fmt.Println("  -cpu-threshold float   CPU使用率告警阈值(%), 0表示不检查")
+	fmt.Println("  -mem-threshold float   内存使用率告警阈值(%), 0表示不检查")
+	fmt.Println("  -disk-threshold float  磁盘使用率告警阈值(%), 0表示不检查")
+	fmt.Println("  -alert-cmd string      触发告警时执行的shell命令")
+	fmt.Println("  -path string        监控该路径所在磁盘 (默认: / 或 C:\\)")
+	fmt.Println("  -graph              多次监控时用迷你图显示CPU和内存使用率趋势")
+	fmt.Println("  -graph-width int    迷你图显示的最近采样数 (默认: 30)")
+	fmt.Println("  -serve string       以HTTP服务方式运行，如 :9100，/metrics 为Prometheus格式，/json 为原始数据")
 	fmt.Println("  -help               显示此帮助信息")
 	fmt.Println()
 	fmt.Println("示例:")
@@ -119,9 +236,17 @@ func showHelp() {
 	fmt.Println("  system_monitor -count -1 -interval 2s             # 持续监控，2秒间隔")
 	fmt.Println("  system_monitor -output json -file monitor.json    # JSON格式输出到文件")
 	fmt.Println("  system_monitor -count 10 -interval 1s             # 监控10次，1秒间隔")
+	fmt.Println("  system_monitor -count -1 -interval 1m -output csv -file trend.csv  # 追加记录趋势")
+	fmt.Println("  system_monitor -path /data                        # 监控/data所在磁盘")
+	fmt.Println("  system_monitor -per-core                          # 显示每个核心的使用率")
+	fmt.Println("  system_monitor -iface eth0 -count -1 -interval 1s # 持续观察eth0的流量")
+	fmt.Println("  system_monitor -count -1 -interval 1s -graph      # 持续监控并显示使用率趋势")
+	fmt.Println("  system_monitor -processes 5                       # 显示资源占用最高的5个进程")
+	fmt.Println("  system_monitor -cpu-threshold 90 -disk-threshold 95 # 超过阈值时告警并返回非零状态")
+	fmt.Println("  system_monitor -serve :9100                       # 供Prometheus抓取 http://host:9100/metrics")
 }
 
-func getSystemInfo() (*SystemInfo, error) {
+func getSystemInfo(diskPath, iface string, topProcesses int) (*SystemInfo, error) {
 	info := &SystemInfo{
 		Timestamp: time.Now(),
 	}
@@ -138,25 +263,40 @@ func getSystemInfo() (*SystemInfo, error) {
 		return nil, fmt.Errorf("获取内存信息失败: %v", err)
 	}
 
-	info.Disk, err = getDiskInfo()
+	info.Disk, err = getDiskInfo(diskPath)
 	if err != nil {
 		return nil, fmt.Errorf("获取磁盘信息失败: %v", err)
 	}
 
+	info.Network, err = getNetworkInfo(iface)
+	if err != nil {
+		return nil, fmt.Errorf("获取网络信息失败: %v", err)
+	}
+
 	info.System = getBasicSystemInfo()
 
+	if topProcesses > 0 {
+		processes, err := getTopProcesses(topProcesses)
+		if err != nil {
+			return nil, fmt.Errorf("获取进程信息失败: %v", err)
+		}
+		info.Processes = &processes
+	}
+
 	return info, nil
 }
 
 func getCPUInfo() (CPUInfo, error) {
 	cpu := CPUInfo{
-		Cores: runtime.NumCPU(),
+		Cores:   runtime.NumCPU(),
+		PerCore: []float64{},
 	}
 
 	if runtime.GOOS == "linux" {
-		usage, err := getCPUUsageLinux()
+		usage, perCore, err := getCPUUsageLinux()
 		if err == nil {
 			cpu.Usage = usage
+			cpu.PerCore = perCore
 		}
 
 		loadAvg, err := getLoadAverageLinux()
@@ -168,78 +308,251 @@ func getCPUInfo() (CPUInfo, error) {
 		if err == nil {
 			cpu.Usage = usage
 		}
+	} else if runtime.GOOS == "darwin" {
+		usage, err := getCPUUsageDarwin()
+		if err == nil {
+			cpu.Usage = usage
+		}
+
+		loadAvg, err := getLoadAverageDarwin()
+		if err == nil {
+			cpu.LoadAvg = loadAvg
+		}
 	}
 
 	return cpu, nil
 }
 
-func getCPUUsageLinux() (float64, error) {
-	file, err := os.Open("/proc/stat")
+// cpuSample /proc/stat 中CPU时间的一次快照（单位: jiffies）
+type cpuSample struct {
+	total uint64
+	idle  uint64
+}
+
+// cpuSnapshot 一次采样中的汇总CPU时间和各核心的CPU时间
+type cpuSnapshot struct {
+	total cpuSample
+	cores []cpuSample
+}
+
+// cpuSampleInterval 没有上一次采样时，两次读取之间的间隔
+const cpuSampleInterval = 200 * time.Millisecond
+
+// lastCPUSnapshot 上一次的采样，持续监控时作为下一次计算的基准
+var lastCPUSnapshot *cpuSnapshot
+
+// getCPUUsageLinux 根据两次采样的差值计算当前CPU总使用率和各核心使用率
+func getCPUUsageLinux() (float64, []float64, error) {
+	return sampleCPUUsage(readCPUSnapshotLinux)
+}
+
+// sampleCPUUsage 使用给定的采样函数计算两次采样之间的CPU使用率
+// 首次调用时采样两次；之后以上一次采样为基准，不再额外等待
+func sampleCPUUsage(read func() (cpuSnapshot, error)) (float64, []float64, error) {
+	if lastCPUSnapshot == nil {
+		first, err := read()
+		if err != nil {
+			return 0, nil, err
+		}
+		lastCPUSnapshot = &first
+		time.Sleep(cpuSampleInterval)
+	}
+
+	current, err := read()
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	if !scanner.Scan() {
-		return 0, fmt.Errorf("无法读取CPU统计信息")
+	usage := cpuUsageBetween(lastCPUSnapshot.total, current.total)
+	perCore := make([]float64, 0, len(current.cores))
+	for i, core := range current.cores {
+		// 核心热插拔时缺少基准的核心按0处理
+		if i < len(lastCPUSnapshot.cores) {
+			perCore = append(perCore, cpuUsageBetween(lastCPUSnapshot.cores[i], core))
+		} else {
+			perCore = append(perCore, 0)
+		}
+	}
+
+	lastCPUSnapshot = &current
+	return usage, perCore, nil
+}
+
+// readCPUSnapshotLinux 读取 /proc/stat 中的汇总和各核心CPU时间
+func readCPUSnapshotLinux() (cpuSnapshot, error) {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return cpuSnapshot{}, err
+	}
+
+	return parseProcStat(string(data))
+}
+
+// parseProcStat 解析 /proc/stat 内容，"cpu" 行为汇总，"cpuN" 行为各核心
+func parseProcStat(content string) (cpuSnapshot, error) {
+	var snapshot cpuSnapshot
+	found := false
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "cpu") {
+			continue
+		}
+
+		sample, err := parseCPUSample(line)
+		if err != nil {
+			return cpuSnapshot{}, err
+		}
+		if strings.HasPrefix(line, "cpu ") {
+			snapshot.total = sample
+			found = true
+		} else {
+			snapshot.cores = append(snapshot.cores, sample)
+		}
 	}
 
-	line := scanner.Text()
+	if !found {
+		return cpuSnapshot{}, fmt.Errorf("无法读取CPU统计信息")
+	}
+	return snapshot, nil
+}
+
+// parseCPUSample 解析 /proc/stat 中的cpu行
+// 字段依次为 user nice system idle iowait irq softirq steal guest guest_nice，
+// guest时间已包含在user中，因此只累加前8项；iowait计入空闲
+func parseCPUSample(line string) (cpuSample, error) {
 	fields := strings.Fields(line)
-	if len(fields) < 8 {
-		return 0, fmt.Errorf("CPU统计信息格式错误")
+	if len(fields) < 5 || !strings.HasPrefix(fields[0], "cpu") {
+		return cpuSample{}, fmt.Errorf("CPU统计信息格式错误")
 	}
 
-	var total, idle uint64
-	for i := 1; i < len(fields); i++ {
+	var sample cpuSample
+	for i := 1; i < len(fields) && i <= 8; i++ {
 		val, err := strconv.ParseUint(fields[i], 10, 64)
 		if err != nil {
-			return 0, err
+			return cpuSample{}, err
 		}
-		total += val
-		if i == 4 {
-			idle = val
+		sample.total += val
+		if i == 4 || i == 5 {
+			sample.idle += val
 		}
 	}
 
-	if total == 0 {
-		return 0, nil
+	return sample, nil
+}
+
+// cpuUsageBetween 计算两次采样之间的CPU使用率
+func cpuUsageBetween(prev, cur cpuSample) float64 {
+	if cur.total <= prev.total {
+		return 0
 	}
 
-	usage := float64(total-idle) / float64(total) * 100
-	return usage, nil
+	totalDelta := cur.total - prev.total
+	var idleDelta uint64
+	if cur.idle > prev.idle {
+		idleDelta = cur.idle - prev.idle
+	}
+	if idleDelta > totalDelta {
+		return 0
+	}
+
+	return float64(totalDelta-idleDelta) / float64(totalDelta) * 100
 }
 
+// getCPUUsageWindows 通过GetSystemTimes的两次采样计算CPU使用率
 func getCPUUsageWindows() (float64, error) {
-	return 0, fmt.Errorf("Windows CPU使用率监控需要额外的系统API")
+	usage, _, err := sampleCPUUsage(func() (cpuSnapshot, error) {
+		sample, err := readCPUSampleWindows()
+		return cpuSnapshot{total: sample}, err
+	})
+	return usage, err
 }
 
-func getLoadAverageLinux() (float64, error) {
-	file, err := os.Open("/proc/loadavg")
+// getCPUUsageDarwin 汇总 ps 输出的各进程CPU占用，按核心数折算为整体使用率
+// ps 的 %cpu 是进程近期的平均值，结果是近似值
+func getCPUUsageDarwin() (float64, error) {
+	out, err := exec.Command("ps", "-A", "-o", "%cpu=").Output()
 	if err != nil {
 		return 0, err
 	}
+
+	var total float64
+	for _, field := range strings.Fields(string(out)) {
+		value, err := strconv.ParseFloat(strings.Replace(field, ",", ".", 1), 64)
+		if err == nil {
+			total += value
+		}
+	}
+
+	usage := total / float64(runtime.NumCPU())
+	if usage > 100 {
+		usage = 100
+	}
+	return usage, nil
+}
+
+// getLoadAverageDarwin 通过 sysctl vm.loadavg 获取1/5/15分钟负载，输出形如 "{ 1.23 1.10 1.05 }"
+func getLoadAverageDarwin() ([3]float64, error) {
+	out, err := exec.Command("sysctl", "-n", "vm.loadavg").Output()
+	if err != nil {
+		return [3]float64{}, err
+	}
+
+	return parseLoadAverage(strings.Fields(strings.Trim(strings.TrimSpace(string(out)), "{}")))
+}
+
+// getLoadAverageLinux 读取 /proc/loadavg 的前三个字段，形如 "0.52 0.58 0.59 1/389 12345"
+func getLoadAverageLinux() ([3]float64, error) {
+	file, err := os.Open("/proc/loadavg")
+	if err != nil {
+		return [3]float64{}, err
+	}
 	defer file.Close()
 
 	scanner := bufio.NewScanner(file)
 	if !scanner.Scan() {
-		return 0, fmt.Errorf("无法读取负载平均值")
+		return [3]float64{}, fmt.Errorf("无法读取负载平均值")
 	}
 
-	fields := strings.Fields(scanner.Text())
-	if len(fields) < 1 {
-		return 0, fmt.Errorf("负载平均值格式错误")
-	}
+	return parseLoadAverage(strings.Fields(scanner.Text()))
+}
 
-	loadAvg, err := strconv.ParseFloat(fields[0], 64)
-	if err != nil {
-		return 0, err
+// parseLoadAverage 解析1、5、15分钟三个负载值
+func parseLoadAverage(fields []string) ([3]float64, error) {
+	var loadAvg [3]float64
+	if len(fields) < len(loadAvg) {
+		return loadAvg, fmt.Errorf("负载平均值格式错误")
 	}
 
+	for i := range loadAvg {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return [3]float64{}, fmt.Errorf("负载平均值格式错误: %v", err)
+		}
+		loadAvg[i] = value
+	}
 	return loadAvg, nil
 }
 
+// formatLoadAverage 显示三个负载值，并比较1分钟与15分钟负载判断趋势
+func formatLoadAverage(loadAvg [3]float64) string {
+	text := fmt.Sprintf("%.2f %.2f %.2f (1/5/15分钟)", loadAvg[0], loadAvg[1], loadAvg[2])
+	switch {
+	case loadAvg == [3]float64{}:
+	case loadAvg[0] > loadAvg[2]*(1+loadTrendMargin):
+		text += " ↑ 上升"
+	case loadAvg[0] < loadAvg[2]*(1-loadTrendMargin):
+		text += " ↓ 下降"
+	default:
+		text += " → 平稳"
+	}
+	return text
+}
+
+// 1分钟负载偏离15分钟负载超过该比例时才认为负载在上升或下降
+const loadTrendMargin = 0.1
+
 func getMemoryInfo() (MemInfo, error) {
 	mem := MemInfo{}
 
@@ -247,6 +560,8 @@ func getMemoryInfo() (MemInfo, error) {
 		return getMemoryInfoLinux()
 	} else if runtime.GOOS == "windows" {
 		return getMemoryInfoWindows()
+	} else if runtime.GOOS == "darwin" {
+		return getMemoryInfoDarwin()
 	}
 
 	return mem, fmt.Errorf("不支持的操作系统: %s", runtime.GOOS)
@@ -281,82 +596,569 @@ func getMemoryInfoLinux() (MemInfo, error) {
 			mem.Total = value
 		case "MemAvailable":
 			mem.Available = value
+		case "MemFree":
+			mem.Free = value
+		case "Buffers":
+			mem.Buffers = value
+		case "Cached", "SReclaimable":
+			// 与 free 命令的 buff/cache 口径一致，可回收的slab计入缓存
+			mem.Cached += value
+		case "SwapTotal":
+			mem.SwapTotal = value
+		case "SwapFree":
+			mem.SwapFree = value
 		}
 	}
 
+	mem.Used = mem.Total - mem.Available
+	if mem.Total > 0 {
+		mem.Usage = float64(mem.Used) / float64(mem.Total) * 100
+	}
+	if reclaimable := mem.Free + mem.Buffers + mem.Cached; reclaimable < mem.Total {
+		mem.AppUsed = mem.Total - reclaimable
+	}
+	setSwapUsage(&mem)
+
+	return mem, nil
+}
+
+// setSwapUsage 根据交换分区总量和空闲量计算已用量和使用率
+func setSwapUsage(mem *MemInfo) {
+	if mem.SwapFree > mem.SwapTotal {
+		mem.SwapFree = mem.SwapTotal
+	}
+	mem.SwapUsed = mem.SwapTotal - mem.SwapFree
+	if mem.SwapTotal > 0 {
+		mem.SwapUsage = float64(mem.SwapUsed) / float64(mem.SwapTotal) * 100
+	}
+}
+
+// getMemoryInfoDarwin 通过 sysctl hw.memsize 获取总内存，vm_stat 获取可用内存
+// 可用内存按 空闲 + 非活跃 + 推测 页计算，与活动监视器的口径接近
+func getMemoryInfoDarwin() (MemInfo, error) {
+	out, err := exec.Command("sysctl", "-n", "hw.memsize").Output()
+	if err != nil {
+		return MemInfo{}, err
+	}
+	total, err := strconv.ParseUint(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return MemInfo{}, fmt.Errorf("解析总内存失败: %v", err)
+	}
+
+	out, err = exec.Command("vm_stat").Output()
+	if err != nil {
+		return MemInfo{}, err
+	}
+	pageSize, pages := parseVMStat(string(out))
+
+	mem := MemInfo{Total: total}
+	mem.Available = (pages["Pages free"] + pages["Pages inactive"] + pages["Pages speculative"]) * pageSize
+	if mem.Available > mem.Total {
+		mem.Available = mem.Total
+	}
 	mem.Used = mem.Total - mem.Available
 	if mem.Total > 0 {
 		mem.Usage = float64(mem.Used) / float64(mem.Total) * 100
 	}
 
+	// 交换分区信息获取失败时不影响内存数据
+	if out, err := exec.Command("sysctl", "-n", "vm.swapusage").Output(); err == nil {
+		mem.SwapTotal, mem.SwapFree = parseSwapUsage(string(out))
+		setSwapUsage(&mem)
+	}
+
 	return mem, nil
 }
 
-func getMemoryInfoWindows() (MemInfo, error) {
-	var m runtime.MemStats
-	runtime.ReadMemStats(&m)
+// parseSwapUsage 解析 sysctl vm.swapusage 输出，返回交换分区总量和空闲量
+// 输出形如 "total = 2048.00M  used = 1024.00M  free = 1024.00M  (encrypted)"
+func parseSwapUsage(output string) (uint64, uint64) {
+	values := make(map[string]uint64)
+	fields := strings.Fields(output)
+	for i := 0; i+2 < len(fields); i++ {
+		if fields[i+1] != "=" {
+			continue
+		}
+		text := fields[i+2]
+		multiplier := uint64(1)
+		switch {
+		case strings.HasSuffix(text, "K"):
+			multiplier = 1 << 10
+		case strings.HasSuffix(text, "M"):
+			multiplier = 1 << 20
+		case strings.HasSuffix(text, "G"):
+			multiplier = 1 << 30
+		}
+		number, err := strconv.ParseFloat(strings.TrimRight(text, "KMG"), 64)
+		if err != nil {
+			continue
+		}
+		values[fields[i]] = uint64(number * float64(multiplier))
+	}
+	return values["total"], values["free"]
+}
+
+// parseVMStat 解析 vm_stat 输出，返回页大小和各项页数
+// 输出首行形如 "Mach Virtual Memory Statistics: (page size of 16384 bytes)"，
+// 其余行形如 "Pages free:    12345."
+func parseVMStat(output string) (uint64, map[string]uint64) {
+	pageSize := uint64(4096)
+	pages := make(map[string]uint64)
+
+	for _, line := range strings.Split(output, "\n") {
+		if idx := strings.Index(line, "page size of "); idx >= 0 {
+			fields := strings.Fields(line[idx+len("page size of "):])
+			if len(fields) > 0 {
+				if size, err := strconv.ParseUint(fields[0], 10, 64); err == nil {
+					pageSize = size
+				}
+			}
+			continue
+		}
 
-	mem := MemInfo{
-		Used: m.Sys,
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(parts[1]), "."), 10, 64)
+		if err != nil {
+			continue
+		}
+		pages[strings.TrimSpace(parts[0])] = value
 	}
 
-	return mem, fmt.Errorf("Windows内存监控需要额外的系统API")
+	return pageSize, pages
 }
 
-func getDiskInfo() (DiskInfo, error) {
-	disk := DiskInfo{}
+// defaultDiskPath 默认监控的磁盘路径
+func defaultDiskPath() string {
+	if runtime.GOOS == "windows" {
+		return "C:\\"
+	}
+	return "/"
+}
 
-	if runtime.GOOS == "linux" {
-		return getDiskInfoLinux()
-	} else if runtime.GOOS == "windows" {
-		return getDiskInfoWindows()
+// getDiskInfo 获取指定路径所在文件系统（挂载点或盘符）的使用情况
+// 具体实现按平台位于 disk_unix.go、disk_windows.go 中
+func getDiskInfo(path string) (DiskInfo, error) {
+	return getDiskUsage(path)
+}
+
+// netSample 一次网络计数采样
+type netSample struct {
+	time     time.Time
+	counters map[string][2]uint64 // 接口名 -> [接收字节, 发送字节]
+}
+
+// lastNetSample 上一次的网络采样，用于计算速率
+var lastNetSample *netSample
+
+// getNetworkInfo 获取网络接口的累计流量和当前速率，iface非空时只返回该接口
+// 目前仅Linux通过 /proc/net/dev 提供，其他平台返回空列表
+func getNetworkInfo(iface string) (NetInfo, error) {
+	net := NetInfo{Interfaces: []InterfaceInfo{}}
+	if runtime.GOOS != "linux" {
+		return net, nil
+	}
+
+	if lastNetSample == nil {
+		first, err := readNetSampleLinux()
+		if err != nil {
+			return net, err
+		}
+		lastNetSample = &first
+		time.Sleep(cpuSampleInterval)
+	}
+
+	current, err := readNetSampleLinux()
+	if err != nil {
+		return net, err
+	}
+
+	elapsed := current.time.Sub(lastNetSample.time).Seconds()
+	names := make([]string, 0, len(current.counters))
+	for name := range current.counters {
+		names = append(names, name)
 	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if iface != "" && name != iface {
+			continue
+		}
 
-	return disk, fmt.Errorf("不支持的操作系统: %s", runtime.GOOS)
+		counters := current.counters[name]
+		info := InterfaceInfo{
+			Name:    name,
+			RxBytes: counters[0],
+			TxBytes: counters[1],
+		}
+		if prev, ok := lastNetSample.counters[name]; ok && elapsed > 0 {
+			info.RxRate = counterRate(prev[0], counters[0], elapsed)
+			info.TxRate = counterRate(prev[1], counters[1], elapsed)
+		}
+		net.Interfaces = append(net.Interfaces, info)
+	}
+
+	lastNetSample = &current
+
+	if iface != "" && len(net.Interfaces) == 0 {
+		return net, fmt.Errorf("找不到网络接口: %s", iface)
+	}
+	return net, nil
+}
+
+// counterRate 计算计数器的增长速率，计数器回绕或重置时返回0
+func counterRate(prev, cur uint64, seconds float64) float64 {
+	if cur < prev {
+		return 0
+	}
+	return float64(cur-prev) / seconds
 }
 
-func getDiskInfoLinux() (DiskInfo, error) {
-	file, err := os.Open("/proc/mounts")
+// readNetSampleLinux 读取 /proc/net/dev
+func readNetSampleLinux() (netSample, error) {
+	data, err := os.ReadFile("/proc/net/dev")
 	if err != nil {
-		return DiskInfo{}, err
+		return netSample{}, err
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		fields := strings.Fields(line)
-		if len(fields) >= 2 && fields[1] == "/" {
-			return getDiskUsage("/")
+	return netSample{
+		time:     time.Now(),
+		counters: parseNetDev(string(data)),
+	}, nil
+}
+
+// parseNetDev 解析 /proc/net/dev 内容
+// 前两行为表头，之后每行形如 "eth0: 接收字节 包数 ... 发送字节 ..."，接收8列、发送8列
+func parseNetDev(content string) map[string][2]uint64 {
+	counters := make(map[string][2]uint64)
+
+	for _, line := range strings.Split(content, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		fields := strings.Fields(parts[1])
+		if len(fields) < 9 {
+			continue
+		}
+
+		rx, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			continue
 		}
+		tx, err := strconv.ParseUint(fields[8], 10, 64)
+		if err != nil {
+			continue
+		}
+		counters[strings.TrimSpace(parts[0])] = [2]uint64{rx, tx}
 	}
 
-	return getDiskUsage("/")
+	return counters
 }
 
-func getDiskInfoWindows() (DiskInfo, error) {
-	return getDiskUsage("C:\\")
+// alertRule 一项指标的告警阈值
+type alertRule struct {
+	metric    string
+	threshold float64
+	value     func(info *SystemInfo) float64
 }
 
-func getDiskUsage(path string) (DiskInfo, error) {
-	disk := DiskInfo{}
+// alertMonitor 检查采样是否超过阈值，只在状态变化（超过/恢复）时输出
+type alertMonitor struct {
+	rules    []alertRule
+	breached map[string]bool
+	command  string
+	fired    int // 触发告警的次数
+}
+
+// newAlertMonitor 根据阈值创建告警检查器，阈值为0的指标不检查
+func newAlertMonitor(cpu, mem, disk float64, command string) *alertMonitor {
+	monitor := &alertMonitor{
+		breached: make(map[string]bool),
+		command:  command,
+	}
+
+	candidates := []alertRule{
+		{"cpu", cpu, func(info *SystemInfo) float64 { return info.CPU.Usage }},
+		{"memory", mem, func(info *SystemInfo) float64 { return info.Memory.Usage }},
+		{"disk", disk, func(info *SystemInfo) float64 { return info.Disk.Usage }},
+	}
+	for _, rule := range candidates {
+		if rule.threshold > 0 {
+			monitor.rules = append(monitor.rules, rule)
+		}
+	}
 
-	file, err := os.Open(path)
+	return monitor
+}
+
+// check 检查一次采样，告警信息输出到标准错误
+func (m *alertMonitor) check(info *SystemInfo) {
+	for _, rule := range m.rules {
+		value := rule.value(info)
+		over := value > rule.threshold
+
+		if over == m.breached[rule.metric] {
+			continue
+		}
+		m.breached[rule.metric] = over
+
+		timestamp := info.Timestamp.Format("2006-01-02 15:04:05")
+		if !over {
+			fmt.Fprintf(os.Stderr, "RECOVERED %s %s=%.2f%% 已恢复到阈值 %.2f%% 以下\n", timestamp, rule.metric, value, rule.threshold)
+			continue
+		}
+
+		m.fired++
+		fmt.Fprintf(os.Stderr, "ALERT %s %s=%.2f%% 超过阈值 %.2f%%\n", timestamp, rule.metric, value, rule.threshold)
+		if m.command != "" {
+			runAlertCommand(m.command, rule.metric, value, rule.threshold)
+		}
+	}
+}
+
+// runAlertCommand 执行告警命令，通过环境变量传递告警详情
+func runAlertCommand(command, metric string, value, threshold float64) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	cmd.Env = append(os.Environ(),
+		"ALERT_METRIC="+metric,
+		fmt.Sprintf("ALERT_VALUE=%.2f", value),
+		fmt.Sprintf("ALERT_THRESHOLD=%.2f", threshold),
+	)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "执行告警命令失败: %v\n", err)
+	}
+}
+
+// procSample 一次进程CPU时间采样
+type procSample struct {
+	cpuTotal uint64         // 同一时刻所有CPU的总时间(jiffies)
+	ticks    map[int]uint64 // pid -> 进程累计CPU时间(utime+stime)
+}
+
+// lastProcSample 上一次的进程采样，用于计算CPU占用
+var lastProcSample *procSample
+
+// getTopProcesses 获取CPU和内存占用最高的n个进程
+func getTopProcesses(n int) (ProcessList, error) {
+	var all []ProcessInfo
+	var err error
+
+	switch runtime.GOOS {
+	case "linux":
+		all, err = getProcessesLinux()
+	case "darwin":
+		all, err = getProcessesDarwin()
+	default:
+		return ProcessList{
+			TopCPU:    []ProcessInfo{},
+			TopMemory: []ProcessInfo{},
+			Note:      "当前平台不支持进程监控: " + runtime.GOOS,
+		}, nil
+	}
+	if err != nil {
+		return ProcessList{}, err
+	}
+
+	return ProcessList{
+		TopCPU:    topProcessesBy(all, n, func(a, b ProcessInfo) bool { return a.CPU > b.CPU }),
+		TopMemory: topProcessesBy(all, n, func(a, b ProcessInfo) bool { return a.RSS > b.RSS }),
+	}, nil
+}
+
+// topProcessesBy 按给定规则排序并返回前n个，相同时按pid排序
+func topProcessesBy(all []ProcessInfo, n int, greater func(a, b ProcessInfo) bool) []ProcessInfo {
+	sorted := make([]ProcessInfo, len(all))
+	copy(sorted, all)
+	sort.Slice(sorted, func(i, j int) bool {
+		if greater(sorted[i], sorted[j]) {
+			return true
+		}
+		if greater(sorted[j], sorted[i]) {
+			return false
+		}
+		return sorted[i].PID < sorted[j].PID
+	})
+
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// getProcessesLinux 读取 /proc/[pid]/stat 和 /proc/[pid]/status
+// CPU占用为两次采样之间进程CPU时间占整机CPU时间的比例，首次采样时额外等待一个采样间隔
+func getProcessesLinux() ([]ProcessInfo, error) {
+	if lastProcSample == nil {
+		first, _, err := readProcSampleLinux()
+		if err != nil {
+			return nil, err
+		}
+		lastProcSample = &first
+		time.Sleep(cpuSampleInterval)
+	}
+
+	current, processes, err := readProcSampleLinux()
+	if err != nil {
+		return nil, err
+	}
+
+	if current.cpuTotal > lastProcSample.cpuTotal {
+		totalDelta := float64(current.cpuTotal - lastProcSample.cpuTotal)
+		for i := range processes {
+			pid := processes[i].PID
+			prev, ok := lastProcSample.ticks[pid]
+			// 新出现的进程没有基准，按0处理
+			if ok && current.ticks[pid] >= prev {
+				processes[i].CPU = float64(current.ticks[pid]-prev) / totalDelta * 100
+			}
+		}
+	}
+
+	lastProcSample = &current
+	return processes, nil
+}
+
+// readProcSampleLinux 读取所有进程的CPU时间、名称和常驻内存
+func readProcSampleLinux() (procSample, []ProcessInfo, error) {
+	snapshot, err := readCPUSnapshotLinux()
+	if err != nil {
+		return procSample{}, nil, err
+	}
+
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return procSample{}, nil, err
+	}
+
+	sample := procSample{cpuTotal: snapshot.total.total, ticks: make(map[int]uint64)}
+	var processes []ProcessInfo
+
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		// 进程可能在读取过程中退出，读取失败时直接跳过
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			continue
+		}
+		name, ticks, err := parseProcPidStat(string(data))
+		if err != nil {
+			continue
+		}
+
+		sample.ticks[pid] = ticks
+		processes = append(processes, ProcessInfo{
+			PID:  pid,
+			Name: name,
+			RSS:  readProcRSS(pid),
+		})
+	}
+
+	return sample, processes, nil
+}
+
+// parseProcPidStat 解析 /proc/[pid]/stat，返回进程名和 utime+stime
+// 进程名在括号中且可能包含空格，因此从最后一个右括号之后开始按空格分割
+func parseProcPidStat(content string) (string, uint64, error) {
+	start := strings.Index(content, "(")
+	end := strings.LastIndex(content, ")")
+	if start < 0 || end < start {
+		return "", 0, fmt.Errorf("进程统计信息格式错误")
+	}
+
+	name := content[start+1 : end]
+	// 右括号之后依次为 state(3) ppid(4) ... utime(14) stime(15)
+	fields := strings.Fields(content[end+1:])
+	if len(fields) < 13 {
+		return "", 0, fmt.Errorf("进程统计信息格式错误")
+	}
+
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return "", 0, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return name, utime + stime, nil
+}
+
+// readProcRSS 从 /proc/[pid]/status 的 VmRSS 读取常驻内存，内核线程没有该项时返回0
+func readProcRSS(pid int) uint64 {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
 	if err != nil {
-		return disk, err
+		return 0
 	}
 	defer file.Close()
 
-	stat, err := file.Stat()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "VmRSS:" {
+			value, err := strconv.ParseUint(fields[1], 10, 64)
+			if err != nil {
+				return 0
+			}
+			return value * 1024
+		}
+	}
+	return 0
+}
+
+// getProcessesDarwin 通过 ps 获取进程列表，%cpu 按核心数折算为占整机的比例
+func getProcessesDarwin() ([]ProcessInfo, error) {
+	out, err := exec.Command("ps", "-A", "-o", "pid=,%cpu=,rss=,comm=").Output()
 	if err != nil {
-		return disk, err
+		return nil, err
 	}
 
-	if !stat.IsDir() {
-		return disk, fmt.Errorf("%s 不是目录", path)
+	var processes []ProcessInfo
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		cpu, _ := strconv.ParseFloat(strings.Replace(fields[1], ",", ".", 1), 64)
+		rss, _ := strconv.ParseUint(fields[2], 10, 64)
+
+		// comm 可能是包含空格的完整路径，只保留文件名
+		command := strings.Join(fields[3:], " ")
+		if idx := strings.LastIndex(command, "/"); idx >= 0 {
+			command = command[idx+1:]
+		}
+
+		processes = append(processes, ProcessInfo{
+			PID:  pid,
+			Name: command,
+			CPU:  cpu / float64(runtime.NumCPU()),
+			RSS:  rss * 1024,
+		})
 	}
 
-	return disk, fmt.Errorf("磁盘使用率监控需要系统调用支持")
+	return processes, nil
 }
 
 func getBasicSystemInfo() SysInfo {
@@ -370,7 +1172,17 @@ func getBasicSystemInfo() SysInfo {
 	}
 }
 
-func outputConsole(info *SystemInfo, file *os.File) {
+func outputConsole(info *SystemInfo, file *os.File, showPerCore bool, trends *usageTrends) {
+	perCoreText := ""
+	if showPerCore {
+		perCoreText = formatPerCore(info.CPU.PerCore)
+	}
+	cpuTrend, memTrend := "", ""
+	if trends != nil {
+		cpuTrend = "  " + sparkline(trends.cpu.values())
+		memTrend = "  " + sparkline(trends.mem.values())
+	}
+
 	output := fmt.Sprintf(`
 ========== 系统监控报告 ==========
 时间: %s
@@ -379,21 +1191,24 @@ func outputConsole(info *SystemInfo, file *os.File) {
 
 CPU 信息:
   核心数: %d
-  使用率: %.2f%%
-  负载平均值: %.2f
-
+  使用率: %.2f%%%s
+  负载平均值: %s
+%s
 内存信息:
   总内存: %s
   已用内存: %s
   可用内存: %s
-  使用率: %.2f%%
+  使用率: %.2f%%%s
+%s  交换分区: %s
 
-磁盘信息:
+磁盘信息 (%s):
   总容量: %s
   已用容量: %s
   可用容量: %s
   使用率: %.2f%%
 
+网络信息:
+%s%s
 ================================
 `,
 		info.Timestamp.Format("2006-01-02 15:04:05"),
@@ -402,15 +1217,23 @@ CPU 信息:
 		info.System.Arch,
 		info.CPU.Cores,
 		info.CPU.Usage,
-		info.CPU.LoadAvg,
+		cpuTrend,
+		formatLoadAverage(info.CPU.LoadAvg),
+		perCoreText,
 		formatBytes(info.Memory.Total),
 		formatBytes(info.Memory.Used),
 		formatBytes(info.Memory.Available),
 		info.Memory.Usage,
+		memTrend,
+		formatMemoryBreakdown(info.Memory),
+		formatSwap(info.Memory),
+		info.Disk.Path,
 		formatBytes(info.Disk.Total),
 		formatBytes(info.Disk.Used),
 		formatBytes(info.Disk.Available),
 		info.Disk.Usage,
+		formatNetwork(info.Network),
+		formatProcesses(info.Processes),
 	)
 
 	if file != nil {
@@ -420,6 +1243,179 @@ CPU 信息:
 	}
 }
 
+// ringBuffer 保存最近 N 个采样值，写满后覆盖最旧的值
+type ringBuffer struct {
+	data  []float64
+	next  int
+	count int
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{data: make([]float64, size)}
+}
+
+func (b *ringBuffer) add(v float64) {
+	b.data[b.next] = v
+	b.next = (b.next + 1) % len(b.data)
+	if b.count < len(b.data) {
+		b.count++
+	}
+}
+
+// values 按从旧到新的顺序返回缓冲区中的值
+func (b *ringBuffer) values() []float64 {
+	result := make([]float64, 0, b.count)
+	start := (b.next - b.count + len(b.data)) % len(b.data)
+	for i := 0; i < b.count; i++ {
+		result = append(result, b.data[(start+i)%len(b.data)])
+	}
+	return result
+}
+
+// usageTrends CPU和内存使用率的历史记录，为nil时不记录
+type usageTrends struct {
+	cpu *ringBuffer
+	mem *ringBuffer
+}
+
+func newUsageTrends(size int) *usageTrends {
+	return &usageTrends{cpu: newRingBuffer(size), mem: newRingBuffer(size)}
+}
+
+func (t *usageTrends) add(info *SystemInfo) {
+	if t == nil {
+		return
+	}
+	t.cpu.add(info.CPU.Usage)
+	t.mem.add(info.Memory.Usage)
+}
+
+// sparkBlocks 迷你图使用的8级方块字符
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline 把0~100的使用率映射为方块字符，固定刻度使不同时刻的图形可以直接比较
+func sparkline(values []float64) string {
+	var builder strings.Builder
+	for _, v := range values {
+		level := int(v / 100 * float64(len(sparkBlocks)))
+		if level < 0 {
+			level = 0
+		} else if level >= len(sparkBlocks) {
+			level = len(sparkBlocks) - 1
+		}
+		builder.WriteRune(sparkBlocks[level])
+	}
+	return builder.String()
+}
+
+// stdoutIsTerminal 判断标准输出是否为终端，重定向到文件或管道时不输出迷你图
+func stdoutIsTerminal() bool {
+	info, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// formatMemoryBreakdown 区分程序占用和缓存，仅在平台提供这些数据时输出
+func formatMemoryBreakdown(mem MemInfo) string {
+	if mem.Buffers == 0 && mem.Cached == 0 {
+		return ""
+	}
+	return fmt.Sprintf("  程序占用: %s\n  缓冲区: %s\n  缓存: %s\n",
+		formatBytes(mem.AppUsed), formatBytes(mem.Buffers), formatBytes(mem.Cached))
+}
+
+// formatSwap 显示交换分区使用情况
+func formatSwap(mem MemInfo) string {
+	if mem.SwapTotal == 0 {
+		return "未启用"
+	}
+	return fmt.Sprintf("%s / %s (%.2f%%)", formatBytes(mem.SwapUsed), formatBytes(mem.SwapTotal), mem.SwapUsage)
+}
+
+// formatNetwork 每个接口一行，显示当前速率和累计流量
+func formatNetwork(net NetInfo) string {
+	if len(net.Interfaces) == 0 {
+		return "  当前平台不支持\n"
+	}
+
+	var builder strings.Builder
+	for _, iface := range net.Interfaces {
+		builder.WriteString(fmt.Sprintf("  %-10s 接收: %s/s (累计 %s)  发送: %s/s (累计 %s)\n",
+			iface.Name,
+			formatBytes(uint64(iface.RxRate)), formatBytes(iface.RxBytes),
+			formatBytes(uint64(iface.TxRate)), formatBytes(iface.TxBytes)))
+	}
+	return builder.String()
+}
+
+// formatProcesses 以表格显示资源占用最高的进程，未启用时返回空字符串
+func formatProcesses(list *ProcessList) string {
+	if list == nil {
+		return ""
+	}
+	if list.Note != "" {
+		return "\n进程信息:\n  " + list.Note + "\n"
+	}
+
+	var builder strings.Builder
+	writeTable := func(title string, processes []ProcessInfo) {
+		builder.WriteString(fmt.Sprintf("\n%s:\n", title))
+		// 中文表头按显示宽度手动对齐
+		builder.WriteString("  PID      名称                      CPU       内存\n")
+		for _, p := range processes {
+			builder.WriteString(fmt.Sprintf("  %-8d %s %7.2f%% %10s\n", p.PID, fitWidth(p.Name, processNameWidth), p.CPU, formatBytes(p.RSS)))
+		}
+	}
+
+	writeTable(fmt.Sprintf("CPU占用最高的 %d 个进程", len(list.TopCPU)), list.TopCPU)
+	writeTable(fmt.Sprintf("内存占用最高的 %d 个进程", len(list.TopMemory)), list.TopMemory)
+	return builder.String()
+}
+
+// processNameWidth 进程表中名称列的显示宽度
+const processNameWidth = 20
+
+// fitWidth 按显示宽度截断并用空格补齐到 width 列，中文等宽字符按2列计算，
+// 按字符截断，不会切开多字节字符
+func fitWidth(text string, width int) string {
+	var builder strings.Builder
+	used := 0
+	for _, r := range text {
+		w := 1
+		if r >= 0x1100 {
+			w = 2
+		}
+		if used+w > width {
+			break
+		}
+		builder.WriteRune(r)
+		used += w
+	}
+	return builder.String() + strings.Repeat(" ", width-used)
+}
+
+// formatPerCore 每行显示4个核心的使用率
+func formatPerCore(perCore []float64) string {
+	if len(perCore) == 0 {
+		return "  各核心使用率: 当前平台不支持\n"
+	}
+
+	var builder strings.Builder
+	builder.WriteString("  各核心使用率:\n")
+	for i, usage := range perCore {
+		if i%4 == 0 {
+			builder.WriteString("   ")
+		}
+		builder.WriteString(fmt.Sprintf(" cpu%-3d %6.2f%%", i, usage))
+		if i%4 == 3 || i == len(perCore)-1 {
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
+}
+
 func outputJSON(info *SystemInfo, file *os.File) {
 	data, err := json.MarshalIndent(info, "", "  ")
 	if err != nil {
@@ -435,6 +1431,159 @@ func outputJSON(info *SystemInfo, file *os.File) {
 	}
 }
 
+// metricsServer HTTP服务模式，每次请求时采样一次
+// CPU、网络和进程的速率依赖上一次采样的全局状态，并发请求必须串行采样
+type metricsServer struct {
+	mu           sync.Mutex
+	diskPath     string
+	iface        string
+	topProcesses int
+}
+
+// runMetricsServer 启动HTTP服务：/metrics 输出Prometheus文本格式，/json 输出原始 SystemInfo
+func runMetricsServer(addr, diskPath, iface string, topProcesses int) error {
+	ms := &metricsServer{diskPath: diskPath, iface: iface, topProcesses: topProcesses}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", ms.handleMetrics)
+	mux.HandleFunc("/json", ms.handleJSON)
+
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	fmt.Printf("📡 指标服务已启动: http://%s\n", addr)
+	fmt.Println("  GET /metrics  Prometheus文本格式")
+	fmt.Println("  GET /json     JSON格式的原始数据")
+	return server.ListenAndServe()
+}
+
+// sample 加锁采样一次，保证并发请求不会同时修改上一次采样的状态
+func (ms *metricsServer) sample() (*SystemInfo, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	return getSystemInfo(ms.diskPath, ms.iface, ms.topProcesses)
+}
+
+func (ms *metricsServer) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	info, err := ms.sample()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writePrometheusMetrics(w, info)
+}
+
+func (ms *metricsServer) handleJSON(w http.ResponseWriter, r *http.Request) {
+	info, err := ms.sample()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(info)
+}
+
+// promWriter 按Prometheus文本格式输出指标，同名指标只输出一次 HELP/TYPE
+type promWriter struct {
+	w    io.Writer
+	seen map[string]bool
+}
+
+// metric 输出一个样本，labels 按 名称、值 成对给出
+func (p *promWriter) metric(name, typ, help string, value float64, labels ...string) {
+	if !p.seen[name] {
+		fmt.Fprintf(p.w, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(p.w, "# TYPE %s %s\n", name, typ)
+		p.seen[name] = true
+	}
+	var pairs []string
+	for i := 0; i+1 < len(labels); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", labels[i], escapeLabelValue(labels[i+1])))
+	}
+	if len(pairs) > 0 {
+		fmt.Fprintf(p.w, "%s{%s} %s\n", name, strings.Join(pairs, ","), strconv.FormatFloat(value, 'g', -1, 64))
+	} else {
+		fmt.Fprintf(p.w, "%s %s\n", name, strconv.FormatFloat(value, 'g', -1, 64))
+	}
+}
+
+// escapeLabelValue 转义标签值中的反斜杠、双引号和换行
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// writePrometheusMetrics 将一次采样转换为Prometheus指标
+func writePrometheusMetrics(w io.Writer, info *SystemInfo) {
+	p := &promWriter{w: w, seen: make(map[string]bool)}
+
+	p.metric("system_cpu_usage_percent", "gauge", "CPU总使用率", info.CPU.Usage)
+	for i, usage := range info.CPU.PerCore {
+		p.metric("system_cpu_core_usage_percent", "gauge", "各CPU核心使用率", usage, "core", strconv.Itoa(i))
+	}
+	p.metric("system_cpu_cores", "gauge", "CPU核心数", float64(info.CPU.Cores))
+	for i, period := range []string{"1m", "5m", "15m"} {
+		p.metric("system_load_average", "gauge", "负载平均值", info.CPU.LoadAvg[i], "period", period)
+	}
+
+	p.metric("system_memory_total_bytes", "gauge", "总内存", float64(info.Memory.Total))
+	p.metric("system_memory_available_bytes", "gauge", "可用内存", float64(info.Memory.Available))
+	p.metric("system_memory_used_bytes", "gauge", "已用内存", float64(info.Memory.Used))
+	p.metric("system_memory_usage_percent", "gauge", "内存使用率", info.Memory.Usage)
+	p.metric("system_swap_total_bytes", "gauge", "交换分区总大小", float64(info.Memory.SwapTotal))
+	p.metric("system_swap_used_bytes", "gauge", "已用交换分区", float64(info.Memory.SwapUsed))
+	p.metric("system_swap_usage_percent", "gauge", "交换分区使用率", info.Memory.SwapUsage)
+
+	disk := info.Disk
+	p.metric("system_disk_total_bytes", "gauge", "磁盘总容量", float64(disk.Total), "path", disk.Path)
+	p.metric("system_disk_used_bytes", "gauge", "磁盘已用容量", float64(disk.Used), "path", disk.Path)
+	p.metric("system_disk_available_bytes", "gauge", "磁盘可用容量", float64(disk.Available), "path", disk.Path)
+	p.metric("system_disk_usage_percent", "gauge", "磁盘使用率", disk.Usage, "path", disk.Path)
+
+	for _, nic := range info.Network.Interfaces {
+		p.metric("system_network_receive_bytes_total", "counter", "累计接收字节数", float64(nic.RxBytes), "interface", nic.Name)
+	}
+	for _, nic := range info.Network.Interfaces {
+		p.metric("system_network_transmit_bytes_total", "counter", "累计发送字节数", float64(nic.TxBytes), "interface", nic.Name)
+	}
+}
+
+// csvHeaderFields CSV输出的列，load_avg 为1分钟负载，5、15分钟负载追加在末尾以保持原有列的顺序
+var csvHeaderFields = []string{"timestamp", "cpu_usage", "mem_usage", "disk_usage", "load_avg", "load_avg_5", "load_avg_15"}
+
+// outputCSV 每次采样输出一行，writeHeader为true时先输出表头
+func outputCSV(info *SystemInfo, file *os.File, writeHeader bool) {
+	target := os.Stdout
+	if file != nil {
+		target = file
+	}
+
+	writer := csv.NewWriter(target)
+	if writeHeader {
+		writer.Write(csvHeaderFields)
+	}
+	writer.Write([]string{
+		info.Timestamp.Format(time.RFC3339),
+		strconv.FormatFloat(info.CPU.Usage, 'f', 2, 64),
+		strconv.FormatFloat(info.Memory.Usage, 'f', 2, 64),
+		strconv.FormatFloat(info.Disk.Usage, 'f', 2, 64),
+		strconv.FormatFloat(info.CPU.LoadAvg[0], 'f', 2, 64),
+		strconv.FormatFloat(info.CPU.LoadAvg[1], 'f', 2, 64),
+		strconv.FormatFloat(info.CPU.LoadAvg[2], 'f', 2, 64),
+	})
+	writer.Flush()
+
+	if err := writer.Error(); err != nil {
+		fmt.Printf("写入CSV失败: %v\n", err)
+	}
+}
+
 func formatBytes(bytes uint64) string {
 	const unit = 1024
 	if bytes < unit {
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"unicode/utf8"
+)
+
+// 两次 /proc/stat 采样，guest 列已包含在 user 中，不应重复计入
+const (
+	procStatBefore = `cpu  100 0 100 700 100 0 0 0 0 0
+cpu0 50 0 50 350 50 0 0 0 0 0
+cpu1 50 0 50 350 50 0 0 0 0 0
+intr 12345 0 0
+ctxt 67890
+`
+	procStatAfter = `cpu  300 0 200 1200 300 0 0 0 50 0
+cpu0 250 0 100 500 150 0 0 0 50 0
+cpu1 50 0 100 700 150 0 0 0 0 0
+intr 23456 0 0
+ctxt 78901
+`
+)
+
+func TestParseProcStat(t *testing.T) {
+	snapshot, err := parseProcStat(procStatBefore)
+	if err != nil {
+		t.Fatalf("parseProcStat 返回错误: %v", err)
+	}
+	if snapshot.total != (cpuSample{total: 1000, idle: 800}) {
+		t.Errorf("汇总采样 = %+v, 期望 {total:1000 idle:800}", snapshot.total)
+	}
+	if len(snapshot.cores) != 2 {
+		t.Fatalf("核心数 = %d, 期望 2", len(snapshot.cores))
+	}
+
+	if _, err := parseProcStat("intr 1 2 3\n"); err == nil {
+		t.Error("缺少 cpu 行时应返回错误")
+	}
+}
+
+func TestSampleCPUUsage(t *testing.T) {
+	before, err := parseProcStat(procStatBefore)
+	if err != nil {
+		t.Fatalf("解析第一次采样失败: %v", err)
+	}
+	after, err := parseProcStat(procStatAfter)
+	if err != nil {
+		t.Fatalf("解析第二次采样失败: %v", err)
+	}
+
+	snapshots := []cpuSnapshot{before, after}
+	read := func() (cpuSnapshot, error) {
+		s := snapshots[0]
+		snapshots = snapshots[1:]
+		return s, nil
+	}
+
+	saved := lastCPUSnapshot
+	lastCPUSnapshot = nil
+	defer func() { lastCPUSnapshot = saved }()
+
+	usage, perCore, err := sampleCPUUsage(read)
+	if err != nil {
+		t.Fatalf("sampleCPUUsage 返回错误: %v", err)
+	}
+	if math.Abs(usage-30) > 1e-9 {
+		t.Errorf("CPU总使用率 = %.2f%%, 期望 30%%", usage)
+	}
+	want := []float64{50, 10}
+	if len(perCore) != len(want) {
+		t.Fatalf("核心使用率个数 = %d, 期望 %d", len(perCore), len(want))
+	}
+	for i := range want {
+		if math.Abs(perCore[i]-want[i]) > 1e-9 {
+			t.Errorf("cpu%d 使用率 = %.2f%%, 期望 %.0f%%", i, perCore[i], want[i])
+		}
+	}
+}
+
+func TestCPUUsageBetweenCounterReset(t *testing.T) {
+	// 计数器回绕或重置时不应得到负数或超过100%的结果
+	prev := cpuSample{total: 2000, idle: 1500}
+	cur := cpuSample{total: 1000, idle: 800}
+	if got := cpuUsageBetween(prev, cur); got != 0 {
+		t.Errorf("计数器重置后使用率 = %v, 期望 0", got)
+	}
+}
+
+func TestFitWidth(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"sshd", "sshd                "},
+		{"a-very-long-process-name-here", "a-very-long-process-"},
+		{"微信", "微信                "},
+		// 10个中文字符正好20列
+		{"网易云音乐桌面客户端程序", "网易云音乐桌面客户端"},
+		// 剩余1列放不下宽字符时用空格补齐
+		{"abc网易云音乐桌面客户端", "abc网易云音乐桌面客 "},
+	}
+	for _, tt := range tests {
+		got := fitWidth(tt.name, processNameWidth)
+		if got != tt.want {
+			t.Errorf("fitWidth(%q) = %q, 期望 %q", tt.name, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("fitWidth(%q) 输出了无效的UTF-8: %q", tt.name, got)
+		}
+	}
+}
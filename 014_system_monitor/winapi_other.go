@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+import "fmt"
+
+// readCPUSampleWindows 非Windows平台不可用
+func readCPUSampleWindows() (cpuSample, error) {
+	return cpuSample{}, fmt.Errorf("仅支持Windows")
+}
+
+// getMemoryInfoWindows 非Windows平台不可用
+func getMemoryInfoWindows() (MemInfo, error) {
+	return MemInfo{}, fmt.Errorf("仅支持Windows")
+}
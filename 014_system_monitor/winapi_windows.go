@@ -0,0 +1,70 @@
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                 = syscall.NewLazyDLL("kernel32.dll")
+	procGetSystemTimes       = kernel32.NewProc("GetSystemTimes")
+	procGlobalMemoryStatusEx = kernel32.NewProc("GlobalMemoryStatusEx")
+)
+
+// memoryStatusEx 对应WinAPI的MEMORYSTATUSEX结构
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+// readCPUSampleWindows 通过GetSystemTimes读取CPU时间，内核时间中已包含空闲时间
+func readCPUSampleWindows() (cpuSample, error) {
+	var idle, kernel, user syscall.Filetime
+	ret, _, err := procGetSystemTimes.Call(
+		uintptr(unsafe.Pointer(&idle)),
+		uintptr(unsafe.Pointer(&kernel)),
+		uintptr(unsafe.Pointer(&user)),
+	)
+	if ret == 0 {
+		return cpuSample{}, err
+	}
+
+	return cpuSample{
+		total: filetimeToUint64(kernel) + filetimeToUint64(user),
+		idle:  filetimeToUint64(idle),
+	}, nil
+}
+
+// filetimeToUint64 将FILETIME转换为100纳秒为单位的整数
+func filetimeToUint64(ft syscall.Filetime) uint64 {
+	return uint64(ft.HighDateTime)<<32 | uint64(ft.LowDateTime)
+}
+
+// getMemoryInfoWindows 通过GlobalMemoryStatusEx获取物理内存使用情况
+func getMemoryInfoWindows() (MemInfo, error) {
+	status := memoryStatusEx{}
+	status.Length = uint32(unsafe.Sizeof(status))
+
+	ret, _, err := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status)))
+	if ret == 0 {
+		return MemInfo{}, err
+	}
+
+	mem := MemInfo{
+		Total:     status.TotalPhys,
+		Available: status.AvailPhys,
+		Used:      status.TotalPhys - status.AvailPhys,
+	}
+	if mem.Total > 0 {
+		mem.Usage = float64(mem.Used) / float64(mem.Total) * 100
+	}
+
+	return mem, nil
+}
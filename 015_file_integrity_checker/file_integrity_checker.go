@@ -4,14 +4,23 @@ import (
 	"crypto/md5"
 	"crypto/sha1"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"hash"
+	"hash/crc32"
 	"io"
+	"math/bits"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -23,26 +32,99 @@ type FileInfo struct {
 	MD5      string    `json:"md5,omitempty"`
 	SHA1     string    `json:"sha1,omitempty"`
 	SHA256   string    `json:"sha256,omitempty"`
+	CRC32    string    `json:"crc32,omitempty"`
+	BLAKE2b  string    `json:"blake2b,omitempty"`
 	IsDir    bool      `json:"is_dir"`
 	Checksum string    `json:"checksum,omitempty"`
+	Symlink  string    `json:"symlink,omitempty"` // -follow-symlinks 时记录符号链接指向的路径
 }
 
 type FileStats struct {
 	Timestamp  time.Time  `json:"timestamp"`
+	Root       string     `json:"root,omitempty"`
+	Algorithm  string     `json:"algorithm,omitempty"`
+	Recursive  bool       `json:"recursive,omitempty"`
+	FollowLink bool       `json:"follow_symlinks,omitempty"`
+	Exclude    []string   `json:"exclude,omitempty"`
+	MinSize    int64      `json:"min_size,omitempty"`
+	MaxSize    int64      `json:"max_size,omitempty"`
 	TotalFiles int        `json:"total_files"`
 	TotalDirs  int        `json:"total_dirs"`
 	TotalSize  int64      `json:"total_size"`
 	Files      []FileInfo `json:"files,omitempty"`
 }
 
+// CompareResult 两个目录的比较结果，路径均为相对各自根目录的路径
+type CompareResult struct {
+	DirA      string   `json:"dir_a"`
+	DirB      string   `json:"dir_b"`
+	Algorithm string   `json:"algorithm"`
+	Same      int      `json:"same"`
+	OnlyInA   []string `json:"only_in_a"`
+	OnlyInB   []string `json:"only_in_b"`
+	Different []string `json:"different"`
+}
+
+// HasDifferences 两个目录是否存在差异
+func (r *CompareResult) HasDifferences() bool {
+	return len(r.OnlyInA) > 0 || len(r.OnlyInB) > 0 || len(r.Different) > 0
+}
+
+// ScanOptions 扫描选项
+type ScanOptions struct {
+	Algos     []string
+	Recursive bool
+	Follow    bool // 跟随符号链接，计算链接目标的校验和并进入链接的目录
+	Workers   int
+	Exclude   []string // glob模式，匹配相对路径或文件名
+	MinSize   int64    // 小于该大小的文件跳过，0表示不限制
+	MaxSize   int64    // 大于该大小的文件跳过，0表示不限制
+	Progress  bool     // 计算校验和时在stderr输出进度
+}
+
+// BaselineDiff 当前目录与基线清单的差异，路径均为相对扫描根目录的路径
+type BaselineDiff struct {
+	Manifest     string    `json:"manifest"`
+	Root         string    `json:"root"`
+	BaselineTime time.Time `json:"baseline_time"`
+	Timestamp    time.Time `json:"timestamp"`
+	Added        []string  `json:"added"`
+	Removed      []string  `json:"removed"`
+	Modified     []string  `json:"modified"`
+}
+
+// HasDrift 是否存在任何差异
+func (d *BaselineDiff) HasDrift() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Modified) > 0
+}
+
+// VerifyResult 单个文件与期望校验和的比对结果
+type VerifyResult struct {
+	File      string `json:"file"`
+	Algorithm string `json:"algorithm"`
+	Expected  string `json:"expected"`
+	Actual    string `json:"actual"`
+	Match     bool   `json:"match"`
+}
+
 func main() {
 	var (
 		path      = flag.String("path", ".", "要检查的目录路径")
-		algo      = flag.String("algo", "sha256", "校验和算法: md5, sha1, sha256")
+		algo      = flag.String("algo", "sha256", "校验和算法: md5, sha1, sha256, crc32, blake2b，多个用逗号分隔")
 		recursive = flag.Bool("recursive", false, "递归检查子目录")
+		follow    = flag.Bool("follow-symlinks", false, "跟随符号链接，计算链接目标的校验和并进入链接的目录")
 		output    = flag.String("output", "console", "输出格式: console, json")
 		file      = flag.String("file", "", "保存结果到文件")
 		monitor   = flag.Duration("monitor", 0, "监控模式间隔 (如: 5s, 1m)")
+		workers   = flag.Int("workers", runtime.NumCPU(), "并发计算校验和的goroutine数量")
+		exclude   = flag.String("exclude", "", "排除的glob模式，多个用逗号分隔 (如: .git,*.mp4)")
+		minSize   = flag.String("min-size", "", "跳过小于该大小的文件 (如: 1K, 10MB)")
+		maxSize   = flag.String("max-size", "", "跳过大于该大小的文件 (如: 100M, 1G)")
+		changeLog = flag.String("log", "", "监控模式下以JSON Lines格式追加记录变化的文件")
+		compare   = flag.Bool("compare", false, "比较两个目录: -compare <目录A> <目录B>")
+		baseline  = flag.String("baseline", "", "基线模式: save 保存清单, verify 校验清单 (清单文件作为最后一个参数)")
+		verify    = flag.String("verify", "", "校验单个文件的校验和，需配合 -expected")
+		expected  = flag.String("expected", "", "-verify 使用的期望校验和 (不区分大小写)")
 		help      = flag.Bool("help", false, "显示帮助信息")
 	)
 	flag.Parse()
@@ -52,7 +134,143 @@ func main() {
 		return
 	}
 
-	stats, err := scanDirectory(*path, *algo, *recursive)
+	if *workers < 1 {
+		*workers = 1
+	}
+	algos, err := parseAlgorithms(*algo)
+	if err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		os.Exit(2)
+	}
+	// JSON 输出通常用于管道或脚本，不输出进度以免干扰
+	opts := ScanOptions{Algos: algos, Recursive: *recursive, Follow: *follow, Workers: *workers, Progress: *output != "json"}
+	if opts.Exclude, err = parseExcludePatterns(*exclude); err != nil {
+		fmt.Printf("参数错误: %v\n", err)
+		os.Exit(2)
+	}
+	if opts.MinSize, err = parseSize(*minSize); err != nil {
+		fmt.Printf("参数错误: -min-size %v\n", err)
+		os.Exit(2)
+	}
+	if opts.MaxSize, err = parseSize(*maxSize); err != nil {
+		fmt.Printf("参数错误: -max-size %v\n", err)
+		os.Exit(2)
+	}
+	if opts.MaxSize > 0 && opts.MinSize > opts.MaxSize {
+		fmt.Println("参数错误: -min-size 不能大于 -max-size")
+		os.Exit(2)
+	}
+
+	if *verify != "" {
+		if *expected == "" {
+			fmt.Println("用法: file_integrity_checker [-algo 算法] -verify <文件> -expected <校验和>")
+			os.Exit(2)
+		}
+		if len(algos) != 1 {
+			fmt.Println("参数错误: -verify 只能指定一种算法")
+			os.Exit(2)
+		}
+		result, err := verifyFile(*verify, algos[0], *expected)
+		if err != nil {
+			fmt.Printf("计算校验和失败: %v\n", err)
+			os.Exit(2)
+		}
+		outputVerify(result, *output, *file)
+		if !result.Match {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *compare {
+		if flag.NArg() != 2 {
+			fmt.Println("用法: file_integrity_checker [选项] -compare <目录A> <目录B>")
+			os.Exit(2)
+		}
+		result, err := compareDirectories(flag.Arg(0), flag.Arg(1), opts)
+		if err != nil {
+			fmt.Printf("比较目录失败: %v\n", err)
+			os.Exit(2)
+		}
+		outputCompare(result, *output, *file)
+		if result.HasDifferences() {
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *baseline != "" {
+		if flag.NArg() != 1 {
+			fmt.Println("用法: file_integrity_checker [选项] -baseline save|verify <清单文件>")
+			os.Exit(2)
+		}
+		setFlags := make(map[string]bool)
+		flag.Visit(func(f *flag.Flag) { setFlags[f.Name] = true })
+
+		switch *baseline {
+		case "save":
+			stats, err := scanDirectory(*path, opts)
+			if err != nil {
+				fmt.Printf("扫描目录失败: %v\n", err)
+				os.Exit(2)
+			}
+			if err := saveBaseline(stats, flag.Arg(0)); err != nil {
+				fmt.Printf("保存基线失败: %v\n", err)
+				os.Exit(2)
+			}
+			fmt.Printf("基线已保存到 %s (%d 个文件, 算法: %s)\n", flag.Arg(0), stats.TotalFiles, stats.Algorithm)
+		case "verify":
+			base, err := loadBaseline(flag.Arg(0))
+			if err != nil {
+				fmt.Printf("读取基线失败: %v\n", err)
+				os.Exit(2)
+			}
+			// 校验时默认沿用清单中的目录、递归和过滤设置，算法必须与清单一致
+			root := base.Root
+			if setFlags["path"] || root == "" {
+				root = *path
+			}
+			if !setFlags["recursive"] {
+				opts.Recursive = base.Recursive
+			}
+			if !setFlags["follow-symlinks"] {
+				opts.Follow = base.FollowLink
+			}
+			if !setFlags["exclude"] {
+				opts.Exclude = base.Exclude
+			}
+			if !setFlags["min-size"] {
+				opts.MinSize = base.MinSize
+			}
+			if !setFlags["max-size"] {
+				opts.MaxSize = base.MaxSize
+			}
+			if setFlags["algo"] && *algo != base.Algorithm {
+				fmt.Printf("注意: 清单使用 %s 算法，忽略 -algo %s\n", base.Algorithm, *algo)
+			}
+			if opts.Algos, err = parseAlgorithms(base.Algorithm); err != nil {
+				fmt.Printf("读取基线失败: %v\n", err)
+				os.Exit(2)
+			}
+			stats, err := scanDirectory(root, opts)
+			if err != nil {
+				fmt.Printf("扫描目录失败: %v\n", err)
+				os.Exit(2)
+			}
+			diff := compareStats(base, stats)
+			diff.Manifest = flag.Arg(0)
+			outputDiff(diff, *output, *file)
+			if diff.HasDrift() {
+				os.Exit(1)
+			}
+		default:
+			fmt.Printf("未知的基线操作: %s (可选: save, verify)\n", *baseline)
+			os.Exit(2)
+		}
+		return
+	}
+
+	stats, err := scanDirectory(*path, opts)
 	if err != nil {
 		fmt.Printf("扫描目录失败: %v\n", err)
 		return
@@ -61,99 +279,641 @@ func main() {
 	outputResults(stats, *output, *file)
 
 	if *monitor > 0 {
-		startMonitoring(*path, *algo, *recursive, *output, *file, *monitor)
+		startMonitoring(*path, opts, *output, *file, *changeLog, *monitor)
 	}
 }
 
-func scanDirectory(path, algo string, recursive bool) (*FileStats, error) {
+// scanDirectory 遍历目录收集文件列表，再由worker池并发计算校验和，结果按路径排序
+func scanDirectory(path string, opts ScanOptions) (*FileStats, error) {
 	stats := &FileStats{
-		Timestamp: time.Now(),
+		Timestamp:  time.Now(),
+		Root:       path,
+		Algorithm:  strings.Join(opts.Algos, ","),
+		Recursive:  opts.Recursive,
+		FollowLink: opts.Follow,
+		Exclude:    opts.Exclude,
+		MinSize:    opts.MinSize,
+		MaxSize:    opts.MaxSize,
 	}
 
-	err := filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+	// ancestors 为当前路径上已进入的目录，跟随链接时用 os.SameFile 比较（同一设备和inode）检测循环
+	var ancestors []os.FileInfo
+	var visit func(filePath string, info os.FileInfo) error
+	visit = func(filePath string, info os.FileInfo) error {
+		if filePath != path {
+			relPath, _ := filepath.Rel(path, filePath)
+			if !opts.Recursive && strings.Contains(relPath, string(filepath.Separator)) {
+				return nil
+			}
+			if isExcluded(relPath, opts.Exclude) {
+				// 被排除的目录整个跳过，不再遍历其内容
+				return nil
+			}
 		}
 
-		if !recursive && filePath != path {
-			relPath, _ := filepath.Rel(path, filePath)
-			if strings.Contains(relPath, string(filepath.Separator)) {
-				if info.IsDir() {
-					return filepath.SkipDir
-				}
+		link := ""
+		if info.Mode()&os.ModeSymlink != 0 && opts.Follow {
+			target, err := os.Stat(filePath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "跳过断开的符号链接: %s\n", filePath)
 				return nil
 			}
+			link, _ = os.Readlink(filePath)
+			info = target
+		}
+
+		if info.IsDir() {
+			for _, ancestor := range ancestors {
+				if os.SameFile(ancestor, info) {
+					fmt.Fprintf(os.Stderr, "跳过循环的符号链接: %s -> %s\n", filePath, link)
+					return nil
+				}
+			}
+			stats.TotalDirs++
+			entries, err := os.ReadDir(filePath)
+			if err != nil {
+				return err
+			}
+			ancestors = append(ancestors, info)
+			defer func() { ancestors = ancestors[:len(ancestors)-1] }()
+			for _, entry := range entries {
+				childPath := filepath.Join(filePath, entry.Name())
+				childInfo, err := os.Lstat(childPath)
+				if err != nil {
+					return err
+				}
+				if err := visit(childPath, childInfo); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if (opts.MinSize > 0 && info.Size() < opts.MinSize) || (opts.MaxSize > 0 && info.Size() > opts.MaxSize) {
+			return nil
 		}
 
-		fileInfo := FileInfo{
+		stats.TotalFiles++
+		stats.TotalSize += info.Size()
+		stats.Files = append(stats.Files, FileInfo{
 			Path:     filePath,
 			Size:     info.Size(),
 			Modified: info.ModTime(),
 			Mode:     info.Mode().String(),
-			IsDir:    info.IsDir(),
-		}
-
-		if !info.IsDir() {
-			checksum, err := calculateChecksum(filePath, algo)
-			if err == nil {
-				fileInfo.Checksum = checksum
-				switch algo {
-				case "md5":
-					fileInfo.MD5 = checksum
-				case "sha1":
-					fileInfo.SHA1 = checksum
-				case "sha256":
-					fileInfo.SHA256 = checksum
+			Symlink:  link,
+		})
+		return nil
+	}
+
+	rootInfo, err := os.Lstat(path)
+	if err == nil && opts.Follow {
+		// 根目录本身是符号链接时直接使用其指向的目录
+		rootInfo, err = os.Stat(path)
+	}
+	if err == nil {
+		err = visit(path, rootInfo)
+	}
+	if err != nil {
+		return stats, err
+	}
+
+	hashFiles(stats.Files, stats.TotalSize, opts)
+	sort.Slice(stats.Files, func(i, j int) bool {
+		return stats.Files[i].Path < stats.Files[j].Path
+	})
+	return stats, nil
+}
+
+// parseExcludePatterns 解析逗号分隔的排除模式并检查语法
+func parseExcludePatterns(list string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(list, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("无效的排除模式 %q: %v", pattern, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// isExcluded 判断相对路径是否匹配排除模式；不含 / 的模式同时匹配任意层级的文件名
+func isExcluded(relPath string, patterns []string) bool {
+	relPath = filepath.ToSlash(relPath)
+	base := filepath.Base(relPath)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(pattern, relPath); ok {
+			return true
+		}
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := filepath.Match(pattern, base); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseSize 解析带单位的大小，如 512、10K、1.5MB、2G（按1024进制），空字符串表示0
+func parseSize(input string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(input))
+	if value == "" {
+		return 0, nil
+	}
+	value = strings.TrimSuffix(value, "B")
+	multiplier := int64(1)
+	if n := len(value); n > 0 {
+		if idx := strings.IndexByte("KMGT", value[n-1]); idx >= 0 {
+			for i := 0; i <= idx; i++ {
+				multiplier *= 1024
+			}
+			value = value[:n-1]
+		}
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("无效的大小: %s", input)
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// hashFiles 使用 workers 个goroutine计算文件校验和，读取失败的文件校验和留空；
+// opts.Progress 为真时按 totalSize 在stderr显示进度
+func hashFiles(files []FileInfo, totalSize int64, opts ScanOptions) {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	var progress *hashProgress
+	if opts.Progress && len(files) > 0 {
+		progress = &hashProgress{totalFiles: len(files), totalBytes: totalSize, start: time.Now()}
+		stop := progress.run(500 * time.Millisecond)
+		defer stop()
+	}
+
+	jobs := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				sums, err := calculateChecksums(files[i].Path, opts.Algos, progress)
+				if progress != nil {
+					progress.files.Add(1)
+				}
+				if err != nil {
+					continue
+				}
+				mu.Lock()
+				fileInfo := &files[i]
+				fileInfo.Checksum = sums[opts.Algos[0]]
+				for _, algo := range opts.Algos {
+					setChecksum(fileInfo, algo, sums[algo])
 				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// hashProgress 记录校验和计算进度，由worker并发更新，定时输出到stderr
+type hashProgress struct {
+	totalFiles int
+	totalBytes int64
+	start      time.Time
+	files      atomic.Int64
+	bytes      atomic.Int64
+}
+
+// Write 作为 io.MultiWriter 的一路，只累计字节数
+func (p *hashProgress) Write(b []byte) (int, error) {
+	p.bytes.Add(int64(len(b)))
+	return len(b), nil
+}
+
+// run 启动定时输出进度的goroutine，返回的函数停止输出并清除进度行
+func (p *hashProgress) run(interval time.Duration) func() {
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		maxWidth := 0
+		for {
+			select {
+			case <-ticker.C:
+				// 新行比上一行短时补空格，覆盖残留字符
+				text := p.line()
+				if width := displayWidth(text); width < maxWidth {
+					text += strings.Repeat(" ", maxWidth-width)
+				} else {
+					maxWidth = width
+				}
+				fmt.Fprintf(os.Stderr, "\r%s", text)
+			case <-done:
+				// 用空格覆盖进度行，避免与后续输出混在一起
+				if maxWidth > 0 {
+					fmt.Fprintf(os.Stderr, "\r%s\r", strings.Repeat(" ", maxWidth))
+				}
+				return
 			}
 		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
 
-		if info.IsDir() {
-			stats.TotalDirs++
+// line 生成一行进度文本：文件数、百分比、已哈希字节数和预计剩余时间
+func (p *hashProgress) line() string {
+	files := p.files.Load()
+	bytes := p.bytes.Load()
+	percent := float64(files) / float64(p.totalFiles) * 100
+	text := fmt.Sprintf("进度: %d/%d 文件 (%.1f%%), 已哈希 %s / %s",
+		files, p.totalFiles, percent, formatBytes(bytes), formatBytes(p.totalBytes))
+
+	// 按字节吞吐量估算剩余时间，刚开始数据太少时不显示
+	elapsed := time.Since(p.start)
+	if bytes > 0 && p.totalBytes > bytes && elapsed >= time.Second {
+		remaining := time.Duration(float64(elapsed) * float64(p.totalBytes-bytes) / float64(bytes))
+		text += fmt.Sprintf(", 预计剩余 %v", remaining.Round(time.Second))
+	}
+	return text
+}
+
+// displayWidth 估算字符串在终端中的显示宽度，中文等宽字符按2列计算
+func displayWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		if r >= 0x1100 {
+			width += 2
 		} else {
-			stats.TotalFiles++
-			stats.TotalSize += info.Size()
-			stats.Files = append(stats.Files, fileInfo)
+			width++
 		}
+	}
+	return width
+}
 
-		return nil
-	})
+// saveBaseline 将扫描结果作为基线清单写入文件
+func saveBaseline(stats *FileStats, manifestPath string) error {
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return fmt.Errorf("JSON序列化失败: %v", err)
+	}
+	return os.WriteFile(manifestPath, append(data, '\n'), 0644)
+}
 
-	return stats, err
+// loadBaseline 读取基线清单
+func loadBaseline(manifestPath string) (*FileStats, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	var stats FileStats
+	if err := json.Unmarshal(data, &stats); err != nil {
+		return nil, fmt.Errorf("清单格式错误: %v", err)
+	}
+	if stats.Algorithm == "" {
+		return nil, fmt.Errorf("清单缺少算法信息")
+	}
+	return &stats, nil
 }
 
-func calculateChecksum(filePath, algo string) (string, error) {
-	file, err := os.Open(filePath)
+// relativePath 返回相对扫描根目录的路径，统一使用 / 分隔以便清单跨平台使用
+func relativePath(root, filePath string) string {
+	rel, err := filepath.Rel(root, filePath)
 	if err != nil {
-		return "", err
+		rel = filePath
 	}
-	defer file.Close()
+	return filepath.ToSlash(rel)
+}
+
+// compareStats 按相对路径和校验和比较基线与当前扫描结果
+func compareStats(baseline, current *FileStats) *BaselineDiff {
+	diff := &BaselineDiff{
+		Root:         current.Root,
+		BaselineTime: baseline.Timestamp,
+		Timestamp:    current.Timestamp,
+		Added:        []string{},
+		Removed:      []string{},
+		Modified:     []string{},
+	}
+
+	baseFiles := make(map[string]FileInfo)
+	for _, f := range baseline.Files {
+		baseFiles[relativePath(baseline.Root, f.Path)] = f
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range current.Files {
+		rel := relativePath(current.Root, f.Path)
+		seen[rel] = true
+		old, exists := baseFiles[rel]
+		switch {
+		case !exists:
+			diff.Added = append(diff.Added, rel)
+		case old.Checksum != f.Checksum:
+			diff.Modified = append(diff.Modified, rel)
+		}
+	}
+	for rel := range baseFiles {
+		if !seen[rel] {
+			diff.Removed = append(diff.Removed, rel)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+	return diff
+}
+
+// compareDirectories 分别扫描两个目录，以A为基准比较文件是否存在及校验和是否一致
+func compareDirectories(dirA, dirB string, opts ScanOptions) (*CompareResult, error) {
+	statsA, err := scanDirectory(dirA, opts)
+	if err != nil {
+		return nil, fmt.Errorf("扫描 %s 失败: %v", dirA, err)
+	}
+	statsB, err := scanDirectory(dirB, opts)
+	if err != nil {
+		return nil, fmt.Errorf("扫描 %s 失败: %v", dirB, err)
+	}
+
+	diff := compareStats(statsA, statsB)
+	return &CompareResult{
+		DirA:      dirA,
+		DirB:      dirB,
+		Algorithm: statsA.Algorithm,
+		Same:      statsA.TotalFiles - len(diff.Removed) - len(diff.Modified),
+		OnlyInA:   diff.Removed,
+		OnlyInB:   diff.Added,
+		Different: diff.Modified,
+	}, nil
+}
+
+// verifyFile 计算单个文件的校验和并与期望值比较，忽略期望值的首尾空白和大小写
+func verifyFile(filePath, algo, expected string) (*VerifyResult, error) {
+	actual, err := calculateChecksum(filePath, algo)
+	if err != nil {
+		return nil, err
+	}
+	expected = strings.TrimSpace(expected)
+	return &VerifyResult{
+		File:      filePath,
+		Algorithm: algo,
+		Expected:  expected,
+		Actual:    actual,
+		Match:     strings.EqualFold(actual, expected),
+	}, nil
+}
+
+// outputVerify 输出单文件校验结果
+func outputVerify(result *VerifyResult, output, filePath string) {
+	var out io.Writer = os.Stdout
+	if filePath != "" {
+		f, err := os.Create(filePath)
+		if err != nil {
+			fmt.Printf("创建输出文件失败: %v\n", err)
+			return
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Printf("JSON序列化失败: %v\n", err)
+			return
+		}
+		fmt.Fprintln(out, string(data))
+		return
+	}
+
+	if result.Match {
+		fmt.Fprintf(out, "✅ 校验通过: %s (%s)\n", result.File, result.Algorithm)
+		return
+	}
+	fmt.Fprintf(out, "❌ 校验失败: %s (%s)\n", result.File, result.Algorithm)
+	fmt.Fprintf(out, "  期望: %s\n", result.Expected)
+	fmt.Fprintf(out, "  实际: %s\n", result.Actual)
+}
 
-	var hash []byte
+// outputCompare 输出目录比较结果
+func outputCompare(result *CompareResult, output, filePath string) {
+	var out io.Writer = os.Stdout
+	if filePath != "" {
+		f, err := os.Create(filePath)
+		if err != nil {
+			fmt.Printf("创建输出文件失败: %v\n", err)
+			return
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fmt.Printf("JSON序列化失败: %v\n", err)
+			return
+		}
+		fmt.Fprintln(out, string(data))
+		return
+	}
+
+	fmt.Fprintf(out, "\n========== 目录比较报告 ==========\n")
+	fmt.Fprintf(out, "目录A: %s\n", result.DirA)
+	fmt.Fprintf(out, "目录B: %s\n", result.DirB)
+	fmt.Fprintf(out, "相同: %d  仅A中存在: %d  仅B中存在: %d  内容不同: %d\n",
+		result.Same, len(result.OnlyInA), len(result.OnlyInB), len(result.Different))
+	for _, p := range result.OnlyInA {
+		fmt.Fprintf(out, "  仅A中存在: %s\n", p)
+	}
+	for _, p := range result.OnlyInB {
+		fmt.Fprintf(out, "  仅B中存在: %s\n", p)
+	}
+	for _, p := range result.Different {
+		fmt.Fprintf(out, "  内容不同: %s\n", p)
+	}
+	if result.HasDifferences() {
+		fmt.Fprintln(out, "结果: 两个目录不一致")
+	} else {
+		fmt.Fprintln(out, "结果: 两个目录一致")
+	}
+	fmt.Fprintln(out, "================================")
+}
+
+// outputDiff 输出基线校验结果
+func outputDiff(diff *BaselineDiff, output, filePath string) {
+	var out io.Writer = os.Stdout
+	if filePath != "" {
+		f, err := os.Create(filePath)
+		if err != nil {
+			fmt.Printf("创建输出文件失败: %v\n", err)
+			return
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if output == "json" {
+		data, err := json.MarshalIndent(diff, "", "  ")
+		if err != nil {
+			fmt.Printf("JSON序列化失败: %v\n", err)
+			return
+		}
+		fmt.Fprintln(out, string(data))
+		return
+	}
+
+	fmt.Fprintf(out, "\n========== 基线校验报告 ==========\n")
+	fmt.Fprintf(out, "清单: %s (%s)\n", diff.Manifest, diff.BaselineTime.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(out, "目录: %s\n", diff.Root)
+	fmt.Fprintf(out, "新增: %d  删除: %d  修改: %d\n", len(diff.Added), len(diff.Removed), len(diff.Modified))
+	for _, p := range diff.Added {
+		fmt.Fprintf(out, "  新增: %s\n", p)
+	}
+	for _, p := range diff.Removed {
+		fmt.Fprintf(out, "  删除: %s\n", p)
+	}
+	for _, p := range diff.Modified {
+		fmt.Fprintf(out, "  修改: %s\n", p)
+	}
+	if diff.HasDrift() {
+		fmt.Fprintln(out, "结果: 检测到变化")
+	} else {
+		fmt.Fprintln(out, "结果: 与基线一致")
+	}
+	fmt.Fprintln(out, "================================")
+}
+
+// parseAlgorithms 解析逗号分隔的算法列表，去重并校验是否支持
+func parseAlgorithms(list string) ([]string, error) {
+	var algos []string
+	seen := make(map[string]bool)
+	for _, algo := range strings.Split(list, ",") {
+		algo = strings.ToLower(strings.TrimSpace(algo))
+		if algo == "" || seen[algo] {
+			continue
+		}
+		if _, err := newHasher(algo); err != nil {
+			return nil, err
+		}
+		seen[algo] = true
+		algos = append(algos, algo)
+	}
+	if len(algos) == 0 {
+		return nil, fmt.Errorf("未指定校验和算法")
+	}
+	return algos, nil
+}
+
+// newHasher 根据算法名称创建哈希对象
+func newHasher(algo string) (hash.Hash, error) {
 	switch algo {
 	case "md5":
-		h := md5.New()
-		if _, err := io.Copy(h, file); err != nil {
-			return "", err
-		}
-		hash = h.Sum(nil)
+		return md5.New(), nil
 	case "sha1":
-		h := sha1.New()
-		if _, err := io.Copy(h, file); err != nil {
-			return "", err
-		}
-		hash = h.Sum(nil)
+		return sha1.New(), nil
 	case "sha256":
-		h := sha256.New()
-		if _, err := io.Copy(h, file); err != nil {
-			return "", err
-		}
-		hash = h.Sum(nil)
+		return sha256.New(), nil
+	case "crc32":
+		return crc32.NewIEEE(), nil
+	case "blake2b":
+		return newBlake2b(), nil
 	default:
-		return "", fmt.Errorf("不支持的算法: %s", algo)
+		return nil, fmt.Errorf("不支持的算法: %s", algo)
+	}
+}
+
+// setChecksum 将校验和写入对应算法的字段
+func setChecksum(fileInfo *FileInfo, algo, checksum string) {
+	switch algo {
+	case "md5":
+		fileInfo.MD5 = checksum
+	case "sha1":
+		fileInfo.SHA1 = checksum
+	case "sha256":
+		fileInfo.SHA256 = checksum
+	case "crc32":
+		fileInfo.CRC32 = checksum
+	case "blake2b":
+		fileInfo.BLAKE2b = checksum
+	}
+}
+
+// getChecksum 读取对应算法的校验和字段
+func getChecksum(fileInfo FileInfo, algo string) string {
+	switch algo {
+	case "md5":
+		return fileInfo.MD5
+	case "sha1":
+		return fileInfo.SHA1
+	case "sha256":
+		return fileInfo.SHA256
+	case "crc32":
+		return fileInfo.CRC32
+	case "blake2b":
+		return fileInfo.BLAKE2b
+	}
+	return ""
+}
+
+func calculateChecksum(filePath, algo string) (string, error) {
+	sums, err := calculateChecksums(filePath, []string{algo}, nil)
+	if err != nil {
+		return "", err
+	}
+	return sums[algo], nil
+}
+
+// calculateChecksums 只读取一次文件，通过 io.MultiWriter 同时计算多个算法的校验和，
+// progress 不为 nil 时同时累计已读取的字节数
+func calculateChecksums(filePath string, algos []string, progress *hashProgress) (map[string]string, error) {
+	hashers := make([]hash.Hash, len(algos))
+	writers := make([]io.Writer, len(algos))
+	for i, algo := range algos {
+		h, err := newHasher(algo)
+		if err != nil {
+			return nil, err
+		}
+		hashers[i] = h
+		writers[i] = h
+	}
+	if progress != nil {
+		writers = append(writers, progress)
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(io.MultiWriter(writers...), file); err != nil {
+		return nil, err
 	}
 
-	return hex.EncodeToString(hash), nil
+	sums := make(map[string]string, len(algos))
+	for i, algo := range algos {
+		sums[algo] = hex.EncodeToString(hashers[i].Sum(nil))
+	}
+	return sums, nil
 }
 
 func outputResults(stats *FileStats, output, filePath string) {
@@ -193,12 +953,21 @@ func outputConsole(stats *FileStats, file *os.File) {
 		formatBytes(stats.TotalSize),
 	)
 
+	algos := strings.Split(stats.Algorithm, ",")
 	for _, fileInfo := range stats.Files {
-		output += fmt.Sprintf("%-50s %10s %s\n",
-			filepath.Base(fileInfo.Path),
-			formatBytes(fileInfo.Size),
-			fileInfo.Checksum,
-		)
+		if len(algos) <= 1 {
+			output += fmt.Sprintf("%-50s %10s %s\n",
+				displayName(fileInfo),
+				formatBytes(fileInfo.Size),
+				fileInfo.Checksum,
+			)
+			continue
+		}
+		// 多算法时每个校验和单独一行并标注算法名
+		output += fmt.Sprintf("%-50s %10s\n", displayName(fileInfo), formatBytes(fileInfo.Size))
+		for _, algo := range algos {
+			output += fmt.Sprintf("  %-7s %s\n", algo+":", getChecksum(fileInfo, algo))
+		}
 	}
 
 	output += "================================\n"
@@ -210,6 +979,15 @@ func outputConsole(stats *FileStats, file *os.File) {
 	}
 }
 
+// displayName 控制台中显示的文件名，跟随的符号链接标注其指向
+func displayName(fileInfo FileInfo) string {
+	name := filepath.Base(fileInfo.Path)
+	if fileInfo.Symlink != "" {
+		name += " -> " + fileInfo.Symlink
+	}
+	return name
+}
+
 func outputJSON(stats *FileStats, file *os.File) {
 	data, err := json.MarshalIndent(stats, "", "  ")
 	if err != nil {
@@ -225,14 +1003,114 @@ func outputJSON(stats *FileStats, file *os.File) {
 	}
 }
 
-func startMonitoring(path, algo string, recursive bool, output, filePath string, interval time.Duration) {
+// ChangeEvent 监控模式下检测到的一次变化，写入 -log 文件时每行一个JSON对象
+type ChangeEvent struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Path        string    `json:"path"`
+	ChangeType  string    `json:"change_type"`
+	OldChecksum string    `json:"old_checksum"`
+	NewChecksum string    `json:"new_checksum"`
+	OldMode     string    `json:"old_mode,omitempty"`
+	NewMode     string    `json:"new_mode,omitempty"`
+}
+
+// 变化类型
+const (
+	ChangeAdded    = "added"
+	ChangeRemoved  = "removed"
+	ChangeModified = "modified"
+	ChangeSize     = "size_changed"
+	ChangeChecksum = "checksum_changed"
+	ChangeMode     = "mode_changed"
+)
+
+// changeLabels 控制台输出中各变化类型的名称
+var changeLabels = map[string]string{
+	ChangeAdded:    "新增",
+	ChangeRemoved:  "删除",
+	ChangeModified: "修改",
+	ChangeSize:     "大小变化",
+	ChangeChecksum: "校验和变化",
+	ChangeMode:     "权限变化",
+}
+
+// detectChanges 比较两次扫描结果，按路径排序返回变化列表
+func detectChanges(previous, current map[string]FileInfo, now time.Time) []ChangeEvent {
+	var events []ChangeEvent
+
+	for path, currentFile := range current {
+		prevFile, exists := previous[path]
+		if !exists {
+			events = append(events, ChangeEvent{Timestamp: now, Path: path, ChangeType: ChangeAdded,
+				NewChecksum: currentFile.Checksum, NewMode: currentFile.Mode})
+			continue
+		}
+		event := ChangeEvent{Timestamp: now, Path: path,
+			OldChecksum: prevFile.Checksum, NewChecksum: currentFile.Checksum}
+		if !currentFile.Modified.Equal(prevFile.Modified) {
+			event.ChangeType = ChangeModified
+			events = append(events, event)
+		}
+		if currentFile.Size != prevFile.Size {
+			event.ChangeType = ChangeSize
+			events = append(events, event)
+		}
+		if currentFile.Checksum != prevFile.Checksum {
+			event.ChangeType = ChangeChecksum
+			events = append(events, event)
+		}
+		if currentFile.Mode != prevFile.Mode {
+			event.ChangeType = ChangeMode
+			event.OldMode, event.NewMode = prevFile.Mode, currentFile.Mode
+			events = append(events, event)
+		}
+	}
+
+	for path, prevFile := range previous {
+		if _, exists := current[path]; !exists {
+			events = append(events, ChangeEvent{Timestamp: now, Path: path, ChangeType: ChangeRemoved,
+				OldChecksum: prevFile.Checksum, OldMode: prevFile.Mode})
+		}
+	}
+
+	// 同一文件的多个事件保持检测顺序
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Path < events[j].Path
+	})
+	return events
+}
+
+// appendChangeLog 以JSON Lines格式追加变化记录
+func appendChangeLog(logPath string, events []ChangeEvent) error {
+	f, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	encoder := json.NewEncoder(f)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func startMonitoring(path string, opts ScanOptions, output, filePath, logPath string, interval time.Duration) {
 	fmt.Printf("开始监控模式，间隔: %v\n", interval)
+	if logPath != "" {
+		fmt.Printf("变化记录写入: %s\n", logPath)
+	}
 	fmt.Printf("按 Ctrl+C 停止监控\n\n")
+	// 周期性重新扫描时不输出进度，避免刷屏
+	opts.Progress = false
 
 	previousStats := make(map[string]FileInfo)
+	firstScan := true
 
 	for {
-		stats, err := scanDirectory(path, algo, recursive)
+		stats, err := scanDirectory(path, opts)
 		if err != nil {
 			fmt.Printf("扫描失败: %v\n", err)
 			time.Sleep(interval)
@@ -244,39 +1122,29 @@ func startMonitoring(path, algo string, recursive bool, output, filePath string,
 			currentFiles[file.Path] = file
 		}
 
-		var changes []string
-
-		for path, currentFile := range currentFiles {
-			if prevFile, exists := previousStats[path]; exists {
-				if currentFile.Modified != prevFile.Modified {
-					changes = append(changes, fmt.Sprintf("修改: %s", path))
-				}
-				if currentFile.Size != prevFile.Size {
-					changes = append(changes, fmt.Sprintf("大小变化: %s", path))
-				}
-				if currentFile.Checksum != prevFile.Checksum {
-					changes = append(changes, fmt.Sprintf("校验和变化: %s", path))
-				}
-			} else {
-				changes = append(changes, fmt.Sprintf("新增: %s", path))
-			}
-		}
-
-		for path := range previousStats {
-			if _, exists := currentFiles[path]; !exists {
-				changes = append(changes, fmt.Sprintf("删除: %s", path))
-			}
-		}
+		changes := detectChanges(previousStats, currentFiles, time.Now())
 
 		if len(changes) > 0 {
 			fmt.Printf("[%s] 检测到变化:\n", time.Now().Format("15:04:05"))
 			for _, change := range changes {
-				fmt.Printf("  %s\n", change)
+				if change.ChangeType == ChangeMode {
+					fmt.Printf("  %s: %s (%s -> %s)\n", changeLabels[change.ChangeType], change.Path, change.OldMode, change.NewMode)
+				} else {
+					fmt.Printf("  %s: %s\n", changeLabels[change.ChangeType], change.Path)
+				}
 			}
 			fmt.Println()
+
+			// 首次扫描只是建立基准，不写入审计日志
+			if logPath != "" && !firstScan {
+				if err := appendChangeLog(logPath, changes); err != nil {
+					fmt.Printf("写入变化记录失败: %v\n", err)
+				}
+			}
 		}
 
 		previousStats = currentFiles
+		firstScan = false
 		time.Sleep(interval)
 	}
 }
@@ -302,17 +1170,170 @@ func showHelp() {
 	fmt.Println()
 	fmt.Println("选项:")
 	fmt.Println("  -path string        要检查的目录路径 (默认: .)")
-	fmt.Println("  -algo string        校验和算法: md5, sha1, sha256 (默认: sha256)")
+	fmt.Println("  -algo string        校验和算法，多个用逗号分隔 (默认: sha256)")
+	fmt.Println("                        md5     速度快，已不抗碰撞，仅用于兼容")
+	fmt.Println("                        sha1    已不抗碰撞，仅用于兼容")
+	fmt.Println("                        sha256  安全性高，适合防篡改校验")
+	fmt.Println("                        crc32   最快，只能发现意外损坏，不具备安全性")
+	fmt.Println("                        blake2b BLAKE2b-512，安全性与SHA-256相当，无SHA硬件加速时通常更快")
 	fmt.Println("  -recursive          递归检查子目录")
 	fmt.Println("  -output string      输出格式: console, json (默认: console)")
 	fmt.Println("  -file string        保存结果到文件")
 	fmt.Println("  -monitor duration   监控模式间隔 (如: 5s, 1m)")
+	fmt.Println("  -workers int        并发计算校验和的goroutine数量 (默认: CPU核心数)")
+	fmt.Println("  -exclude string     排除的glob模式，多个用逗号分隔 (如: .git,*.mp4)")
+	fmt.Println("  -min-size string    跳过小于该大小的文件 (如: 1K, 10MB)")
+	fmt.Println("  -max-size string    跳过大于该大小的文件 (如: 100M, 1G)")
+	fmt.Println("  -log string         监控模式下以JSON Lines格式追加记录变化")
+	fmt.Println("  -compare            比较两个目录，目录A和目录B放在最后")
+	fmt.Println("  -baseline string    基线模式: save 保存清单, verify 校验清单 (清单文件放在最后)")
+	fmt.Println("  -verify string      校验单个文件，需配合 -expected 指定期望校验和")
+	fmt.Println("  -expected string    期望的校验和，不区分大小写")
 	fmt.Println("  -help               显示此帮助信息")
 	fmt.Println()
 	fmt.Println("示例:")
 	fmt.Println("  file_integrity_checker                            # 检查当前目录")
 	fmt.Println("  file_integrity_checker -path /tmp -algo md5       # 检查/tmp目录使用MD5")
 	fmt.Println("  file_integrity_checker -recursive -output json    # 递归检查并输出JSON")
+	fmt.Println("  file_integrity_checker -algo md5,sha256           # 一次读取同时计算MD5和SHA256")
+	fmt.Println("  file_integrity_checker -recursive -exclude .git,node_modules -max-size 100M  # 排除目录和大文件")
 	fmt.Println("  file_integrity_checker -monitor 30s              # 每30秒监控一次")
+	fmt.Println("  file_integrity_checker -monitor 1m -log audit.jsonl  # 监控并记录变化日志")
 	fmt.Println("  file_integrity_checker -file result.json          # 保存结果到文件")
+	fmt.Println("  file_integrity_checker -recursive -baseline save manifest.json  # 保存基线")
+	fmt.Println("  file_integrity_checker -baseline verify manifest.json           # 校验基线，有变化时退出码为1")
+	fmt.Println("  file_integrity_checker -recursive -compare /data /backup/data   # 比较源目录和备份")
+	fmt.Println("  file_integrity_checker -algo sha256 -verify go.tar.gz -expected 3F2A...  # 核对下载文件")
+}
+
+// BLAKE2b-512 (RFC 7693) 的纯Go实现，标准库未提供该算法
+
+const (
+	blake2bBlockSize = 128
+	blake2bSize      = 64
+)
+
+var blake2bIV = [8]uint64{
+	0x6a09e667f3bcc908, 0xbb67ae8584caa73b, 0x3c6ef372fe94f82b, 0xa54ff53a5f1d36f1,
+	0x510e527fade682d1, 0x9b05688c2b3e6c1f, 0x1f83d9abfb41bd6b, 0x5be0cd19137e2179,
+}
+
+var blake2bSigma = [12][16]byte{
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3},
+	{11, 8, 12, 0, 5, 2, 15, 13, 10, 14, 3, 6, 7, 1, 9, 4},
+	{7, 9, 3, 1, 13, 12, 11, 14, 2, 6, 5, 10, 4, 0, 15, 8},
+	{9, 0, 5, 7, 2, 4, 10, 15, 14, 1, 11, 12, 6, 8, 3, 13},
+	{2, 12, 6, 10, 0, 11, 8, 3, 4, 13, 7, 5, 15, 14, 1, 9},
+	{12, 5, 1, 15, 14, 13, 4, 10, 0, 7, 6, 3, 9, 2, 8, 11},
+	{13, 11, 7, 14, 12, 1, 3, 9, 5, 0, 15, 4, 8, 6, 2, 10},
+	{6, 15, 14, 9, 11, 3, 0, 8, 12, 2, 13, 7, 1, 4, 10, 5},
+	{10, 2, 8, 4, 7, 6, 1, 5, 15, 11, 9, 14, 3, 12, 13, 0},
+	{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15},
+	{14, 10, 4, 8, 9, 15, 13, 6, 1, 12, 0, 2, 11, 7, 5, 3},
+}
+
+// blake2bDigest 实现 hash.Hash 接口
+type blake2bDigest struct {
+	h   [8]uint64
+	t   [2]uint64 // 128位已处理字节计数
+	buf [blake2bBlockSize]byte
+	n   int
+}
+
+func newBlake2b() hash.Hash {
+	d := &blake2bDigest{}
+	d.Reset()
+	return d
+}
+
+func (d *blake2bDigest) Size() int      { return blake2bSize }
+func (d *blake2bDigest) BlockSize() int { return blake2bBlockSize }
+
+func (d *blake2bDigest) Reset() {
+	d.h = blake2bIV
+	// 参数块: 摘要长度64字节，无密钥，fanout=1，depth=1
+	d.h[0] ^= 0x01010000 ^ blake2bSize
+	d.t = [2]uint64{}
+	d.n = 0
+}
+
+func (d *blake2bDigest) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 {
+		// 最后一个块需要带结束标记压缩，所以缓冲区满且还有数据时才压缩
+		if d.n == blake2bBlockSize {
+			d.addCounter(blake2bBlockSize)
+			d.compress(false)
+			d.n = 0
+		}
+		copied := copy(d.buf[d.n:], p)
+		d.n += copied
+		p = p[copied:]
+	}
+	return written, nil
+}
+
+func (d *blake2bDigest) Sum(in []byte) []byte {
+	final := *d
+	final.addCounter(uint64(final.n))
+	for i := final.n; i < blake2bBlockSize; i++ {
+		final.buf[i] = 0
+	}
+	final.compress(true)
+
+	var out [blake2bSize]byte
+	for i, v := range final.h {
+		binary.LittleEndian.PutUint64(out[i*8:], v)
+	}
+	return append(in, out[:]...)
+}
+
+func (d *blake2bDigest) addCounter(n uint64) {
+	d.t[0] += n
+	if d.t[0] < n {
+		d.t[1]++
+	}
+}
+
+func (d *blake2bDigest) compress(last bool) {
+	var m [16]uint64
+	for i := range m {
+		m[i] = binary.LittleEndian.Uint64(d.buf[i*8:])
+	}
+
+	var v [16]uint64
+	copy(v[:8], d.h[:])
+	copy(v[8:], blake2bIV[:])
+	v[12] ^= d.t[0]
+	v[13] ^= d.t[1]
+	if last {
+		v[14] = ^v[14]
+	}
+
+	g := func(a, b, c, e int, x, y uint64) {
+		v[a] += v[b] + x
+		v[e] = bits.RotateLeft64(v[e]^v[a], -32)
+		v[c] += v[e]
+		v[b] = bits.RotateLeft64(v[b]^v[c], -24)
+		v[a] += v[b] + y
+		v[e] = bits.RotateLeft64(v[e]^v[a], -16)
+		v[c] += v[e]
+		v[b] = bits.RotateLeft64(v[b]^v[c], -63)
+	}
+
+	for _, s := range blake2bSigma {
+		g(0, 4, 8, 12, m[s[0]], m[s[1]])
+		g(1, 5, 9, 13, m[s[2]], m[s[3]])
+		g(2, 6, 10, 14, m[s[4]], m[s[5]])
+		g(3, 7, 11, 15, m[s[6]], m[s[7]])
+		g(0, 5, 10, 15, m[s[8]], m[s[9]])
+		g(1, 6, 11, 12, m[s[10]], m[s[11]])
+		g(2, 7, 8, 13, m[s[12]], m[s[13]])
+		g(3, 4, 9, 14, m[s[14]], m[s[15]])
+	}
+
+	for i := range d.h {
+		d.h[i] ^= v[i] ^ v[i+8]
+	}
 }
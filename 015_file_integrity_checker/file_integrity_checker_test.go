@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// "hello\n" 的 sha256
+const helloSHA256 = "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03"
+
+func TestVerifyFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "hello.txt")
+	if err := os.WriteFile(file, []byte("hello\n"), 0644); err != nil {
+		t.Fatalf("写入测试文件失败: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+		match    bool
+	}{
+		{"一致", helloSHA256, true},
+		{"大写并带空白", "  5891B5B522D5DF086D0FF0B110FBD9D21BB4FC7163AF34D08286A2E846F6BE03\n", true},
+		{"不一致", "0000000000000000000000000000000000000000000000000000000000000000", false},
+	}
+	for _, tt := range tests {
+		result, err := verifyFile(file, "sha256", tt.expected)
+		if err != nil {
+			t.Errorf("%s: verifyFile 返回错误: %v", tt.name, err)
+			continue
+		}
+		if result.Match != tt.match {
+			t.Errorf("%s: Match = %v, 期望 %v", tt.name, result.Match, tt.match)
+		}
+		if result.Actual != helloSHA256 {
+			t.Errorf("%s: Actual = %s, 期望 %s", tt.name, result.Actual, helloSHA256)
+		}
+	}
+}
+
+func TestVerifyFileMissing(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing.txt")
+	if result, err := verifyFile(missing, "sha256", helloSHA256); err == nil {
+		t.Errorf("文件不存在时应返回错误, 实际结果: %+v", result)
+	}
+}
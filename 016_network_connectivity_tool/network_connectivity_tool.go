@@ -1,14 +1,26 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"math"
 	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
@@ -20,8 +32,37 @@ type ConnectivityResult struct {
 	Latency   time.Duration `json:"latency"`
 	Error     string        `json:"error,omitempty"`
 	Type      string        `json:"type"` // "ping", "tcp", "udp"
+	State     string        `json:"state,omitempty"`
+	Banner    string        `json:"banner,omitempty"` // scan 模式 -banner 抓取的服务标识
+
+	// HTTP 模式
+	StatusCode int        `json:"status_code,omitempty"`
+	FinalURL   string     `json:"final_url,omitempty"`
+	CertExpiry *time.Time `json:"cert_expiry,omitempty"`
+	BodyMatch  *bool      `json:"body_match,omitempty"`
+
+	// -retries 大于1时的多次探测汇总，此时 Latency 为平均延迟
+	Retry *RetryStats `json:"retry,omitempty"`
+}
+
+// RetryStats 同一端口多次探测的汇总
+type RetryStats struct {
+	Attempts     int           `json:"attempts"`
+	Successes    int           `json:"successes"`
+	SuccessRatio float64       `json:"success_ratio"`
+	MinLatency   time.Duration `json:"min_latency"`
+	AvgLatency   time.Duration `json:"avg_latency"`
+	MaxLatency   time.Duration `json:"max_latency"`
 }
 
+// UDP探测状态
+const (
+	StateOpen         = "open"
+	StateClosed       = "closed"
+	StateFiltered     = "filtered"
+	StateOpenFiltered = "open|filtered"
+)
+
 type ScanResult struct {
 	Timestamp   time.Time            `json:"timestamp"`
 	Host        string               `json:"host"`
@@ -32,21 +73,108 @@ type ScanResult struct {
 }
 
 type NetworkTool struct {
-	host    string
-	ports   []int
-	timeout time.Duration
-	output  string
-	file    string
-	mode    string
-	threads int
-	verbose bool
+	host      string
+	ports     []int
+	timeout   time.Duration
+	output    string
+	file      string
+	mode      string
+	threads   int
+	verbose   bool
+	expect    string
+	count     int
+	interval  time.Duration
+	dnsServer string
+	maxHops   int
+	retries   int
+	family    string // "4"、"6" 或空（自动选择）
+	banner    bool   // scan 模式下抓取开放端口的服务标识
+
+	// portSlots 端口探测的并发槽位，多主机时所有主机共享，保证同时进行的连接不超过 -threads
+	portSlots chan struct{}
+}
+
+// dialTimeout 建立连接，测试中可替换以统计并发连接数
+var dialTimeout = net.DialTimeout
+
+// portSemaphore 返回端口探测使用的并发槽位，未设置共享槽位时按 -threads 新建
+func (nt *NetworkTool) portSemaphore() chan struct{} {
+	if nt.portSlots != nil {
+		return nt.portSlots
+	}
+	return make(chan struct{}, nt.threads)
+}
+
+// network 根据 -4/-6 返回带地址族后缀的网络类型，如 tcp4、udp6、ip4
+func (nt *NetworkTool) network(proto string) string {
+	return proto + nt.family
+}
+
+// DNSResult DNS 模式的解析结果
+type DNSResult struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Host      string        `json:"host"`
+	Resolver  string        `json:"resolver"`
+	Success   bool          `json:"success"`
+	Status    string        `json:"status"` // NOERROR, NXDOMAIN, TIMEOUT, ERROR
+	Latency   time.Duration `json:"latency"`
+	A         []string      `json:"a,omitempty"`
+	AAAA      []string      `json:"aaaa,omitempty"`
+	CNAME     string        `json:"cname,omitempty"`
+	MX        []MXRecord    `json:"mx,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// TraceResult 路由跟踪结果
+type TraceResult struct {
+	Timestamp time.Time  `json:"timestamp"`
+	Host      string     `json:"host"`
+	Target    string     `json:"target"`
+	Reached   bool       `json:"reached"`
+	Hops      []TraceHop `json:"hops"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// TraceHop 路由跟踪中的一跳，无应答时 Address 为空
+type TraceHop struct {
+	TTL     int           `json:"ttl"`
+	Address string        `json:"address,omitempty"`
+	RTT     time.Duration `json:"rtt,omitempty"`
+}
+
+// MXRecord 邮件交换记录
+type MXRecord struct {
+	Host string `json:"host"`
+	Pref uint16 `json:"pref"`
+}
+
+// ProbeStats 持续监控模式下单个目标的汇总统计，延迟只统计成功的探测
+type ProbeStats struct {
+	Target      string        `json:"target"`
+	Sent        int           `json:"sent"`
+	Received    int           `json:"received"`
+	LossPercent float64       `json:"loss_percent"`
+	MinLatency  time.Duration `json:"min_latency"`
+	AvgLatency  time.Duration `json:"avg_latency"`
+	MaxLatency  time.Duration `json:"max_latency"`
+	StdDev      time.Duration `json:"stddev"`
+}
+
+// MonitorReport 持续监控模式的完整结果
+type MonitorReport struct {
+	Timestamp time.Time            `json:"timestamp"`
+	Host      string               `json:"host"`
+	Mode      string               `json:"mode"`
+	Results   []ConnectivityResult `json:"results"`
+	Summary   []ProbeStats         `json:"summary"`
 }
 
 func main() {
 	tool := &NetworkTool{}
 
-	flag.StringVar(&tool.host, "host", "8.8.8.8", "目标主机地址")
-	flag.StringVar(&tool.mode, "mode", "ping", "检测模式: ping, tcp, udp, scan")
+	flag.StringVar(&tool.host, "host", "8.8.8.8", "目标主机地址，多个用逗号分隔")
+	hostsFile := flag.String("hosts-file", "", "主机列表文件，每行一个主机或 host:port")
+	flag.StringVar(&tool.mode, "mode", "ping", "检测模式: ping, tcp, udp, scan, http, dns, trace")
 	portsStr := flag.String("ports", "80,443,22,21,25,53,110,993,995", "端口列表(逗号分隔)")
 	portRange := flag.String("range", "", "端口范围(如: 1-1000)")
 	flag.DurationVar(&tool.timeout, "timeout", 3*time.Second, "连接超时时间")
@@ -54,6 +182,17 @@ func main() {
 	flag.StringVar(&tool.file, "file", "", "保存结果到文件")
 	flag.IntVar(&tool.threads, "threads", 50, "并发线程数")
 	flag.BoolVar(&tool.verbose, "verbose", false, "详细输出")
+	flag.StringVar(&tool.expect, "expect", "", "HTTP 模式下响应内容必须包含的字符串")
+	flag.StringVar(&tool.dnsServer, "dns-server", "", "DNS 模式使用的DNS服务器(如: 8.8.8.8 或 1.1.1.1:53)，默认使用系统配置")
+	flag.IntVar(&tool.maxHops, "max-hops", 30, "trace 模式的最大跳数")
+	flag.IntVar(&tool.retries, "retries", 1, "tcp/udp/scan 模式下每个端口的探测次数")
+	flag.IntVar(&tool.count, "count", 1, "探测次数，-1 表示持续探测直到 Ctrl+C")
+	flag.DurationVar(&tool.interval, "interval", time.Second, "持续探测的间隔")
+	flag.BoolVar(&tool.banner, "banner", false, "scan 模式下抓取开放端口的服务标识")
+	saveDir := flag.String("save", "", "将结果以JSON保存到该目录下带时间戳的文件中")
+	diffFile := flag.String("diff", "", "与之前保存的JSON结果对比，报告端口和可达性变化")
+	ipv4Only := flag.Bool("4", false, "只使用IPv4")
+	ipv6Only := flag.Bool("6", false, "只使用IPv6")
 	help := flag.Bool("help", false, "显示帮助信息")
 
 	flag.Parse()
@@ -70,25 +209,352 @@ func main() {
 		tool.ports = parsePorts(*portsStr)
 	}
 
-	switch tool.mode {
+	if !validModes[tool.mode] {
+		fmt.Printf("错误: 不支持的模式 '%s'\n", tool.mode)
+		os.Exit(1)
+	}
+	if *ipv4Only && *ipv6Only {
+		fmt.Println("错误: -4 和 -6 不能同时使用")
+		os.Exit(1)
+	}
+	if *ipv4Only {
+		tool.family = "4"
+	} else if *ipv6Only {
+		tool.family = "6"
+	}
+	if tool.banner && tool.mode != "scan" {
+		fmt.Println("错误: -banner 只能用于 scan 模式")
+		os.Exit(1)
+	}
+	if tool.threads < 1 {
+		fmt.Println("错误: -threads 必须大于0")
+		os.Exit(1)
+	}
+	if tool.retries < 1 {
+		fmt.Println("错误: -retries 必须大于0")
+		os.Exit(1)
+	}
+	if tool.maxHops < 1 || tool.maxHops > 255 {
+		fmt.Println("错误: -max-hops 必须在 1~255 之间")
+		os.Exit(1)
+	}
+	if tool.count == 0 || tool.count < -1 {
+		fmt.Println("错误: -count 必须为正数或 -1")
+		os.Exit(1)
+	}
+
+	// 收集目标主机: -host 逗号列表和 -hosts-file，只指定 -hosts-file 时不使用 -host 的默认值
+	hostSet := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "host" {
+			hostSet = true
+		}
+	})
+	var hosts []string
+	if hostSet || *hostsFile == "" {
+		hosts = splitHosts(tool.host)
+	}
+	if *hostsFile != "" {
+		fileHosts, err := readHostsFile(*hostsFile)
+		if err != nil {
+			fmt.Printf("错误: 读取主机列表失败: %v\n", err)
+			os.Exit(1)
+		}
+		hosts = append(hosts, fileHosts...)
+	}
+	if len(hosts) == 0 {
+		fmt.Println("错误: 没有指定目标主机")
+		os.Exit(1)
+	}
+	// 在复制到各主机之前创建，使所有主机共享端口探测的并发限制
+	tool.portSlots = make(chan struct{}, tool.threads)
+	tools := make([]*NetworkTool, len(hosts))
+	for i, host := range hosts {
+		tools[i] = tool.forHost(host)
+	}
+
+	if (*saveDir != "" || *diffFile != "") && (tool.count != 1 || len(tools) > 1) {
+		fmt.Println("错误: -save 和 -diff 只支持单个主机的单次检测")
+		os.Exit(1)
+	}
+
+	if tool.count != 1 {
+		if tool.mode == "scan" || tool.mode == "dns" || tool.mode == "trace" {
+			fmt.Printf("错误: %s 模式不支持 -count\n", tool.mode)
+			os.Exit(1)
+		}
+		tool.runMonitor(tools)
+		return
+	}
+
+	if len(tools) > 1 {
+		tool.runMultiHost(tools)
+		return
+	}
+
+	single := tools[0]
+
+	// 先读取对比文件，格式或主机不符时不必执行检测
+	var previous interface{}
+	if *diffFile != "" {
+		var err error
+		previous, err = loadPreviousResult(*diffFile, single.mode)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		// http 模式的结果中记录的是补全协议后的URL，比较前按同样的规则处理
+		currentHost := single.host
+		if single.mode == "http" {
+			currentHost = httpTarget(currentHost)
+		}
+		if prevHost := snapshotOf(previous).host; !strings.EqualFold(prevHost, currentHost) {
+			fmt.Printf("错误: 对比文件中的主机 %s 与当前主机 %s 不一致\n", prevHost, currentHost)
+			os.Exit(1)
+		}
+	}
+
+	result := single.runMode()
+	if *saveDir != "" {
+		path, err := saveResult(*saveDir, single, result)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			os.Exit(1)
+		}
+		// 写到标准错误，避免混入标准输出的JSON
+		fmt.Fprintf(os.Stderr, "结果已保存到: %s\n", path)
+	}
+
+	if previous == nil {
+		single.outputModeResult(result)
+		return
+	}
+	diff := diffResults(previous, result, single.mode)
+	if single.output == "json" {
+		single.outputJSON(struct {
+			Result interface{} `json:"result"`
+			Diff   ResultDiff  `json:"diff"`
+		}{result, diff})
+		return
+	}
+	single.outputModeResult(result)
+	fmt.Println()
+	outputConsoleDiff(diff)
+}
+
+// validModes 支持的检测模式
+var validModes = map[string]bool{
+	"ping": true, "tcp": true, "udp": true, "scan": true, "http": true, "dns": true, "trace": true,
+}
+
+// HostReport 多主机模式下单个主机的结果，Result 的结构与对应模式单独运行时相同
+type HostReport struct {
+	Host      string      `json:"host"`
+	Reachable bool        `json:"reachable"`
+	Result    interface{} `json:"result"`
+}
+
+// MultiHostReport 多主机模式的汇总报告
+type MultiHostReport struct {
+	Timestamp   time.Time    `json:"timestamp"`
+	Mode        string       `json:"mode"`
+	TotalHosts  int          `json:"total_hosts"`
+	Reachable   int          `json:"reachable"`
+	Unreachable int          `json:"unreachable"`
+	Hosts       []HostReport `json:"hosts"`
+}
+
+// splitHosts 解析逗号分隔的主机列表
+func splitHosts(list string) []string {
+	var hosts []string
+	for _, host := range strings.Split(list, ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// readHostsFile 读取主机列表文件，每行一个主机或 host:port，忽略空行和 # 注释
+func readHostsFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var hosts []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			hosts = append(hosts, line)
+		}
+	}
+	return hosts, nil
+}
+
+// forHost 复制当前配置并设置目标主机；host:port 形式的端口覆盖 -ports（http 模式的URL不拆分）
+func (nt *NetworkTool) forHost(entry string) *NetworkTool {
+	clone := *nt
+	clone.host = entry
+	if nt.mode == "http" || strings.Contains(entry, "://") {
+		return &clone
+	}
+	if host, portStr, err := net.SplitHostPort(entry); err == nil {
+		clone.host = host
+		if port, err := strconv.Atoi(portStr); err == nil && port > 0 && port <= 65535 {
+			clone.ports = []int{port}
+		}
+	} else if strings.HasPrefix(entry, "[") && strings.HasSuffix(entry, "]") {
+		// 不带端口的 [IPv6] 写法，去掉方括号后再由 JoinHostPort 统一处理
+		clone.host = entry[1 : len(entry)-1]
+	}
+	return &clone
+}
+
+// runMode 按当前模式执行一次检测并返回结果
+func (nt *NetworkTool) runMode() interface{} {
+	switch nt.mode {
 	case "ping":
-		result := tool.pingHost()
-		tool.outputResult(result)
+		return nt.pingHost()
 	case "tcp":
-		results := tool.testTCPPorts()
-		tool.outputResults(results)
+		return nt.testTCPPorts()
 	case "udp":
-		results := tool.testUDPPorts()
-		tool.outputResults(results)
+		return nt.testUDPPorts()
 	case "scan":
-		scanResult := tool.scanPorts()
-		tool.outputScanResult(scanResult)
+		return nt.scanPorts()
+	case "http":
+		return nt.checkHTTP()
+	case "trace":
+		return nt.traceRoute()
 	default:
-		fmt.Printf("错误: 不支持的模式 '%s'\n", tool.mode)
-		os.Exit(1)
+		return nt.lookupDNS()
+	}
+}
+
+// outputModeResult 按结果类型选择对应的输出方式
+func (nt *NetworkTool) outputModeResult(result interface{}) {
+	switch r := result.(type) {
+	case ConnectivityResult:
+		nt.outputResult(r)
+	case []ConnectivityResult:
+		nt.outputResults(r)
+	case ScanResult:
+		nt.outputScanResult(r)
+	case DNSResult:
+		nt.outputDNSResult(r)
+	case TraceResult:
+		nt.outputTraceResult(r)
 	}
 }
 
+// isReachable 判断检测结果是否表示主机可达
+func isReachable(result interface{}) bool {
+	switch r := result.(type) {
+	case ConnectivityResult:
+		return r.Success
+	case []ConnectivityResult:
+		for _, item := range r {
+			if item.Success {
+				return true
+			}
+		}
+	case ScanResult:
+		return len(r.OpenPorts) > 0
+	case DNSResult:
+		return r.Success
+	case TraceResult:
+		return r.Reached
+	}
+	return false
+}
+
+// runMultiHost 最多同时检测 -threads 个主机，各主机的端口探测共享同一组 -threads 个并发槽位，
+// 按输入顺序输出报告
+func (nt *NetworkTool) runMultiHost(tools []*NetworkTool) {
+	report := MultiHostReport{
+		Timestamp:  time.Now(),
+		Mode:       nt.mode,
+		TotalHosts: len(tools),
+		Hosts:      make([]HostReport, len(tools)),
+	}
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, nt.threads)
+	for i, t := range tools {
+		wg.Add(1)
+		go func(i int, t *NetworkTool) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			result := t.runMode()
+			report.Hosts[i] = HostReport{Host: t.host, Reachable: isReachable(result), Result: result}
+		}(i, t)
+	}
+	wg.Wait()
+
+	for _, host := range report.Hosts {
+		if host.Reachable {
+			report.Reachable++
+		}
+	}
+	report.Unreachable = report.TotalHosts - report.Reachable
+
+	if nt.output == "json" {
+		nt.outputJSON(report)
+		return
+	}
+	if nt.mode == "ping" {
+		outputPingTable(report)
+		return
+	}
+	for i, host := range report.Hosts {
+		tools[i].outputModeResult(host.Result)
+		fmt.Println()
+	}
+	fmt.Printf("可达主机: %d/%d\n", report.Reachable, report.TotalHosts)
+	for _, host := range report.Hosts {
+		if !host.Reachable {
+			fmt.Printf("  ❌ %s\n", host.Host)
+		}
+	}
+}
+
+// outputPingTable 多主机 ping 的汇总表：可达主机按延迟从低到高排列，不可达主机按输入顺序排在最后
+func outputPingTable(report MultiHostReport) {
+	rows := make([]ConnectivityResult, 0, len(report.Hosts))
+	hostWidth := 0
+	for _, host := range report.Hosts {
+		result := host.Result.(ConnectivityResult)
+		result.Host = host.Host
+		rows = append(rows, result)
+		if len(host.Host) > hostWidth {
+			hostWidth = len(host.Host)
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Success != rows[j].Success {
+			return rows[i].Success
+		}
+		return rows[i].Success && rows[i].Latency < rows[j].Latency
+	})
+
+	fmt.Println("========== 多主机 PING 结果 ==========")
+	fmt.Printf("时间: %s\n", report.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Println()
+	for _, r := range rows {
+		if r.Success {
+			fmt.Printf("✅ %-*s  %v\n", hostWidth, r.Host, r.Latency.Round(time.Microsecond))
+		} else {
+			fmt.Printf("❌ %-*s  %s\n", hostWidth, r.Host, r.Error)
+		}
+	}
+	fmt.Println()
+	fmt.Printf("可达: %d  不可达: %d  共 %d 个主机\n", report.Reachable, report.Unreachable, report.TotalHosts)
+	fmt.Println("=====================================")
+}
+
 func showHelp() {
 	fmt.Println(`网络连通性测试工具 (016)
 
@@ -96,197 +562,1082 @@ func showHelp() {
   network_connectivity_tool [选项]
 
 选项:
-  -host string        目标主机地址 (默认: "8.8.8.8")
-  -mode string        检测模式: ping, tcp, udp, scan (默认: "ping")
+  -host string        目标主机地址，多个用逗号分隔 (默认: "8.8.8.8")
+  -hosts-file string  主机列表文件，每行一个主机或 host:port
+  -mode string        检测模式: ping, tcp, udp, scan, http, dns, trace (默认: "ping")
   -ports string       端口列表，逗号分隔 (默认: "80,443,22,21,25,53,110,993,995")
   -range string       端口范围，如: 1-1000
   -timeout duration   连接超时时间 (默认: 3s)
   -output string      输出格式: console, json (默认: "console")
   -file string        保存结果到文件
-  -threads int        并发线程数 (默认: 50)
+  -threads int        并发线程数，端口探测连接数上限（多主机共享）及同时检测的主机数 (默认: 50)
   -verbose            详细输出
+  -expect string      HTTP 模式下响应内容必须包含的字符串
+  -dns-server string  DNS 模式使用的DNS服务器，默认使用系统配置
+  -max-hops int       trace 模式的最大跳数 (默认: 30)
+  -retries int        tcp/udp/scan 模式下每个端口的探测次数 (默认: 1)
+  -count int          探测次数，-1 表示持续探测直到 Ctrl+C (默认: 1)
+  -interval duration  持续探测的间隔 (默认: 1s)
+  -banner             scan 模式下抓取开放端口的服务标识 (HTTP/SSH/SMTP 等)
+  -save string        将结果以JSON保存到该目录下带时间戳的文件中
+  -diff string        与之前保存的JSON结果对比 (ping/tcp/udp/scan/http 模式)
+  -4                  只使用IPv4
+  -6                  只使用IPv6
   -help               显示此帮助信息
 
 示例:
   # PING 测试
   network_connectivity_tool -host google.com -mode ping
 
+  # 每2秒 ping 一次，共10次，最后输出丢包率和延迟统计
+  network_connectivity_tool -host google.com -count 10 -interval 2s
+
+  # 持续探测 443 端口，Ctrl+C 结束后输出统计
+  network_connectivity_tool -host example.com -mode tcp -ports 443 -count -1
+
   # TCP 端口测试
   network_connectivity_tool -host example.com -mode tcp -ports 80,443,22
 
+  # 每个端口探测3次，统计成功率和延迟
+  network_connectivity_tool -host example.com -mode tcp -ports 80,443 -retries 3
+
   # 端口扫描
   network_connectivity_tool -host 192.168.1.1 -mode scan -range 1-1000
 
+  # 端口扫描并识别开放端口上的服务
+  network_connectivity_tool -host 192.168.1.1 -mode scan -range 1-1024 -banner
+
+  # HTTP 健康检查，要求响应内容包含 "ok"
+  network_connectivity_tool -mode http -host https://example.com/health -expect ok
+
+  # DNS 解析，使用指定的DNS服务器
+  network_connectivity_tool -mode dns -host example.com -dns-server 1.1.1.1
+
+  # 批量检测主机列表文件中的主机
+  network_connectivity_tool -hosts-file hosts.txt -mode tcp -ports 22
+
+  # 路由跟踪 (需要 root/管理员权限)
+  sudo network_connectivity_tool -mode trace -host example.com -max-hops 20
+
+  # 分别通过 IPv4 和 IPv6 测试双栈主机的 443 端口
+  network_connectivity_tool -host example.com -mode tcp -ports 443 -4
+  network_connectivity_tool -host example.com -mode tcp -ports 443 -6
+
   # UDP 测试
   network_connectivity_tool -host 8.8.8.8 -mode udp -ports 53,123
 
-  # JSON 输出并保存到文件
-  network_connectivity_tool -host example.com -mode scan -output json -file result.json`)
+  # JSON 输出并保存到文件
+  network_connectivity_tool -host example.com -mode scan -output json -file result.json
+
+  # 保存本次扫描结果，并与上次保存的结果对比
+  network_connectivity_tool -host 192.168.1.1 -mode scan -range 1-1024 -save scans -diff scans/192.168.1.1_scan_20240101_020000.json`)
+}
+
+// icmpSeq ICMP回显请求序号，每次发送递增
+var icmpSeq uint32
+
+// icmpFallbackOnce 无原始套接字权限时只提示一次
+var icmpFallbackOnce sync.Once
+
+// pingHost 发送ICMP回显请求并以应答的往返时间作为延迟；
+// 没有原始套接字权限（非root/管理员）时退回TCP连接测试
+func (nt *NetworkTool) pingHost() ConnectivityResult {
+	result := ConnectivityResult{
+		Timestamp: time.Now(),
+		Host:      nt.host,
+		Type:      "ping",
+	}
+
+	dst, err := net.ResolveIPAddr(nt.network("ip"), nt.host)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	conn, err := listenICMP(dst)
+	if err != nil {
+		icmpFallbackOnce.Do(func() {
+			fmt.Fprintf(os.Stderr, "提示: 无法创建ICMP原始套接字(%v)，改用TCP连接测试\n", err)
+		})
+		return nt.pingHostTCP()
+	}
+	defer conn.Close()
+
+	seq := int(atomic.AddUint32(&icmpSeq, 1) & 0xffff)
+	reply, err := icmpEcho(conn, dst, os.Getpid()&0xffff, seq, nt.timeout)
+	if err != nil {
+		result.Latency = nt.timeout
+		result.Error = err.Error()
+		return result
+	}
+	result.Latency = reply.RTT
+	switch reply.Type {
+	case icmpEchoReply:
+		result.Success = true
+	case icmpDestUnreachable:
+		result.Error = fmt.Sprintf("目标不可达 (来自 %s, code %d)", reply.Peer, reply.Code)
+	case icmpTimeExceeded:
+		result.Error = fmt.Sprintf("TTL 超时 (来自 %s)", reply.Peer)
+	}
+	return result
+}
+
+// ICMP 报文类型，ICMPv6 的应答会被转换为对应的 ICMPv4 类型
+const (
+	icmpEchoReply       = 0
+	icmpDestUnreachable = 3
+	icmpEchoRequest     = 8
+	icmpTimeExceeded    = 11
+
+	icmpv6DestUnreachable = 1
+	icmpv6TimeExceeded    = 3
+	icmpv6EchoRequest     = 128
+	icmpv6EchoReply       = 129
+)
+
+// listenICMP 按目标地址族创建ICMP原始套接字
+func listenICMP(dst *net.IPAddr) (net.PacketConn, error) {
+	if dst.IP.To4() == nil {
+		return net.ListenPacket("ip6:ipv6-icmp", "::")
+	}
+	return net.ListenPacket("ip4:icmp", "0.0.0.0")
+}
+
+// icmpReply 与某个回显请求匹配的ICMP应答或差错报文
+type icmpReply struct {
+	RTT  time.Duration
+	Peer net.Addr
+	Type int
+	Code int
+}
+
+// icmpEcho 发送一个ICMP回显请求，等待匹配标识符和序号的应答或差错报文，超时返回错误
+func icmpEcho(conn net.PacketConn, dst *net.IPAddr, id, seq int, timeout time.Duration) (*icmpReply, error) {
+	v6 := dst.IP.To4() == nil
+	requestType := byte(icmpEchoRequest)
+	if v6 {
+		requestType = icmpv6EchoRequest
+	}
+
+	msg := make([]byte, 16)
+	msg[0] = requestType
+	binary.BigEndian.PutUint16(msg[4:], uint16(id))
+	binary.BigEndian.PutUint16(msg[6:], uint16(seq))
+	binary.BigEndian.PutUint64(msg[8:], uint64(time.Now().UnixNano()))
+	if !v6 {
+		// ICMPv6 校验和包含伪首部，由内核计算
+		binary.BigEndian.PutUint16(msg[2:], icmpChecksum(msg))
+	}
+
+	start := time.Now()
+	if err := conn.SetDeadline(start.Add(timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteTo(msg, dst); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				return nil, fmt.Errorf("请求超时")
+			}
+			return nil, err
+		}
+		reply := buf[:n]
+		if len(reply) < 8 {
+			continue
+		}
+
+		msgType := int(reply[0])
+		if v6 {
+			switch msgType {
+			case icmpv6EchoReply:
+				msgType = icmpEchoReply
+			case icmpv6DestUnreachable:
+				msgType = icmpDestUnreachable
+			case icmpv6TimeExceeded:
+				msgType = icmpTimeExceeded
+			default:
+				continue
+			}
+		}
+
+		switch msgType {
+		case icmpEchoReply:
+			if peer.String() != dst.String() || !matchEcho(reply, id, seq) {
+				continue
+			}
+		case icmpDestUnreachable, icmpTimeExceeded:
+			// 差错报文的载荷中包含原始IP头（IPv6为固定40字节）和ICMP头
+			inner := reply[8:]
+			headerLen := 40
+			if !v6 {
+				if len(inner) < 20 {
+					continue
+				}
+				headerLen = int(inner[0]&0x0f) * 4
+			}
+			if len(inner) < headerLen+8 || inner[headerLen] != requestType || !matchEcho(inner[headerLen:], id, seq) {
+				continue
+			}
+		default:
+			continue
+		}
+		return &icmpReply{RTT: time.Since(start), Peer: peer, Type: msgType, Code: int(reply[1])}, nil
+	}
+}
+
+// traceRoute 发送TTL从1递增的ICMP回显请求，根据各跳返回的TTL超时报文记录路由，
+// 收到目标的回显应答或不可达报文、或达到 -max-hops 时结束
+func (nt *NetworkTool) traceRoute() TraceResult {
+	result := TraceResult{
+		Timestamp: time.Now(),
+		Host:      nt.host,
+		Hops:      []TraceHop{},
+	}
+
+	dst, err := net.ResolveIPAddr(nt.network("ip"), nt.host)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Target = dst.String()
+	v6 := dst.IP.To4() == nil
+
+	conn, err := listenICMP(dst)
+	if err != nil {
+		result.Error = fmt.Sprintf("路由跟踪需要原始套接字权限，请使用 root/管理员权限运行: %v", err)
+		return result
+	}
+	defer conn.Close()
+
+	rawConn, err := conn.(*net.IPConn).SyscallConn()
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	id := os.Getpid() & 0xffff
+	for ttl := 1; ttl <= nt.maxHops; ttl++ {
+		var ttlErr error
+		if err := rawConn.Control(func(fd uintptr) { ttlErr = setSocketTTL(fd, ttl, v6) }); err != nil {
+			ttlErr = err
+		}
+		if ttlErr != nil {
+			result.Error = fmt.Sprintf("设置TTL失败: %v", ttlErr)
+			return result
+		}
+
+		hop := TraceHop{TTL: ttl}
+		seq := int(atomic.AddUint32(&icmpSeq, 1) & 0xffff)
+		reply, err := icmpEcho(conn, dst, id, seq, nt.timeout)
+		if err == nil {
+			hop.Address = reply.Peer.String()
+			hop.RTT = reply.RTT
+		}
+		result.Hops = append(result.Hops, hop)
+
+		if err == nil && reply.Type != icmpTimeExceeded {
+			result.Reached = reply.Type == icmpEchoReply
+			if !result.Reached {
+				result.Error = fmt.Sprintf("目标不可达 (来自 %s, code %d)", reply.Peer, reply.Code)
+			}
+			break
+		}
+	}
+	if !result.Reached && result.Error == "" {
+		result.Error = fmt.Sprintf("%d 跳内未到达目标", nt.maxHops)
+	}
+	return result
+}
+
+func (nt *NetworkTool) outputTraceResult(result TraceResult) {
+	if nt.output == "json" {
+		nt.outputJSON(result)
+		return
+	}
+
+	fmt.Println("========== 路由跟踪结果 ==========")
+	fmt.Printf("时间: %s\n", result.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Printf("主机: %s", result.Host)
+	if result.Target != "" && result.Target != result.Host {
+		fmt.Printf(" (%s)", result.Target)
+	}
+	fmt.Println()
+	for _, hop := range result.Hops {
+		if hop.Address == "" {
+			fmt.Printf("%3d  *\n", hop.TTL)
+		} else {
+			fmt.Printf("%3d  %-40s %v\n", hop.TTL, hop.Address, hop.RTT)
+		}
+	}
+	if result.Reached {
+		fmt.Printf("状态: ✅ 经过 %d 跳到达目标\n", len(result.Hops))
+	} else {
+		fmt.Println("状态: ❌ 未到达目标")
+		if result.Error != "" {
+			fmt.Printf("错误: %s\n", result.Error)
+		}
+	}
+	fmt.Println("================================")
+}
+
+// matchEcho 检查ICMP回显报文的标识符和序号
+func matchEcho(msg []byte, id, seq int) bool {
+	return int(binary.BigEndian.Uint16(msg[4:])) == id && int(binary.BigEndian.Uint16(msg[6:])) == seq
+}
+
+// icmpChecksum 计算ICMP校验和（16位反码和）
+func icmpChecksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+// pingTCPPorts TCP模拟ping时依次尝试的常用端口
+var pingTCPPorts = []string{"80", "443", "22", "21"}
+
+// pingHostTCP 通过TCP连接常用端口模拟ping，用于没有ICMP权限时；
+// 延迟只计算最终成功的那次连接，不包含之前失败端口的耗时
+func (nt *NetworkTool) pingHostTCP() ConnectivityResult {
+	result := ConnectivityResult{
+		Timestamp: time.Now(),
+		Host:      nt.host,
+		Type:      "ping",
+	}
+
+	var err error
+	for _, port := range pingTCPPorts {
+		start := time.Now()
+		var conn net.Conn
+		conn, err = dialTimeout(nt.network("tcp"), net.JoinHostPort(nt.host, port), nt.timeout)
+		result.Latency = time.Since(start)
+		if err == nil {
+			conn.Close()
+			break
+		}
+	}
+
+	if err == nil {
+		result.Success = true
+	} else {
+		result.Success = false
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// probeWithRetries 对端口探测 -retries 次，任意一次成功即视为开放；
+// 延迟统计基于成功的探测，全部失败时基于所有探测
+func (nt *NetworkTool) probeWithRetries(port int, probe func(int) ConnectivityResult) ConnectivityResult {
+	if nt.retries <= 1 {
+		return probe(port)
+	}
+
+	var result ConnectivityResult
+	var all, succeeded []time.Duration
+	for i := 0; i < nt.retries; i++ {
+		attempt := probe(port)
+		all = append(all, attempt.Latency)
+		if attempt.Success {
+			succeeded = append(succeeded, attempt.Latency)
+		}
+		// 保留第一次成功的结果，没有成功时保留最后一次的错误信息
+		if !result.Success {
+			result = attempt
+		}
+	}
+
+	latencies := succeeded
+	if len(latencies) == 0 {
+		latencies = all
+	}
+	stats := &RetryStats{
+		Attempts:     nt.retries,
+		Successes:    len(succeeded),
+		SuccessRatio: float64(len(succeeded)) / float64(nt.retries),
+		MinLatency:   latencies[0],
+		MaxLatency:   latencies[0],
+	}
+	var sum time.Duration
+	for _, l := range latencies {
+		sum += l
+		if l < stats.MinLatency {
+			stats.MinLatency = l
+		}
+		if l > stats.MaxLatency {
+			stats.MaxLatency = l
+		}
+	}
+	stats.AvgLatency = sum / time.Duration(len(latencies))
+	result.Latency = stats.AvgLatency
+	result.Retry = stats
+	return result
+}
+
+func (nt *NetworkTool) testTCPPorts() []ConnectivityResult {
+	var results []ConnectivityResult
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	semaphore := nt.portSemaphore()
+
+	for _, port := range nt.ports {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			result := nt.probeWithRetries(p, nt.testTCPPort)
+
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}(port)
+	}
+
+	wg.Wait()
+	return results
+}
+
+func (nt *NetworkTool) testTCPPort(port int) ConnectivityResult {
+	start := time.Now()
+	address := net.JoinHostPort(nt.host, strconv.Itoa(port))
+
+	conn, err := dialTimeout(nt.network("tcp"), address, nt.timeout)
+
+	result := ConnectivityResult{
+		Timestamp: time.Now(),
+		Host:      nt.host,
+		Port:      port,
+		Type:      "tcp",
+		Latency:   time.Since(start),
+	}
+
+	if err == nil {
+		result.Success = true
+		conn.Close()
+	} else {
+		result.Success = false
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+func (nt *NetworkTool) testUDPPorts() []ConnectivityResult {
+	var results []ConnectivityResult
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+
+	semaphore := nt.portSemaphore()
+
+	for _, port := range nt.ports {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			result := nt.probeWithRetries(p, nt.testUDPPort)
+
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}(port)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// testUDPPort 发送协议相关的探测报文并等待应答：收到应答为open，
+// 收到ICMP端口不可达为closed，收到其他不可达为filtered，超时为open|filtered
+func (nt *NetworkTool) testUDPPort(port int) ConnectivityResult {
+	start := time.Now()
+	address := net.JoinHostPort(nt.host, strconv.Itoa(port))
+
+	result := ConnectivityResult{
+		Timestamp: time.Now(),
+		Host:      nt.host,
+		Port:      port,
+		Type:      "udp",
+	}
+
+	conn, err := dialTimeout(nt.network("udp"), address, nt.timeout)
+	if err != nil {
+		result.Latency = time.Since(start)
+		result.Error = err.Error()
+		return result
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(nt.timeout))
+	// 已连接的UDP套接字会把ICMP差错报文作为后续读写的错误返回
+	if _, err = conn.Write(udpProbePayload(port)); err == nil {
+		buf := make([]byte, 1500)
+		_, err = conn.Read(buf)
+	}
+	result.Latency = time.Since(start)
+
+	switch {
+	case err == nil:
+		result.Success = true
+		result.State = StateOpen
+	case errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET):
+		result.State = StateClosed
+		result.Error = "ICMP 端口不可达"
+	case errors.Is(err, syscall.EHOSTUNREACH) || errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.EACCES):
+		result.State = StateFiltered
+		result.Error = err.Error()
+	default:
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			result.State = StateOpenFiltered
+			result.Error = "等待应答超时"
+		} else {
+			result.State = StateFiltered
+			result.Error = err.Error()
+		}
+	}
+
+	return result
+}
+
+// udpProbePayload 返回端口对应协议的探测报文，未知端口发送空数据报
+func udpProbePayload(port int) []byte {
+	switch port {
+	case 53:
+		// DNS: 查询根域名的NS记录，任何DNS服务器都会应答
+		return []byte{
+			0x13, 0x37, 0x01, 0x00, // ID, 标志(RD)
+			0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // QDCOUNT=1
+			0x00,       // 根域名
+			0x00, 0x02, // QTYPE=NS
+			0x00, 0x01, // QCLASS=IN
+		}
+	case 123:
+		// NTP: v3 客户端请求
+		payload := make([]byte, 48)
+		payload[0] = 0x1b
+		return payload
+	case 161:
+		// SNMP v1: 使用public团体名读取 sysDescr.0
+		return []byte{
+			0x30, 0x29, 0x02, 0x01, 0x00,
+			0x04, 0x06, 'p', 'u', 'b', 'l', 'i', 'c',
+			0xa0, 0x1c, 0x02, 0x04, 0x00, 0x00, 0x00, 0x01,
+			0x02, 0x01, 0x00, 0x02, 0x01, 0x00,
+			0x30, 0x0e, 0x30, 0x0c, 0x06, 0x08, 0x2b, 0x06, 0x01, 0x02, 0x01, 0x01, 0x01, 0x00, 0x05, 0x00,
+		}
+	default:
+		return []byte{}
+	}
+}
+
+// maxHTTPBody HTTP 模式读取响应内容的上限
+const maxHTTPBody = 10 << 20
+
+// httpTarget 为没有协议的 http 模式目标补全 http:// 前缀
+func httpTarget(host string) string {
+	if !strings.Contains(host, "://") {
+		return "http://" + host
+	}
+	return host
+}
+
+// checkHTTP 对 -host 指定的URL发起GET请求（自动跟随重定向），
+// 记录状态码、响应时间、HTTPS证书到期时间以及内容是否包含 -expect；
+// -timeout 限制整个请求（含重定向和读取响应内容）的耗时
+func (nt *NetworkTool) checkHTTP() ConnectivityResult {
+	target := httpTarget(nt.host)
+
+	result := ConnectivityResult{
+		Timestamp: time.Now(),
+		Host:      target,
+		Type:      "http",
+	}
+
+	client := &http.Client{Timeout: nt.timeout}
+	if nt.family != "" {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		dialer := &net.Dialer{}
+		transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, nt.network("tcp"), addr)
+		}
+		client.Transport = transport
+	}
+	start := time.Now()
+	resp, err := client.Get(target)
+	if err != nil {
+		result.Latency = time.Since(start)
+		result.Error = err.Error()
+		return result
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHTTPBody))
+	result.Latency = time.Since(start)
+	result.StatusCode = resp.StatusCode
+	result.FinalURL = resp.Request.URL.String()
+	if resp.TLS != nil && len(resp.TLS.PeerCertificates) > 0 {
+		expiry := resp.TLS.PeerCertificates[0].NotAfter
+		result.CertExpiry = &expiry
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("读取响应失败: %v", err)
+		return result
+	}
+
+	result.Success = resp.StatusCode < 400
+	if resp.StatusCode >= 400 {
+		result.Error = resp.Status
+	}
+	if nt.expect != "" {
+		matched := strings.Contains(string(body), nt.expect)
+		result.BodyMatch = &matched
+		if !matched {
+			result.Success = false
+			if result.Error == "" {
+				result.Error = fmt.Sprintf("响应内容不包含 %q", nt.expect)
+			}
+		}
+	}
+
+	return result
+}
+
+// lookupDNS 查询 A/AAAA/CNAME/MX 记录，-timeout 限制全部查询的总耗时
+func (nt *NetworkTool) lookupDNS() DNSResult {
+	result := DNSResult{
+		Timestamp: time.Now(),
+		Host:      nt.host,
+		Resolver:  "系统默认",
+	}
+
+	resolver := net.DefaultResolver
+	if nt.dnsServer != "" {
+		server := nt.dnsServer
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+		}
+		result.Resolver = server
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, server)
+			},
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), nt.timeout)
+	defer cancel()
+
+	start := time.Now()
+	var errs []error
+	if nt.family != "6" {
+		if ips, err := resolver.LookupIP(ctx, "ip4", nt.host); err == nil {
+			for _, ip := range ips {
+				result.A = append(result.A, ip.String())
+			}
+		} else {
+			errs = append(errs, err)
+		}
+	}
+	if nt.family != "4" {
+		if ips, err := resolver.LookupIP(ctx, "ip6", nt.host); err == nil {
+			for _, ip := range ips {
+				result.AAAA = append(result.AAAA, ip.String())
+			}
+		} else {
+			errs = append(errs, err)
+		}
+	}
+	if cname, err := resolver.LookupCNAME(ctx, nt.host); err == nil {
+		// 没有CNAME时返回的是名称本身
+		if strings.TrimSuffix(cname, ".") != strings.TrimSuffix(nt.host, ".") {
+			result.CNAME = cname
+		}
+	}
+	if mxs, err := resolver.LookupMX(ctx, nt.host); err == nil {
+		for _, mx := range mxs {
+			result.MX = append(result.MX, MXRecord{Host: mx.Host, Pref: mx.Pref})
+		}
+	}
+	result.Latency = time.Since(start)
+
+	if len(result.A) > 0 || len(result.AAAA) > 0 || result.CNAME != "" || len(result.MX) > 0 {
+		result.Success = true
+		result.Status = "NOERROR"
+		return result
+	}
+
+	result.Status = "ERROR"
+	for _, err := range errs {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsTimeout || errors.Is(err, context.DeadlineExceeded) {
+			result.Status = "TIMEOUT"
+			result.Error = err.Error()
+			break
+		}
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			result.Status = "NXDOMAIN"
+		}
+		result.Error = err.Error()
+	}
+	return result
 }
 
-func (nt *NetworkTool) pingHost() ConnectivityResult {
-	start := time.Now()
-
-	// 对于 ICMP ping，我们使用 TCP 连接测试作为替代
-	// 因为 ICMP 需要特殊权限
-	conn, err := net.DialTimeout("tcp", net.JoinHostPort(nt.host, "80"), nt.timeout)
+func (nt *NetworkTool) outputDNSResult(result DNSResult) {
+	if nt.output == "json" {
+		nt.outputJSON(result)
+		return
+	}
 
-	result := ConnectivityResult{
-		Timestamp: time.Now(),
-		Host:      nt.host,
-		Type:      "ping",
-		Latency:   time.Since(start),
+	fmt.Println("========== DNS 解析结果 ==========")
+	fmt.Printf("时间: %s\n", result.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Printf("域名: %s\n", result.Host)
+	fmt.Printf("解析器: %s\n", result.Resolver)
+	fmt.Printf("耗时: %v\n", result.Latency)
+	fmt.Printf("状态: %s\n", result.Status)
+	if result.CNAME != "" {
+		fmt.Printf("CNAME: %s\n", result.CNAME)
+	}
+	for _, ip := range result.A {
+		fmt.Printf("A:     %s\n", ip)
 	}
+	for _, ip := range result.AAAA {
+		fmt.Printf("AAAA:  %s\n", ip)
+	}
+	for _, mx := range result.MX {
+		fmt.Printf("MX:    %s (优先级 %d)\n", mx.Host, mx.Pref)
+	}
+	if !result.Success && result.Error != "" {
+		fmt.Printf("错误: %s\n", result.Error)
+	}
+	fmt.Println("================================")
+}
 
-	if err != nil {
-		// 尝试其他常用端口
-		ports := []string{"443", "22", "21"}
-		for _, port := range ports {
-			conn, err = net.DialTimeout("tcp", net.JoinHostPort(nt.host, port), nt.timeout)
-			if err == nil {
-				break
-			}
-		}
+func (nt *NetworkTool) scanPorts() ScanResult {
+	results := nt.testTCPPorts()
+	if nt.banner {
+		nt.grabBanners(results)
 	}
 
-	if err == nil {
-		result.Success = true
-		if conn != nil {
-			conn.Close()
+	var openPorts, closedPorts []int
+
+	for _, result := range results {
+		if result.Success {
+			openPorts = append(openPorts, result.Port)
+		} else {
+			closedPorts = append(closedPorts, result.Port)
 		}
-	} else {
-		result.Success = false
-		result.Error = err.Error()
 	}
 
-	return result
+	return ScanResult{
+		Timestamp:   time.Now(),
+		Host:        nt.host,
+		TotalPorts:  len(nt.ports),
+		OpenPorts:   openPorts,
+		ClosedPorts: closedPorts,
+		Results:     results,
+	}
 }
 
-func (nt *NetworkTool) testTCPPorts() []ConnectivityResult {
-	var results []ConnectivityResult
-	var wg sync.WaitGroup
-	var mu sync.Mutex
+// httpBannerPorts 发送 HEAD 请求识别 HTTP 服务的端口
+var httpBannerPorts = map[int]bool{80: true, 8000: true, 8008: true, 8080: true, 8888: true}
 
-	semaphore := make(chan struct{}, nt.threads)
+// tlsBannerPorts 先完成TLS握手再发送 HEAD 请求的端口
+var tlsBannerPorts = map[int]bool{443: true, 8443: true}
 
-	for _, port := range nt.ports {
+// maxBannerLen 服务标识最多保留的字符数
+const maxBannerLen = 120
+
+// grabBanners 并发抓取所有开放端口的服务标识，结果写回 results
+func (nt *NetworkTool) grabBanners(results []ConnectivityResult) {
+	var wg sync.WaitGroup
+	semaphore := nt.portSemaphore()
+	for i := range results {
+		if !results[i].Success {
+			continue
+		}
 		wg.Add(1)
-		go func(p int) {
+		go func(r *ConnectivityResult) {
 			defer wg.Done()
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
-
-			result := nt.testTCPPort(p)
-
-			mu.Lock()
-			results = append(results, result)
-			mu.Unlock()
-		}(port)
+			r.Banner = nt.grabBanner(r.Port)
+		}(&results[i])
 	}
-
 	wg.Wait()
-	return results
 }
 
-func (nt *NetworkTool) testTCPPort(port int) ConnectivityResult {
-	start := time.Now()
+// grabBanner 连接端口读取服务标识：HTTP/HTTPS 端口发送 HEAD 请求，
+// 其他端口（SSH、SMTP、FTP等）被动读取服务端主动发送的欢迎信息。
+// 整个过程受 -timeout 限制，读不到任何数据时返回空字符串
+func (nt *NetworkTool) grabBanner(port int) string {
 	address := net.JoinHostPort(nt.host, strconv.Itoa(port))
+	conn, err := dialTimeout(nt.network("tcp"), address, nt.timeout)
+	if err != nil {
+		return ""
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(nt.timeout))
+
+	prefix := ""
+	if tlsBannerPorts[port] {
+		// 只用于识别服务，不校验证书
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: nt.host, InsecureSkipVerify: true})
+		if err := tlsConn.Handshake(); err != nil {
+			return ""
+		}
+		conn = tlsConn
+		prefix = "TLS "
+	}
 
-	conn, err := net.DialTimeout("tcp", address, nt.timeout)
+	if httpBannerPorts[port] || tlsBannerPorts[port] {
+		request := "HEAD / HTTP/1.0\r\nHost: " + nt.host + "\r\nUser-Agent: network_connectivity_tool\r\n\r\n"
+		if _, err := conn.Write([]byte(request)); err != nil {
+			return ""
+		}
+		data, _ := io.ReadAll(io.LimitReader(conn, 4096))
+		if banner := httpBanner(string(data)); banner != "" {
+			return prefix + banner
+		}
+		return ""
+	}
 
-	result := ConnectivityResult{
-		Timestamp: time.Now(),
-		Host:      nt.host,
-		Port:      port,
-		Type:      "tcp",
-		Latency:   time.Since(start),
+	buf := make([]byte, 1024)
+	n, _ := conn.Read(buf)
+	return cleanBanner(string(buf[:n]))
+}
+
+// httpBanner 从HTTP响应头中提取状态行和 Server 字段，如 "HTTP/1.1 200 OK (nginx/1.24.0)"
+func httpBanner(response string) string {
+	lines := strings.Split(response, "\n")
+	status := strings.TrimSpace(lines[0])
+	if !strings.HasPrefix(status, "HTTP/") {
+		return cleanBanner(response)
+	}
+	for _, line := range lines[1:] {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(name, "server") {
+			return cleanBanner(status + " (" + strings.TrimSpace(value) + ")")
+		}
 	}
+	return cleanBanner(status)
+}
 
-	if err == nil {
-		result.Success = true
-		conn.Close()
-	} else {
-		result.Success = false
-		result.Error = err.Error()
+// cleanBanner 取第一行有意义的内容（至少3个可打印字符），
+// 二进制握手包（如MySQL）中的换行字节可能把版本号分到后面的行
+func cleanBanner(raw string) string {
+	first := ""
+	for _, line := range strings.FieldsFunc(raw, func(r rune) bool { return r == '\r' || r == '\n' }) {
+		line = printableBanner(line)
+		if first == "" {
+			first = line
+		}
+		if len([]rune(line)) >= 3 {
+			return line
+		}
 	}
+	return first
+}
 
-	return result
+// printableBanner 把不可打印字符压缩为空格并截断到 maxBannerLen
+func printableBanner(raw string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range raw {
+		if r < 0x20 || r == 0x7f || r == 0xfffd {
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(r)
+	}
+	banner := strings.TrimSpace(b.String())
+	if runes := []rune(banner); len(runes) > maxBannerLen {
+		banner = string(runes[:maxBannerLen]) + "..."
+	}
+	return banner
 }
 
-func (nt *NetworkTool) testUDPPorts() []ConnectivityResult {
+// probeOnce 按当前模式执行一轮探测，结果按端口排序
+func (nt *NetworkTool) probeOnce() []ConnectivityResult {
 	var results []ConnectivityResult
-	var wg sync.WaitGroup
-	var mu sync.Mutex
+	switch r := nt.runMode().(type) {
+	case ConnectivityResult:
+		results = []ConnectivityResult{r}
+	case []ConnectivityResult:
+		results = r
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Port < results[j].Port
+	})
+	return results
+}
 
+// probeAll 并发探测所有主机一轮（最多同时 -threads 个主机，端口探测共享并发槽位），结果按主机输入顺序排列
+func (nt *NetworkTool) probeAll(tools []*NetworkTool) []ConnectivityResult {
+	perHost := make([][]ConnectivityResult, len(tools))
+	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, nt.threads)
-
-	for _, port := range nt.ports {
+	for i, t := range tools {
 		wg.Add(1)
-		go func(p int) {
+		go func(i int, t *NetworkTool) {
 			defer wg.Done()
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
-
-			result := nt.testUDPPort(p)
-
-			mu.Lock()
-			results = append(results, result)
-			mu.Unlock()
-		}(port)
+			perHost[i] = t.probeOnce()
+		}(i, t)
 	}
-
 	wg.Wait()
+
+	var results []ConnectivityResult
+	for _, r := range perHost {
+		results = append(results, r...)
+	}
 	return results
 }
 
-func (nt *NetworkTool) testUDPPort(port int) ConnectivityResult {
-	start := time.Now()
-	address := net.JoinHostPort(nt.host, strconv.Itoa(port))
+// probeTarget 返回结果对应的探测目标，用于汇总统计
+func probeTarget(result ConnectivityResult) string {
+	if result.Port > 0 {
+		return fmt.Sprintf("%s/%s", net.JoinHostPort(result.Host, strconv.Itoa(result.Port)), result.Type)
+	}
+	return result.Host
+}
 
-	conn, err := net.DialTimeout("udp", address, nt.timeout)
+// runMonitor 按 -interval 重复探测 -count 次（-1 为无限次），
+// 控制台模式逐条打印结果，结束或收到 SIGINT 后输出类似 ping 的统计
+func (nt *NetworkTool) runMonitor(tools []*NetworkTool) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
 
-	result := ConnectivityResult{
-		Timestamp: time.Now(),
-		Host:      nt.host,
-		Port:      port,
-		Type:      "udp",
-		Latency:   time.Since(start),
+	hosts := make([]string, len(tools))
+	for i, t := range tools {
+		hosts[i] = t.host
+	}
+	report := MonitorReport{Timestamp: time.Now(), Host: strings.Join(hosts, ","), Mode: nt.mode}
+	if nt.output != "json" {
+		fmt.Printf("开始探测 %s (%s)，间隔 %v，按 Ctrl+C 结束\n", report.Host, strings.ToUpper(nt.mode), nt.interval)
 	}
 
-	if err == nil {
-		result.Success = true
-		// UDP 连接不会立即返回错误，所以我们假设成功
-		// 实际的 UDP 测试需要发送数据包并等待响应
-		conn.Close()
-	} else {
-		result.Success = false
-		result.Error = err.Error()
+loop:
+	for seq := 1; nt.count < 0 || seq <= nt.count; seq++ {
+		for _, result := range nt.probeAll(tools) {
+			report.Results = append(report.Results, result)
+			if nt.output != "json" {
+				printProbeLine(seq, result)
+			}
+		}
+		if nt.count > 0 && seq == nt.count {
+			break
+		}
+		select {
+		case <-sigCh:
+			break loop
+		case <-time.After(nt.interval):
+		}
 	}
 
-	return result
+	report.Summary = summarizeProbes(report.Results)
+	if nt.output == "json" {
+		nt.outputJSON(report)
+		return
+	}
+	fmt.Println()
+	for _, stats := range report.Summary {
+		fmt.Printf("--- %s 探测统计 ---\n", stats.Target)
+		fmt.Printf("已发送 %d 次, 成功 %d 次, %.1f%% 丢失\n", stats.Sent, stats.Received, stats.LossPercent)
+		if stats.Received > 0 {
+			fmt.Printf("延迟 min/avg/max/stddev = %v/%v/%v/%v\n",
+				stats.MinLatency, stats.AvgLatency, stats.MaxLatency, stats.StdDev)
+		}
+	}
 }
 
-func (nt *NetworkTool) scanPorts() ScanResult {
-	results := nt.testTCPPorts()
-
-	var openPorts, closedPorts []int
+// printProbeLine 打印单次探测结果
+func printProbeLine(seq int, result ConnectivityResult) {
+	line := fmt.Sprintf("[%s] %s seq=%d", result.Timestamp.Format("15:04:05"), probeTarget(result), seq)
+	if result.Success {
+		line += fmt.Sprintf(" ✅ 延迟=%v", result.Latency)
+	} else {
+		line += fmt.Sprintf(" ❌ %s", result.Error)
+	}
+	if result.StatusCode > 0 {
+		line += fmt.Sprintf(" 状态码=%d", result.StatusCode)
+	}
+	if result.State != "" {
+		line += fmt.Sprintf(" [%s]", result.State)
+	}
+	fmt.Println(line)
+}
 
+// summarizeProbes 按目标汇总成功率和延迟的 min/avg/max/stddev
+func summarizeProbes(results []ConnectivityResult) []ProbeStats {
+	var order []string
+	latencies := make(map[string][]time.Duration)
+	sent := make(map[string]int)
 	for _, result := range results {
+		target := probeTarget(result)
+		if _, ok := sent[target]; !ok {
+			order = append(order, target)
+		}
+		sent[target]++
 		if result.Success {
-			openPorts = append(openPorts, result.Port)
-		} else {
-			closedPorts = append(closedPorts, result.Port)
+			latencies[target] = append(latencies[target], result.Latency)
 		}
 	}
 
-	return ScanResult{
-		Timestamp:   time.Now(),
-		Host:        nt.host,
-		TotalPorts:  len(nt.ports),
-		OpenPorts:   openPorts,
-		ClosedPorts: closedPorts,
-		Results:     results,
+	summary := make([]ProbeStats, 0, len(order))
+	for _, target := range order {
+		stats := ProbeStats{Target: target, Sent: sent[target], Received: len(latencies[target])}
+		stats.LossPercent = float64(stats.Sent-stats.Received) / float64(stats.Sent) * 100
+		if stats.Received > 0 {
+			var sum float64
+			stats.MinLatency = latencies[target][0]
+			for _, l := range latencies[target] {
+				sum += float64(l)
+				if l < stats.MinLatency {
+					stats.MinLatency = l
+				}
+				if l > stats.MaxLatency {
+					stats.MaxLatency = l
+				}
+			}
+			mean := sum / float64(stats.Received)
+			var variance float64
+			for _, l := range latencies[target] {
+				variance += (float64(l) - mean) * (float64(l) - mean)
+			}
+			stats.AvgLatency = time.Duration(mean)
+			stats.StdDev = time.Duration(math.Sqrt(variance / float64(stats.Received)))
+		}
+		summary = append(summary, stats)
 	}
+	return summary
 }
 
 func (nt *NetworkTool) outputResult(result ConnectivityResult) {
@@ -325,6 +1676,24 @@ func (nt *NetworkTool) outputConsole(result ConnectivityResult) {
 	fmt.Printf("协议: %s\n", strings.ToUpper(result.Type))
 	fmt.Printf("延迟: %v\n", result.Latency)
 
+	if result.StatusCode > 0 {
+		fmt.Printf("状态码: %d\n", result.StatusCode)
+		if result.FinalURL != "" && result.FinalURL != result.Host {
+			fmt.Printf("最终地址: %s\n", result.FinalURL)
+		}
+	}
+	if result.CertExpiry != nil {
+		days := int(time.Until(*result.CertExpiry).Hours() / 24)
+		fmt.Printf("证书到期: %s (剩余 %d 天)\n", result.CertExpiry.Format("2006-01-02 15:04:05"), days)
+	}
+	if result.BodyMatch != nil {
+		if *result.BodyMatch {
+			fmt.Printf("内容匹配: ✅ 包含 %q\n", nt.expect)
+		} else {
+			fmt.Printf("内容匹配: ❌ 不包含 %q\n", nt.expect)
+		}
+	}
+
 	if result.Success {
 		fmt.Println("状态: ✅ 连接成功")
 	} else {
@@ -349,8 +1718,18 @@ func (nt *NetworkTool) outputConsoleResults(results []ConnectivityResult) {
 		}
 	}
 
+	uncertain := 0
+	for _, result := range results {
+		if result.State == StateOpenFiltered {
+			uncertain++
+		}
+	}
+
 	fmt.Printf("开放端口: %d\n", openCount)
-	fmt.Printf("关闭端口: %d\n", len(results)-openCount)
+	if uncertain > 0 {
+		fmt.Printf("开放或被过滤: %d\n", uncertain)
+	}
+	fmt.Printf("关闭端口: %d\n", len(results)-openCount-uncertain)
 	fmt.Println()
 
 	fmt.Println("详细结果:")
@@ -358,10 +1737,20 @@ func (nt *NetworkTool) outputConsoleResults(results []ConnectivityResult) {
 		status := "❌"
 		if result.Success {
 			status = "✅"
+		} else if result.State == StateOpenFiltered {
+			status = "❓"
 		}
 
-		fmt.Printf("%s 端口 %d/%s - 延迟: %v", status, result.Port,
-			strings.ToUpper(result.Type), result.Latency)
+		if r := result.Retry; r != nil {
+			fmt.Printf("%s 端口 %d/%s - 成功 %d/%d - 延迟 min/avg/max: %v/%v/%v", status, result.Port,
+				strings.ToUpper(result.Type), r.Successes, r.Attempts, r.MinLatency, r.AvgLatency, r.MaxLatency)
+		} else {
+			fmt.Printf("%s 端口 %d/%s - 延迟: %v", status, result.Port,
+				strings.ToUpper(result.Type), result.Latency)
+		}
+		if result.State != "" {
+			fmt.Printf(" [%s]", result.State)
+		}
 
 		if !result.Success && nt.verbose {
 			fmt.Printf(" (%s)", result.Error)
@@ -381,9 +1770,19 @@ func (nt *NetworkTool) outputConsoleScan(result ScanResult) {
 	fmt.Println()
 
 	if len(result.OpenPorts) > 0 {
+		banners := make(map[int]string)
+		for _, r := range result.Results {
+			if r.Banner != "" {
+				banners[r.Port] = r.Banner
+			}
+		}
 		fmt.Println("开放端口:")
 		for _, port := range result.OpenPorts {
-			fmt.Printf("  ✅ %d/tcp\n", port)
+			if banner, ok := banners[port]; ok {
+				fmt.Printf("  ✅ %d/tcp  %s\n", port, banner)
+			} else {
+				fmt.Printf("  ✅ %d/tcp\n", port)
+			}
 		}
 		fmt.Println()
 	}
@@ -420,6 +1819,212 @@ func (nt *NetworkTool) outputJSON(data interface{}) {
 	}
 }
 
+// saveResult 将结果写入 dir/<主机>_<模式>_<时间>.json，返回文件路径
+func saveResult(dir string, nt *NetworkTool, result interface{}) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建保存目录失败: %v", err)
+	}
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("JSON 序列化错误: %v", err)
+	}
+
+	// 主机名中的 :/ 等字符（IPv6、URL）不能出现在文件名中
+	host := strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, nt.host)
+	name := fmt.Sprintf("%s_%s_%s.json", host, nt.mode, time.Now().Format("20060102_150405"))
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("保存结果失败: %v", err)
+	}
+	return path, nil
+}
+
+// loadPreviousResult 按当前模式读取之前保存的JSON结果
+func loadPreviousResult(path, mode string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取对比文件失败: %v", err)
+	}
+	mismatch := fmt.Errorf("对比文件 %s 不是 %s 模式的结果", path, mode)
+
+	switch mode {
+	case "scan":
+		var result ScanResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, mismatch
+		}
+		if result.Host == "" || result.TotalPorts == 0 {
+			return nil, mismatch
+		}
+		return result, nil
+	case "tcp", "udp":
+		var results []ConnectivityResult
+		if err := json.Unmarshal(data, &results); err != nil {
+			return nil, mismatch
+		}
+		if len(results) == 0 || results[0].Type != mode {
+			return nil, mismatch
+		}
+		return results, nil
+	case "ping", "http":
+		var result ConnectivityResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, mismatch
+		}
+		if result.Type != mode {
+			return nil, mismatch
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("-diff 不支持 %s 模式", mode)
+}
+
+// resultSnapshot 用于对比的检测结果摘要
+type resultSnapshot struct {
+	timestamp time.Time
+	host      string
+	proto     string
+	reachable bool
+	ports     map[int]string // 端口 -> 状态
+}
+
+// snapshotOf 提取检测结果中的主机、可达性和各端口状态
+func snapshotOf(result interface{}) resultSnapshot {
+	snap := resultSnapshot{reachable: isReachable(result), ports: make(map[int]string)}
+	switch r := result.(type) {
+	case ScanResult:
+		snap.timestamp, snap.host, snap.proto = r.Timestamp, r.Host, "tcp"
+		for _, port := range r.OpenPorts {
+			snap.ports[port] = StateOpen
+		}
+		for _, port := range r.ClosedPorts {
+			snap.ports[port] = StateClosed
+		}
+	case []ConnectivityResult:
+		for _, item := range r {
+			snap.timestamp, snap.host, snap.proto = item.Timestamp, item.Host, item.Type
+			snap.ports[item.Port] = portState(item)
+		}
+	case ConnectivityResult:
+		snap.timestamp, snap.host, snap.proto = r.Timestamp, r.Host, r.Type
+	}
+	return snap
+}
+
+// portState 返回端口状态，TCP 结果没有 state 字段时按是否连接成功判断
+func portState(result ConnectivityResult) string {
+	if result.State != "" {
+		return result.State
+	}
+	if result.Success {
+		return StateOpen
+	}
+	return StateClosed
+}
+
+// PortChange 端口状态变化
+type PortChange struct {
+	Port   int    `json:"port"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// ResultDiff 当前结果与之前保存的结果的差异
+type ResultDiff struct {
+	Host              string       `json:"host"`
+	Mode              string       `json:"mode"`
+	Protocol          string       `json:"protocol"`
+	PreviousTime      time.Time    `json:"previous_time"`
+	CurrentTime       time.Time    `json:"current_time"`
+	NewlyOpen         []int        `json:"newly_open"`
+	NewlyClosed       []int        `json:"newly_closed"`
+	StateChanges      []PortChange `json:"state_changes,omitempty"` // 其他状态之间的变化，如 closed -> filtered
+	UnmatchedPorts    int          `json:"unmatched_ports,omitempty"`
+	PreviousReachable bool         `json:"previous_reachable"`
+	CurrentReachable  bool         `json:"current_reachable"`
+	Changed           bool         `json:"changed"`
+}
+
+// diffResults 对比两次结果；只在其中一次结果中出现的端口不参与对比
+func diffResults(previous, current interface{}, mode string) ResultDiff {
+	before, after := snapshotOf(previous), snapshotOf(current)
+	diff := ResultDiff{
+		Host:              after.host,
+		Mode:              mode,
+		Protocol:          after.proto,
+		PreviousTime:      before.timestamp,
+		CurrentTime:       after.timestamp,
+		NewlyOpen:         []int{},
+		NewlyClosed:       []int{},
+		PreviousReachable: before.reachable,
+		CurrentReachable:  after.reachable,
+	}
+
+	ports := make([]int, 0, len(after.ports))
+	for port := range after.ports {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+
+	matched := 0
+	for _, port := range ports {
+		was, ok := before.ports[port]
+		if !ok {
+			continue
+		}
+		matched++
+		now := after.ports[port]
+		switch {
+		case was == now:
+		case now == StateOpen:
+			diff.NewlyOpen = append(diff.NewlyOpen, port)
+		case was == StateOpen:
+			diff.NewlyClosed = append(diff.NewlyClosed, port)
+		default:
+			diff.StateChanges = append(diff.StateChanges, PortChange{Port: port, Before: was, After: now})
+		}
+	}
+	diff.UnmatchedPorts = len(before.ports) + len(after.ports) - 2*matched
+
+	diff.Changed = len(diff.NewlyOpen) > 0 || len(diff.NewlyClosed) > 0 || len(diff.StateChanges) > 0 ||
+		diff.PreviousReachable != diff.CurrentReachable
+	return diff
+}
+
+// outputConsoleDiff 在控制台输出对比结果
+func outputConsoleDiff(diff ResultDiff) {
+	reachability := map[bool]string{true: "可达", false: "不可达"}
+
+	fmt.Println("========== 与之前结果对比 ==========")
+	fmt.Printf("之前: %s\n", diff.PreviousTime.Format("2006-01-02 15:04:05"))
+	fmt.Printf("当前: %s\n", diff.CurrentTime.Format("2006-01-02 15:04:05"))
+
+	if diff.PreviousReachable != diff.CurrentReachable {
+		fmt.Printf("⚠️  可达性变化: %s -> %s\n", reachability[diff.PreviousReachable], reachability[diff.CurrentReachable])
+	}
+	for _, port := range diff.NewlyOpen {
+		fmt.Printf("  ➕ %d/%s 新开放\n", port, diff.Protocol)
+	}
+	for _, port := range diff.NewlyClosed {
+		fmt.Printf("  ➖ %d/%s 已关闭\n", port, diff.Protocol)
+	}
+	for _, change := range diff.StateChanges {
+		fmt.Printf("  🔄 %d/%s %s -> %s\n", change.Port, diff.Protocol, change.Before, change.After)
+	}
+	if diff.UnmatchedPorts > 0 {
+		fmt.Printf("  %d 个端口只在其中一次结果中出现，未参与对比\n", diff.UnmatchedPorts)
+	}
+	if !diff.Changed {
+		fmt.Printf("✅ 未发现变化 (%s)\n", reachability[diff.CurrentReachable])
+	}
+	fmt.Println("================================")
+}
+
 func parsePorts(portsStr string) []int {
 	var ports []int
 
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 多主机并发检测时，所有主机同时进行的端口探测连接数不应超过 -threads
+func TestMultiHostDialConcurrency(t *testing.T) {
+	const threads = 4
+
+	var mu sync.Mutex
+	active, peak, total := 0, 0, 0
+	saved := dialTimeout
+	dialTimeout = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		mu.Lock()
+		active++
+		total++
+		if active > peak {
+			peak = active
+		}
+		mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return nil, errors.New("connection refused")
+	}
+	defer func() { dialTimeout = saved }()
+
+	for _, mode := range []string{"tcp", "udp", "scan"} {
+		mu.Lock()
+		peak, total = 0, 0
+		mu.Unlock()
+
+		base := &NetworkTool{
+			mode:      mode,
+			ports:     []int{22, 80, 443, 3306, 5432, 6379, 8080, 9000},
+			timeout:   time.Second,
+			threads:   threads,
+			retries:   1,
+			portSlots: make(chan struct{}, threads),
+		}
+		var tools []*NetworkTool
+		for i := 1; i <= 6; i++ {
+			tools = append(tools, base.forHost(fmt.Sprintf("192.0.2.%d", i)))
+		}
+
+		if mode == "scan" {
+			// runMultiHost 会打印报告，测试中丢弃输出
+			stdout := os.Stdout
+			devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+			if err != nil {
+				t.Fatalf("打开 %s 失败: %v", os.DevNull, err)
+			}
+			os.Stdout = devNull
+			base.runMultiHost(tools)
+			os.Stdout = stdout
+			devNull.Close()
+		} else {
+			base.probeAll(tools)
+		}
+
+		mu.Lock()
+		if want := len(tools) * len(base.ports); total != want {
+			t.Errorf("%s 模式共发起 %d 次连接, 期望 %d", mode, total, want)
+		}
+		if peak > threads {
+			t.Errorf("%s 模式同时进行的连接最多 %d 个, 超过 -threads %d", mode, peak, threads)
+		}
+		mu.Unlock()
+	}
+}
+
+// 80 端口失败后改用 443 成功时，延迟只计算 443 的连接
+func TestPingHostTCPLatencyFromSuccessfulDial(t *testing.T) {
+	const slowFailure = 100 * time.Millisecond
+
+	saved := dialTimeout
+	dialTimeout = func(network, address string, timeout time.Duration) (net.Conn, error) {
+		_, port, _ := net.SplitHostPort(address)
+		if port == "80" {
+			time.Sleep(slowFailure)
+			return nil, errors.New("connection timed out")
+		}
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	}
+	defer func() { dialTimeout = saved }()
+
+	nt := &NetworkTool{host: "192.0.2.1", timeout: time.Second}
+	result := nt.pingHostTCP()
+	if !result.Success {
+		t.Fatalf("pingHostTCP 失败: %s", result.Error)
+	}
+	if result.Latency >= slowFailure {
+		t.Errorf("延迟 = %v, 包含了失败的 80 端口连接耗时 (>= %v)", result.Latency, slowFailure)
+	}
+}
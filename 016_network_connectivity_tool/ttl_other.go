@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !windows
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!windows
+
+package main
+
+import "fmt"
+
+// setSocketTTL 当前平台不支持设置TTL
+func setSocketTTL(fd uintptr, ttl int, v6 bool) error {
+	return fmt.Errorf("当前平台不支持设置TTL")
+}
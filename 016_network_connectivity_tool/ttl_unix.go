@@ -0,0 +1,14 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+// +build linux darwin freebsd netbsd openbsd
+
+package main
+
+import "syscall"
+
+// setSocketTTL 设置套接字发出报文的TTL（IPv6为跳数限制）
+func setSocketTTL(fd uintptr, ttl int, v6 bool) error {
+	if v6 {
+		return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_UNICAST_HOPS, ttl)
+	}
+	return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TTL, ttl)
+}